@@ -36,11 +36,31 @@ type RealWorldClient interface {
 	UpdateArticle(ctx context.Context, in *UpdateArticleRequest, opts ...grpc.CallOption) (*SingleArticleReply, error)
 	DeleteArticle(ctx context.Context, in *DeleteArticleRequest, opts ...grpc.CallOption) (*SingleArticleReply, error)
 	AddComment(ctx context.Context, in *AddCommentRequest, opts ...grpc.CallOption) (*SingleCommentReply, error)
-	GetComment(ctx context.Context, in *GetCommentRequest, opts ...grpc.CallOption) (*SingleCommentReply, error)
+	GetComment(ctx context.Context, in *GetCommentRequest, opts ...grpc.CallOption) (*MultipleCommentsReply, error)
 	DeleteComment(ctx context.Context, in *DeleteCommentRequest, opts ...grpc.CallOption) (*SingleCommentReply, error)
 	FavoriteArticle(ctx context.Context, in *FavoriteArticleRequest, opts ...grpc.CallOption) (*SingleArticleReply, error)
 	UnFavoriteArticle(ctx context.Context, in *UnFavoriteArticleRequest, opts ...grpc.CallOption) (*SingleArticleReply, error)
 	GetTags(ctx context.Context, in *GetTagsRequest, opts ...grpc.CallOption) (*TagListReply, error)
+	VerifyEmail(ctx context.Context, in *VerifyEmailRequest, opts ...grpc.CallOption) (*VerifyEmailReply, error)
+	ResendVerification(ctx context.Context, in *ResendVerificationRequest, opts ...grpc.CallOption) (*VerifyEmailReply, error)
+	RequestPasswordReset(ctx context.Context, in *PasswordResetRequest, opts ...grpc.CallOption) (*PasswordResetReply, error)
+	ConfirmPasswordReset(ctx context.Context, in *ConfirmPasswordResetRequest, opts ...grpc.CallOption) (*PasswordResetReply, error)
+	ListNotifications(ctx context.Context, in *ListNotificationsRequest, opts ...grpc.CallOption) (*MultipleNotificationsReply, error)
+	GetUnreadNotificationCount(ctx context.Context, in *GetUnreadNotificationCountRequest, opts ...grpc.CallOption) (*UnreadNotificationCountReply, error)
+	MarkNotificationsRead(ctx context.Context, in *MarkNotificationsReadRequest, opts ...grpc.CallOption) (*UnreadNotificationCountReply, error)
+	MarkAllNotificationsRead(ctx context.Context, in *MarkAllNotificationsReadRequest, opts ...grpc.CallOption) (*UnreadNotificationCountReply, error)
+	GetNotificationPreferences(ctx context.Context, in *GetNotificationPreferencesRequest, opts ...grpc.CallOption) (*NotificationPreferencesReply, error)
+	UpdateNotificationPreferences(ctx context.Context, in *UpdateNotificationPreferencesRequest, opts ...grpc.CallOption) (*NotificationPreferencesReply, error)
+	// 实时事件。HTTP 客户端使用 GET /api/stream（Server-Sent Events）或
+	// GET /api/stream/ws（WebSocket），查询参数与 StreamEventsRequest 相同；
+	// 不能设置请求头时，用 POST /api/stream/tickets 换取一次性的 ?ticket=
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (RealWorld_StreamEventsClient, error)
+	CreateWebhook(ctx context.Context, in *CreateWebhookRequest, opts ...grpc.CallOption) (*WebhookReply, error)
+	ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*MultipleWebhooksReply, error)
+	DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*DeleteWebhookReply, error)
+	ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*MultipleWebhookDeliveriesReply, error)
+	// 立即发送一个 webhook.test 事件并返回结果，不重试
+	TestWebhook(ctx context.Context, in *TestWebhookRequest, opts ...grpc.CallOption) (*WebhookDeliveryReply, error)
 }
 
 type realWorldClient struct {
@@ -177,8 +197,8 @@ func (c *realWorldClient) AddComment(ctx context.Context, in *AddCommentRequest,
 	return out, nil
 }
 
-func (c *realWorldClient) GetComment(ctx context.Context, in *GetCommentRequest, opts ...grpc.CallOption) (*SingleCommentReply, error) {
-	out := new(SingleCommentReply)
+func (c *realWorldClient) GetComment(ctx context.Context, in *GetCommentRequest, opts ...grpc.CallOption) (*MultipleCommentsReply, error) {
+	out := new(MultipleCommentsReply)
 	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/GetComment", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -222,6 +242,173 @@ func (c *realWorldClient) GetTags(ctx context.Context, in *GetTagsRequest, opts
 	return out, nil
 }
 
+func (c *realWorldClient) VerifyEmail(ctx context.Context, in *VerifyEmailRequest, opts ...grpc.CallOption) (*VerifyEmailReply, error) {
+	out := new(VerifyEmailReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/VerifyEmail", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) ResendVerification(ctx context.Context, in *ResendVerificationRequest, opts ...grpc.CallOption) (*VerifyEmailReply, error) {
+	out := new(VerifyEmailReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/ResendVerification", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) RequestPasswordReset(ctx context.Context, in *PasswordResetRequest, opts ...grpc.CallOption) (*PasswordResetReply, error) {
+	out := new(PasswordResetReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/RequestPasswordReset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) ConfirmPasswordReset(ctx context.Context, in *ConfirmPasswordResetRequest, opts ...grpc.CallOption) (*PasswordResetReply, error) {
+	out := new(PasswordResetReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/ConfirmPasswordReset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) ListNotifications(ctx context.Context, in *ListNotificationsRequest, opts ...grpc.CallOption) (*MultipleNotificationsReply, error) {
+	out := new(MultipleNotificationsReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/ListNotifications", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) GetUnreadNotificationCount(ctx context.Context, in *GetUnreadNotificationCountRequest, opts ...grpc.CallOption) (*UnreadNotificationCountReply, error) {
+	out := new(UnreadNotificationCountReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/GetUnreadNotificationCount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) MarkNotificationsRead(ctx context.Context, in *MarkNotificationsReadRequest, opts ...grpc.CallOption) (*UnreadNotificationCountReply, error) {
+	out := new(UnreadNotificationCountReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/MarkNotificationsRead", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) MarkAllNotificationsRead(ctx context.Context, in *MarkAllNotificationsReadRequest, opts ...grpc.CallOption) (*UnreadNotificationCountReply, error) {
+	out := new(UnreadNotificationCountReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/MarkAllNotificationsRead", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) GetNotificationPreferences(ctx context.Context, in *GetNotificationPreferencesRequest, opts ...grpc.CallOption) (*NotificationPreferencesReply, error) {
+	out := new(NotificationPreferencesReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/GetNotificationPreferences", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) UpdateNotificationPreferences(ctx context.Context, in *UpdateNotificationPreferencesRequest, opts ...grpc.CallOption) (*NotificationPreferencesReply, error) {
+	out := new(NotificationPreferencesReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/UpdateNotificationPreferences", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (RealWorld_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RealWorld_ServiceDesc.Streams[0], "/realworld.v1.RealWorld/StreamEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &realWorldStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type RealWorld_StreamEventsClient interface {
+	Recv() (*StreamEvent, error)
+	grpc.ClientStream
+}
+
+type realWorldStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *realWorldStreamEventsClient) Recv() (*StreamEvent, error) {
+	m := new(StreamEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *realWorldClient) CreateWebhook(ctx context.Context, in *CreateWebhookRequest, opts ...grpc.CallOption) (*WebhookReply, error) {
+	out := new(WebhookReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/CreateWebhook", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*MultipleWebhooksReply, error) {
+	out := new(MultipleWebhooksReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/ListWebhooks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*DeleteWebhookReply, error) {
+	out := new(DeleteWebhookReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/DeleteWebhook", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*MultipleWebhookDeliveriesReply, error) {
+	out := new(MultipleWebhookDeliveriesReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/ListWebhookDeliveries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *realWorldClient) TestWebhook(ctx context.Context, in *TestWebhookRequest, opts ...grpc.CallOption) (*WebhookDeliveryReply, error) {
+	out := new(WebhookDeliveryReply)
+	err := c.cc.Invoke(ctx, "/realworld.v1.RealWorld/TestWebhook", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RealWorldServer is the server API for RealWorld service.
 // All implementations must embed UnimplementedRealWorldServer
 // for forward compatibility
@@ -240,11 +427,31 @@ type RealWorldServer interface {
 	UpdateArticle(context.Context, *UpdateArticleRequest) (*SingleArticleReply, error)
 	DeleteArticle(context.Context, *DeleteArticleRequest) (*SingleArticleReply, error)
 	AddComment(context.Context, *AddCommentRequest) (*SingleCommentReply, error)
-	GetComment(context.Context, *GetCommentRequest) (*SingleCommentReply, error)
+	GetComment(context.Context, *GetCommentRequest) (*MultipleCommentsReply, error)
 	DeleteComment(context.Context, *DeleteCommentRequest) (*SingleCommentReply, error)
 	FavoriteArticle(context.Context, *FavoriteArticleRequest) (*SingleArticleReply, error)
 	UnFavoriteArticle(context.Context, *UnFavoriteArticleRequest) (*SingleArticleReply, error)
 	GetTags(context.Context, *GetTagsRequest) (*TagListReply, error)
+	VerifyEmail(context.Context, *VerifyEmailRequest) (*VerifyEmailReply, error)
+	ResendVerification(context.Context, *ResendVerificationRequest) (*VerifyEmailReply, error)
+	RequestPasswordReset(context.Context, *PasswordResetRequest) (*PasswordResetReply, error)
+	ConfirmPasswordReset(context.Context, *ConfirmPasswordResetRequest) (*PasswordResetReply, error)
+	ListNotifications(context.Context, *ListNotificationsRequest) (*MultipleNotificationsReply, error)
+	GetUnreadNotificationCount(context.Context, *GetUnreadNotificationCountRequest) (*UnreadNotificationCountReply, error)
+	MarkNotificationsRead(context.Context, *MarkNotificationsReadRequest) (*UnreadNotificationCountReply, error)
+	MarkAllNotificationsRead(context.Context, *MarkAllNotificationsReadRequest) (*UnreadNotificationCountReply, error)
+	GetNotificationPreferences(context.Context, *GetNotificationPreferencesRequest) (*NotificationPreferencesReply, error)
+	UpdateNotificationPreferences(context.Context, *UpdateNotificationPreferencesRequest) (*NotificationPreferencesReply, error)
+	// 实时事件。HTTP 客户端使用 GET /api/stream（Server-Sent Events）或
+	// GET /api/stream/ws（WebSocket），查询参数与 StreamEventsRequest 相同；
+	// 不能设置请求头时，用 POST /api/stream/tickets 换取一次性的 ?ticket=
+	StreamEvents(*StreamEventsRequest, RealWorld_StreamEventsServer) error
+	CreateWebhook(context.Context, *CreateWebhookRequest) (*WebhookReply, error)
+	ListWebhooks(context.Context, *ListWebhooksRequest) (*MultipleWebhooksReply, error)
+	DeleteWebhook(context.Context, *DeleteWebhookRequest) (*DeleteWebhookReply, error)
+	ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*MultipleWebhookDeliveriesReply, error)
+	// 立即发送一个 webhook.test 事件并返回结果，不重试
+	TestWebhook(context.Context, *TestWebhookRequest) (*WebhookDeliveryReply, error)
 	mustEmbedUnimplementedRealWorldServer()
 }
 
@@ -294,7 +501,7 @@ func (UnimplementedRealWorldServer) DeleteArticle(context.Context, *DeleteArticl
 func (UnimplementedRealWorldServer) AddComment(context.Context, *AddCommentRequest) (*SingleCommentReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddComment not implemented")
 }
-func (UnimplementedRealWorldServer) GetComment(context.Context, *GetCommentRequest) (*SingleCommentReply, error) {
+func (UnimplementedRealWorldServer) GetComment(context.Context, *GetCommentRequest) (*MultipleCommentsReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetComment not implemented")
 }
 func (UnimplementedRealWorldServer) DeleteComment(context.Context, *DeleteCommentRequest) (*SingleCommentReply, error) {
@@ -309,6 +516,54 @@ func (UnimplementedRealWorldServer) UnFavoriteArticle(context.Context, *UnFavori
 func (UnimplementedRealWorldServer) GetTags(context.Context, *GetTagsRequest) (*TagListReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTags not implemented")
 }
+func (UnimplementedRealWorldServer) VerifyEmail(context.Context, *VerifyEmailRequest) (*VerifyEmailReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyEmail not implemented")
+}
+func (UnimplementedRealWorldServer) ResendVerification(context.Context, *ResendVerificationRequest) (*VerifyEmailReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResendVerification not implemented")
+}
+func (UnimplementedRealWorldServer) RequestPasswordReset(context.Context, *PasswordResetRequest) (*PasswordResetReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestPasswordReset not implemented")
+}
+func (UnimplementedRealWorldServer) ConfirmPasswordReset(context.Context, *ConfirmPasswordResetRequest) (*PasswordResetReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmPasswordReset not implemented")
+}
+func (UnimplementedRealWorldServer) ListNotifications(context.Context, *ListNotificationsRequest) (*MultipleNotificationsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNotifications not implemented")
+}
+func (UnimplementedRealWorldServer) GetUnreadNotificationCount(context.Context, *GetUnreadNotificationCountRequest) (*UnreadNotificationCountReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUnreadNotificationCount not implemented")
+}
+func (UnimplementedRealWorldServer) MarkNotificationsRead(context.Context, *MarkNotificationsReadRequest) (*UnreadNotificationCountReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkNotificationsRead not implemented")
+}
+func (UnimplementedRealWorldServer) MarkAllNotificationsRead(context.Context, *MarkAllNotificationsReadRequest) (*UnreadNotificationCountReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkAllNotificationsRead not implemented")
+}
+func (UnimplementedRealWorldServer) GetNotificationPreferences(context.Context, *GetNotificationPreferencesRequest) (*NotificationPreferencesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNotificationPreferences not implemented")
+}
+func (UnimplementedRealWorldServer) UpdateNotificationPreferences(context.Context, *UpdateNotificationPreferencesRequest) (*NotificationPreferencesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateNotificationPreferences not implemented")
+}
+func (UnimplementedRealWorldServer) StreamEvents(*StreamEventsRequest, RealWorld_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedRealWorldServer) CreateWebhook(context.Context, *CreateWebhookRequest) (*WebhookReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWebhook not implemented")
+}
+func (UnimplementedRealWorldServer) ListWebhooks(context.Context, *ListWebhooksRequest) (*MultipleWebhooksReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhooks not implemented")
+}
+func (UnimplementedRealWorldServer) DeleteWebhook(context.Context, *DeleteWebhookRequest) (*DeleteWebhookReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteWebhook not implemented")
+}
+func (UnimplementedRealWorldServer) ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*MultipleWebhookDeliveriesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookDeliveries not implemented")
+}
+func (UnimplementedRealWorldServer) TestWebhook(context.Context, *TestWebhookRequest) (*WebhookDeliveryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TestWebhook not implemented")
+}
 func (UnimplementedRealWorldServer) mustEmbedUnimplementedRealWorldServer() {}
 
 // UnsafeRealWorldServer may be embedded to opt out of forward compatibility for this service.
@@ -664,6 +919,297 @@ func _RealWorld_GetTags_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RealWorld_VerifyEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyEmailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).VerifyEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/VerifyEmail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).VerifyEmail(ctx, req.(*VerifyEmailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_ResendVerification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResendVerificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).ResendVerification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/ResendVerification",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).ResendVerification(ctx, req.(*ResendVerificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_RequestPasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordResetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).RequestPasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/RequestPasswordReset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).RequestPasswordReset(ctx, req.(*PasswordResetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_ConfirmPasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmPasswordResetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).ConfirmPasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/ConfirmPasswordReset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).ConfirmPasswordReset(ctx, req.(*ConfirmPasswordResetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_ListNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNotificationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).ListNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/ListNotifications",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).ListNotifications(ctx, req.(*ListNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_GetUnreadNotificationCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUnreadNotificationCountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).GetUnreadNotificationCount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/GetUnreadNotificationCount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).GetUnreadNotificationCount(ctx, req.(*GetUnreadNotificationCountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_MarkNotificationsRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkNotificationsReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).MarkNotificationsRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/MarkNotificationsRead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).MarkNotificationsRead(ctx, req.(*MarkNotificationsReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_MarkAllNotificationsRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkAllNotificationsReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).MarkAllNotificationsRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/MarkAllNotificationsRead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).MarkAllNotificationsRead(ctx, req.(*MarkAllNotificationsReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_GetNotificationPreferences_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNotificationPreferencesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).GetNotificationPreferences(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/GetNotificationPreferences",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).GetNotificationPreferences(ctx, req.(*GetNotificationPreferencesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_UpdateNotificationPreferences_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateNotificationPreferencesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).UpdateNotificationPreferences(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/UpdateNotificationPreferences",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).UpdateNotificationPreferences(ctx, req.(*UpdateNotificationPreferencesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RealWorldServer).StreamEvents(m, &realWorldStreamEventsServer{stream})
+}
+
+type RealWorld_StreamEventsServer interface {
+	Send(*StreamEvent) error
+	grpc.ServerStream
+}
+
+type realWorldStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *realWorldStreamEventsServer) Send(m *StreamEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _RealWorld_CreateWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).CreateWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/CreateWebhook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).CreateWebhook(ctx, req.(*CreateWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_ListWebhooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).ListWebhooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/ListWebhooks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).ListWebhooks(ctx, req.(*ListWebhooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_DeleteWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).DeleteWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/DeleteWebhook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).DeleteWebhook(ctx, req.(*DeleteWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_ListWebhookDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).ListWebhookDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/ListWebhookDeliveries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).ListWebhookDeliveries(ctx, req.(*ListWebhookDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RealWorld_TestWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TestWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RealWorldServer).TestWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/realworld.v1.RealWorld/TestWebhook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RealWorldServer).TestWebhook(ctx, req.(*TestWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RealWorld_ServiceDesc is the grpc.ServiceDesc for RealWorld service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -747,7 +1293,73 @@ var RealWorld_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTags",
 			Handler:    _RealWorld_GetTags_Handler,
 		},
+		{
+			MethodName: "VerifyEmail",
+			Handler:    _RealWorld_VerifyEmail_Handler,
+		},
+		{
+			MethodName: "ResendVerification",
+			Handler:    _RealWorld_ResendVerification_Handler,
+		},
+		{
+			MethodName: "RequestPasswordReset",
+			Handler:    _RealWorld_RequestPasswordReset_Handler,
+		},
+		{
+			MethodName: "ConfirmPasswordReset",
+			Handler:    _RealWorld_ConfirmPasswordReset_Handler,
+		},
+		{
+			MethodName: "ListNotifications",
+			Handler:    _RealWorld_ListNotifications_Handler,
+		},
+		{
+			MethodName: "GetUnreadNotificationCount",
+			Handler:    _RealWorld_GetUnreadNotificationCount_Handler,
+		},
+		{
+			MethodName: "MarkNotificationsRead",
+			Handler:    _RealWorld_MarkNotificationsRead_Handler,
+		},
+		{
+			MethodName: "MarkAllNotificationsRead",
+			Handler:    _RealWorld_MarkAllNotificationsRead_Handler,
+		},
+		{
+			MethodName: "GetNotificationPreferences",
+			Handler:    _RealWorld_GetNotificationPreferences_Handler,
+		},
+		{
+			MethodName: "UpdateNotificationPreferences",
+			Handler:    _RealWorld_UpdateNotificationPreferences_Handler,
+		},
+		{
+			MethodName: "CreateWebhook",
+			Handler:    _RealWorld_CreateWebhook_Handler,
+		},
+		{
+			MethodName: "ListWebhooks",
+			Handler:    _RealWorld_ListWebhooks_Handler,
+		},
+		{
+			MethodName: "DeleteWebhook",
+			Handler:    _RealWorld_DeleteWebhook_Handler,
+		},
+		{
+			MethodName: "ListWebhookDeliveries",
+			Handler:    _RealWorld_ListWebhookDeliveries_Handler,
+		},
+		{
+			MethodName: "TestWebhook",
+			Handler:    _RealWorld_TestWebhook_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _RealWorld_StreamEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "realworld/v1/realworld.proto",
 }
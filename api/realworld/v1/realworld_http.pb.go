@@ -20,45 +20,76 @@ var _ = binding.EncodeURL
 const _ = http.SupportPackageIsVersion1
 
 const OperationRealWorldAddComment = "/realworld.v1.RealWorld/AddComment"
+const OperationRealWorldConfirmPasswordReset = "/realworld.v1.RealWorld/ConfirmPasswordReset"
 const OperationRealWorldCreateArticle = "/realworld.v1.RealWorld/CreateArticle"
+const OperationRealWorldCreateWebhook = "/realworld.v1.RealWorld/CreateWebhook"
 const OperationRealWorldDeleteArticle = "/realworld.v1.RealWorld/DeleteArticle"
 const OperationRealWorldDeleteComment = "/realworld.v1.RealWorld/DeleteComment"
+const OperationRealWorldDeleteWebhook = "/realworld.v1.RealWorld/DeleteWebhook"
 const OperationRealWorldFavoriteArticle = "/realworld.v1.RealWorld/FavoriteArticle"
 const OperationRealWorldFeedListArticles = "/realworld.v1.RealWorld/FeedListArticles"
 const OperationRealWorldFollowUser = "/realworld.v1.RealWorld/FollowUser"
 const OperationRealWorldGetArticle = "/realworld.v1.RealWorld/GetArticle"
 const OperationRealWorldGetComment = "/realworld.v1.RealWorld/GetComment"
 const OperationRealWorldGetCurrentUser = "/realworld.v1.RealWorld/GetCurrentUser"
+const OperationRealWorldGetNotificationPreferences = "/realworld.v1.RealWorld/GetNotificationPreferences"
 const OperationRealWorldGetProfile = "/realworld.v1.RealWorld/GetProfile"
 const OperationRealWorldGetTags = "/realworld.v1.RealWorld/GetTags"
+const OperationRealWorldGetUnreadNotificationCount = "/realworld.v1.RealWorld/GetUnreadNotificationCount"
 const OperationRealWorldListArticles = "/realworld.v1.RealWorld/ListArticles"
+const OperationRealWorldListNotifications = "/realworld.v1.RealWorld/ListNotifications"
+const OperationRealWorldListWebhookDeliveries = "/realworld.v1.RealWorld/ListWebhookDeliveries"
+const OperationRealWorldListWebhooks = "/realworld.v1.RealWorld/ListWebhooks"
 const OperationRealWorldLogin = "/realworld.v1.RealWorld/Login"
+const OperationRealWorldMarkAllNotificationsRead = "/realworld.v1.RealWorld/MarkAllNotificationsRead"
+const OperationRealWorldMarkNotificationsRead = "/realworld.v1.RealWorld/MarkNotificationsRead"
 const OperationRealWorldRegister = "/realworld.v1.RealWorld/Register"
+const OperationRealWorldRequestPasswordReset = "/realworld.v1.RealWorld/RequestPasswordReset"
+const OperationRealWorldResendVerification = "/realworld.v1.RealWorld/ResendVerification"
+const OperationRealWorldTestWebhook = "/realworld.v1.RealWorld/TestWebhook"
 const OperationRealWorldUnFavoriteArticle = "/realworld.v1.RealWorld/UnFavoriteArticle"
 const OperationRealWorldUnFollowUser = "/realworld.v1.RealWorld/UnFollowUser"
 const OperationRealWorldUpdateArticle = "/realworld.v1.RealWorld/UpdateArticle"
+const OperationRealWorldUpdateNotificationPreferences = "/realworld.v1.RealWorld/UpdateNotificationPreferences"
 const OperationRealWorldUpdateUser = "/realworld.v1.RealWorld/UpdateUser"
+const OperationRealWorldVerifyEmail = "/realworld.v1.RealWorld/VerifyEmail"
 
 type RealWorldHTTPServer interface {
 	AddComment(context.Context, *AddCommentRequest) (*SingleCommentReply, error)
+	ConfirmPasswordReset(context.Context, *ConfirmPasswordResetRequest) (*PasswordResetReply, error)
 	CreateArticle(context.Context, *CreateArticleRequest) (*SingleArticleReply, error)
+	CreateWebhook(context.Context, *CreateWebhookRequest) (*WebhookReply, error)
 	DeleteArticle(context.Context, *DeleteArticleRequest) (*SingleArticleReply, error)
 	DeleteComment(context.Context, *DeleteCommentRequest) (*SingleCommentReply, error)
+	DeleteWebhook(context.Context, *DeleteWebhookRequest) (*DeleteWebhookReply, error)
 	FavoriteArticle(context.Context, *FavoriteArticleRequest) (*SingleArticleReply, error)
 	FeedListArticles(context.Context, *FeedArticlesRequest) (*MultipleArticlesReply, error)
 	FollowUser(context.Context, *FollowUserRequest) (*ProfileReply, error)
 	GetArticle(context.Context, *GetArticleRequest) (*SingleArticleReply, error)
-	GetComment(context.Context, *GetCommentRequest) (*SingleCommentReply, error)
+	GetComment(context.Context, *GetCommentRequest) (*MultipleCommentsReply, error)
 	GetCurrentUser(context.Context, *GetCurrentRequest) (*UserReply, error)
+	GetNotificationPreferences(context.Context, *GetNotificationPreferencesRequest) (*NotificationPreferencesReply, error)
 	GetProfile(context.Context, *GetProfileRequest) (*GetProfileReply, error)
 	GetTags(context.Context, *GetTagsRequest) (*TagListReply, error)
+	GetUnreadNotificationCount(context.Context, *GetUnreadNotificationCountRequest) (*UnreadNotificationCountReply, error)
 	ListArticles(context.Context, *ListArticlesRequest) (*MultipleArticlesReply, error)
+	ListNotifications(context.Context, *ListNotificationsRequest) (*MultipleNotificationsReply, error)
+	ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*MultipleWebhookDeliveriesReply, error)
+	ListWebhooks(context.Context, *ListWebhooksRequest) (*MultipleWebhooksReply, error)
 	Login(context.Context, *LoginRequest) (*LoginReply, error)
+	MarkAllNotificationsRead(context.Context, *MarkAllNotificationsReadRequest) (*UnreadNotificationCountReply, error)
+	MarkNotificationsRead(context.Context, *MarkNotificationsReadRequest) (*UnreadNotificationCountReply, error)
 	Register(context.Context, *RegisterRequest) (*UserReply, error)
+	RequestPasswordReset(context.Context, *PasswordResetRequest) (*PasswordResetReply, error)
+	ResendVerification(context.Context, *ResendVerificationRequest) (*VerifyEmailReply, error)
+	// TestWebhook 立即发送一个 webhook.test 事件并返回结果，不重试
+	TestWebhook(context.Context, *TestWebhookRequest) (*WebhookDeliveryReply, error)
 	UnFavoriteArticle(context.Context, *UnFavoriteArticleRequest) (*SingleArticleReply, error)
 	UnFollowUser(context.Context, *UnFollowUserRequest) (*ProfileReply, error)
 	UpdateArticle(context.Context, *UpdateArticleRequest) (*SingleArticleReply, error)
+	UpdateNotificationPreferences(context.Context, *UpdateNotificationPreferencesRequest) (*NotificationPreferencesReply, error)
 	UpdateUser(context.Context, *UpdateUserRequest) (*UserReply, error)
+	VerifyEmail(context.Context, *VerifyEmailRequest) (*VerifyEmailReply, error)
 }
 
 func RegisterRealWorldHTTPServer(s *http.Server, srv RealWorldHTTPServer) {
@@ -82,6 +113,21 @@ func RegisterRealWorldHTTPServer(s *http.Server, srv RealWorldHTTPServer) {
 	r.POST("/api/articles/{slug}/favorite", _RealWorld_FavoriteArticle0_HTTP_Handler(srv))
 	r.DELETE("/api/articles/{slug}/favorite", _RealWorld_UnFavoriteArticle0_HTTP_Handler(srv))
 	r.GET("/api/tags", _RealWorld_GetTags0_HTTP_Handler(srv))
+	r.POST("/api/users/verify", _RealWorld_VerifyEmail0_HTTP_Handler(srv))
+	r.POST("/api/users/verify/resend", _RealWorld_ResendVerification0_HTTP_Handler(srv))
+	r.POST("/api/users/password/reset", _RealWorld_RequestPasswordReset0_HTTP_Handler(srv))
+	r.POST("/api/users/password/reset/confirm", _RealWorld_ConfirmPasswordReset0_HTTP_Handler(srv))
+	r.GET("/api/notifications", _RealWorld_ListNotifications0_HTTP_Handler(srv))
+	r.GET("/api/notifications/unread-count", _RealWorld_GetUnreadNotificationCount0_HTTP_Handler(srv))
+	r.POST("/api/notifications/read", _RealWorld_MarkNotificationsRead0_HTTP_Handler(srv))
+	r.POST("/api/notifications/read-all", _RealWorld_MarkAllNotificationsRead0_HTTP_Handler(srv))
+	r.GET("/api/notifications/preferences", _RealWorld_GetNotificationPreferences0_HTTP_Handler(srv))
+	r.PUT("/api/notifications/preferences", _RealWorld_UpdateNotificationPreferences0_HTTP_Handler(srv))
+	r.POST("/api/webhooks", _RealWorld_CreateWebhook0_HTTP_Handler(srv))
+	r.GET("/api/webhooks", _RealWorld_ListWebhooks0_HTTP_Handler(srv))
+	r.DELETE("/api/webhooks/{id}", _RealWorld_DeleteWebhook0_HTTP_Handler(srv))
+	r.GET("/api/webhooks/{id}/deliveries", _RealWorld_ListWebhookDeliveries0_HTTP_Handler(srv))
+	r.POST("/api/webhooks/{id}/test", _RealWorld_TestWebhook0_HTTP_Handler(srv))
 }
 
 func _RealWorld_Login0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
@@ -409,7 +455,7 @@ func _RealWorld_GetComment0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.
 		if err != nil {
 			return err
 		}
-		reply := out.(*SingleCommentReply)
+		reply := out.(*MultipleCommentsReply)
 		return ctx.Result(200, reply)
 	}
 }
@@ -502,26 +548,362 @@ func _RealWorld_GetTags0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Con
 	}
 }
 
+func _RealWorld_VerifyEmail0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in VerifyEmailRequest
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldVerifyEmail)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.VerifyEmail(ctx, req.(*VerifyEmailRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*VerifyEmailReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_ResendVerification0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in ResendVerificationRequest
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldResendVerification)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.ResendVerification(ctx, req.(*ResendVerificationRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*VerifyEmailReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_RequestPasswordReset0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in PasswordResetRequest
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldRequestPasswordReset)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.RequestPasswordReset(ctx, req.(*PasswordResetRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*PasswordResetReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_ConfirmPasswordReset0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in ConfirmPasswordResetRequest
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldConfirmPasswordReset)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.ConfirmPasswordReset(ctx, req.(*ConfirmPasswordResetRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*PasswordResetReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_ListNotifications0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in ListNotificationsRequest
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldListNotifications)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.ListNotifications(ctx, req.(*ListNotificationsRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*MultipleNotificationsReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_GetUnreadNotificationCount0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in GetUnreadNotificationCountRequest
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldGetUnreadNotificationCount)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.GetUnreadNotificationCount(ctx, req.(*GetUnreadNotificationCountRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*UnreadNotificationCountReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_MarkNotificationsRead0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in MarkNotificationsReadRequest
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldMarkNotificationsRead)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.MarkNotificationsRead(ctx, req.(*MarkNotificationsReadRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*UnreadNotificationCountReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_MarkAllNotificationsRead0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in MarkAllNotificationsReadRequest
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldMarkAllNotificationsRead)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.MarkAllNotificationsRead(ctx, req.(*MarkAllNotificationsReadRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*UnreadNotificationCountReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_GetNotificationPreferences0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in GetNotificationPreferencesRequest
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldGetNotificationPreferences)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.GetNotificationPreferences(ctx, req.(*GetNotificationPreferencesRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*NotificationPreferencesReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_UpdateNotificationPreferences0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in UpdateNotificationPreferencesRequest
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldUpdateNotificationPreferences)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.UpdateNotificationPreferences(ctx, req.(*UpdateNotificationPreferencesRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*NotificationPreferencesReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_CreateWebhook0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in CreateWebhookRequest
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldCreateWebhook)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.CreateWebhook(ctx, req.(*CreateWebhookRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*WebhookReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_ListWebhooks0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in ListWebhooksRequest
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldListWebhooks)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.ListWebhooks(ctx, req.(*ListWebhooksRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*MultipleWebhooksReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_DeleteWebhook0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in DeleteWebhookRequest
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindVars(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldDeleteWebhook)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.DeleteWebhook(ctx, req.(*DeleteWebhookRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*DeleteWebhookReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_ListWebhookDeliveries0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in ListWebhookDeliveriesRequest
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindVars(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldListWebhookDeliveries)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.ListWebhookDeliveries(ctx, req.(*ListWebhookDeliveriesRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*MultipleWebhookDeliveriesReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+func _RealWorld_TestWebhook0_HTTP_Handler(srv RealWorldHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in TestWebhookRequest
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindVars(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, OperationRealWorldTestWebhook)
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.TestWebhook(ctx, req.(*TestWebhookRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*WebhookDeliveryReply)
+		return ctx.Result(200, reply)
+	}
+}
+
 type RealWorldHTTPClient interface {
 	AddComment(ctx context.Context, req *AddCommentRequest, opts ...http.CallOption) (rsp *SingleCommentReply, err error)
+	ConfirmPasswordReset(ctx context.Context, req *ConfirmPasswordResetRequest, opts ...http.CallOption) (rsp *PasswordResetReply, err error)
 	CreateArticle(ctx context.Context, req *CreateArticleRequest, opts ...http.CallOption) (rsp *SingleArticleReply, err error)
+	CreateWebhook(ctx context.Context, req *CreateWebhookRequest, opts ...http.CallOption) (rsp *WebhookReply, err error)
 	DeleteArticle(ctx context.Context, req *DeleteArticleRequest, opts ...http.CallOption) (rsp *SingleArticleReply, err error)
 	DeleteComment(ctx context.Context, req *DeleteCommentRequest, opts ...http.CallOption) (rsp *SingleCommentReply, err error)
+	DeleteWebhook(ctx context.Context, req *DeleteWebhookRequest, opts ...http.CallOption) (rsp *DeleteWebhookReply, err error)
 	FavoriteArticle(ctx context.Context, req *FavoriteArticleRequest, opts ...http.CallOption) (rsp *SingleArticleReply, err error)
 	FeedListArticles(ctx context.Context, req *FeedArticlesRequest, opts ...http.CallOption) (rsp *MultipleArticlesReply, err error)
 	FollowUser(ctx context.Context, req *FollowUserRequest, opts ...http.CallOption) (rsp *ProfileReply, err error)
 	GetArticle(ctx context.Context, req *GetArticleRequest, opts ...http.CallOption) (rsp *SingleArticleReply, err error)
-	GetComment(ctx context.Context, req *GetCommentRequest, opts ...http.CallOption) (rsp *SingleCommentReply, err error)
+	GetComment(ctx context.Context, req *GetCommentRequest, opts ...http.CallOption) (rsp *MultipleCommentsReply, err error)
 	GetCurrentUser(ctx context.Context, req *GetCurrentRequest, opts ...http.CallOption) (rsp *UserReply, err error)
+	GetNotificationPreferences(ctx context.Context, req *GetNotificationPreferencesRequest, opts ...http.CallOption) (rsp *NotificationPreferencesReply, err error)
 	GetProfile(ctx context.Context, req *GetProfileRequest, opts ...http.CallOption) (rsp *GetProfileReply, err error)
 	GetTags(ctx context.Context, req *GetTagsRequest, opts ...http.CallOption) (rsp *TagListReply, err error)
+	GetUnreadNotificationCount(ctx context.Context, req *GetUnreadNotificationCountRequest, opts ...http.CallOption) (rsp *UnreadNotificationCountReply, err error)
 	ListArticles(ctx context.Context, req *ListArticlesRequest, opts ...http.CallOption) (rsp *MultipleArticlesReply, err error)
+	ListNotifications(ctx context.Context, req *ListNotificationsRequest, opts ...http.CallOption) (rsp *MultipleNotificationsReply, err error)
+	ListWebhookDeliveries(ctx context.Context, req *ListWebhookDeliveriesRequest, opts ...http.CallOption) (rsp *MultipleWebhookDeliveriesReply, err error)
+	ListWebhooks(ctx context.Context, req *ListWebhooksRequest, opts ...http.CallOption) (rsp *MultipleWebhooksReply, err error)
 	Login(ctx context.Context, req *LoginRequest, opts ...http.CallOption) (rsp *LoginReply, err error)
+	MarkAllNotificationsRead(ctx context.Context, req *MarkAllNotificationsReadRequest, opts ...http.CallOption) (rsp *UnreadNotificationCountReply, err error)
+	MarkNotificationsRead(ctx context.Context, req *MarkNotificationsReadRequest, opts ...http.CallOption) (rsp *UnreadNotificationCountReply, err error)
 	Register(ctx context.Context, req *RegisterRequest, opts ...http.CallOption) (rsp *UserReply, err error)
+	RequestPasswordReset(ctx context.Context, req *PasswordResetRequest, opts ...http.CallOption) (rsp *PasswordResetReply, err error)
+	ResendVerification(ctx context.Context, req *ResendVerificationRequest, opts ...http.CallOption) (rsp *VerifyEmailReply, err error)
+	TestWebhook(ctx context.Context, req *TestWebhookRequest, opts ...http.CallOption) (rsp *WebhookDeliveryReply, err error)
 	UnFavoriteArticle(ctx context.Context, req *UnFavoriteArticleRequest, opts ...http.CallOption) (rsp *SingleArticleReply, err error)
 	UnFollowUser(ctx context.Context, req *UnFollowUserRequest, opts ...http.CallOption) (rsp *ProfileReply, err error)
 	UpdateArticle(ctx context.Context, req *UpdateArticleRequest, opts ...http.CallOption) (rsp *SingleArticleReply, err error)
+	UpdateNotificationPreferences(ctx context.Context, req *UpdateNotificationPreferencesRequest, opts ...http.CallOption) (rsp *NotificationPreferencesReply, err error)
 	UpdateUser(ctx context.Context, req *UpdateUserRequest, opts ...http.CallOption) (rsp *UserReply, err error)
+	VerifyEmail(ctx context.Context, req *VerifyEmailRequest, opts ...http.CallOption) (rsp *VerifyEmailReply, err error)
 }
 
 type RealWorldHTTPClientImpl struct {
@@ -545,6 +927,19 @@ func (c *RealWorldHTTPClientImpl) AddComment(ctx context.Context, in *AddComment
 	return &out, nil
 }
 
+func (c *RealWorldHTTPClientImpl) ConfirmPasswordReset(ctx context.Context, in *ConfirmPasswordResetRequest, opts ...http.CallOption) (*PasswordResetReply, error) {
+	var out PasswordResetReply
+	pattern := "/api/users/password/reset/confirm"
+	path := binding.EncodeURL(pattern, in, false)
+	opts = append(opts, http.Operation(OperationRealWorldConfirmPasswordReset))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "POST", path, in, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *RealWorldHTTPClientImpl) CreateArticle(ctx context.Context, in *CreateArticleRequest, opts ...http.CallOption) (*SingleArticleReply, error) {
 	var out SingleArticleReply
 	pattern := "/api/article"
@@ -558,6 +953,19 @@ func (c *RealWorldHTTPClientImpl) CreateArticle(ctx context.Context, in *CreateA
 	return &out, nil
 }
 
+func (c *RealWorldHTTPClientImpl) CreateWebhook(ctx context.Context, in *CreateWebhookRequest, opts ...http.CallOption) (*WebhookReply, error) {
+	var out WebhookReply
+	pattern := "/api/webhooks"
+	path := binding.EncodeURL(pattern, in, false)
+	opts = append(opts, http.Operation(OperationRealWorldCreateWebhook))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "POST", path, in, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *RealWorldHTTPClientImpl) DeleteArticle(ctx context.Context, in *DeleteArticleRequest, opts ...http.CallOption) (*SingleArticleReply, error) {
 	var out SingleArticleReply
 	pattern := "/api/article/{slug}"
@@ -584,6 +992,19 @@ func (c *RealWorldHTTPClientImpl) DeleteComment(ctx context.Context, in *DeleteC
 	return &out, nil
 }
 
+func (c *RealWorldHTTPClientImpl) DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...http.CallOption) (*DeleteWebhookReply, error) {
+	var out DeleteWebhookReply
+	pattern := "/api/webhooks/{id}"
+	path := binding.EncodeURL(pattern, in, true)
+	opts = append(opts, http.Operation(OperationRealWorldDeleteWebhook))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "DELETE", path, nil, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *RealWorldHTTPClientImpl) FavoriteArticle(ctx context.Context, in *FavoriteArticleRequest, opts ...http.CallOption) (*SingleArticleReply, error) {
 	var out SingleArticleReply
 	pattern := "/api/articles/{slug}/favorite"
@@ -636,8 +1057,8 @@ func (c *RealWorldHTTPClientImpl) GetArticle(ctx context.Context, in *GetArticle
 	return &out, nil
 }
 
-func (c *RealWorldHTTPClientImpl) GetComment(ctx context.Context, in *GetCommentRequest, opts ...http.CallOption) (*SingleCommentReply, error) {
-	var out SingleCommentReply
+func (c *RealWorldHTTPClientImpl) GetComment(ctx context.Context, in *GetCommentRequest, opts ...http.CallOption) (*MultipleCommentsReply, error) {
+	var out MultipleCommentsReply
 	pattern := "/api/articles/{slug}/comments"
 	path := binding.EncodeURL(pattern, in, true)
 	opts = append(opts, http.Operation(OperationRealWorldGetComment))
@@ -662,6 +1083,19 @@ func (c *RealWorldHTTPClientImpl) GetCurrentUser(ctx context.Context, in *GetCur
 	return &out, nil
 }
 
+func (c *RealWorldHTTPClientImpl) GetNotificationPreferences(ctx context.Context, in *GetNotificationPreferencesRequest, opts ...http.CallOption) (*NotificationPreferencesReply, error) {
+	var out NotificationPreferencesReply
+	pattern := "/api/notifications/preferences"
+	path := binding.EncodeURL(pattern, in, true)
+	opts = append(opts, http.Operation(OperationRealWorldGetNotificationPreferences))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "GET", path, nil, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *RealWorldHTTPClientImpl) GetProfile(ctx context.Context, in *GetProfileRequest, opts ...http.CallOption) (*GetProfileReply, error) {
 	var out GetProfileReply
 	pattern := "/api/profile/{username}"
@@ -688,6 +1122,19 @@ func (c *RealWorldHTTPClientImpl) GetTags(ctx context.Context, in *GetTagsReques
 	return &out, nil
 }
 
+func (c *RealWorldHTTPClientImpl) GetUnreadNotificationCount(ctx context.Context, in *GetUnreadNotificationCountRequest, opts ...http.CallOption) (*UnreadNotificationCountReply, error) {
+	var out UnreadNotificationCountReply
+	pattern := "/api/notifications/unread-count"
+	path := binding.EncodeURL(pattern, in, true)
+	opts = append(opts, http.Operation(OperationRealWorldGetUnreadNotificationCount))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "GET", path, nil, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *RealWorldHTTPClientImpl) ListArticles(ctx context.Context, in *ListArticlesRequest, opts ...http.CallOption) (*MultipleArticlesReply, error) {
 	var out MultipleArticlesReply
 	pattern := "/api/articles"
@@ -701,6 +1148,45 @@ func (c *RealWorldHTTPClientImpl) ListArticles(ctx context.Context, in *ListArti
 	return &out, nil
 }
 
+func (c *RealWorldHTTPClientImpl) ListNotifications(ctx context.Context, in *ListNotificationsRequest, opts ...http.CallOption) (*MultipleNotificationsReply, error) {
+	var out MultipleNotificationsReply
+	pattern := "/api/notifications"
+	path := binding.EncodeURL(pattern, in, true)
+	opts = append(opts, http.Operation(OperationRealWorldListNotifications))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "GET", path, nil, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *RealWorldHTTPClientImpl) ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...http.CallOption) (*MultipleWebhookDeliveriesReply, error) {
+	var out MultipleWebhookDeliveriesReply
+	pattern := "/api/webhooks/{id}/deliveries"
+	path := binding.EncodeURL(pattern, in, true)
+	opts = append(opts, http.Operation(OperationRealWorldListWebhookDeliveries))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "GET", path, nil, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *RealWorldHTTPClientImpl) ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...http.CallOption) (*MultipleWebhooksReply, error) {
+	var out MultipleWebhooksReply
+	pattern := "/api/webhooks"
+	path := binding.EncodeURL(pattern, in, true)
+	opts = append(opts, http.Operation(OperationRealWorldListWebhooks))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "GET", path, nil, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *RealWorldHTTPClientImpl) Login(ctx context.Context, in *LoginRequest, opts ...http.CallOption) (*LoginReply, error) {
 	var out LoginReply
 	pattern := "/api/users/login"
@@ -714,6 +1200,32 @@ func (c *RealWorldHTTPClientImpl) Login(ctx context.Context, in *LoginRequest, o
 	return &out, nil
 }
 
+func (c *RealWorldHTTPClientImpl) MarkAllNotificationsRead(ctx context.Context, in *MarkAllNotificationsReadRequest, opts ...http.CallOption) (*UnreadNotificationCountReply, error) {
+	var out UnreadNotificationCountReply
+	pattern := "/api/notifications/read-all"
+	path := binding.EncodeURL(pattern, in, false)
+	opts = append(opts, http.Operation(OperationRealWorldMarkAllNotificationsRead))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "POST", path, in, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *RealWorldHTTPClientImpl) MarkNotificationsRead(ctx context.Context, in *MarkNotificationsReadRequest, opts ...http.CallOption) (*UnreadNotificationCountReply, error) {
+	var out UnreadNotificationCountReply
+	pattern := "/api/notifications/read"
+	path := binding.EncodeURL(pattern, in, false)
+	opts = append(opts, http.Operation(OperationRealWorldMarkNotificationsRead))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "POST", path, in, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *RealWorldHTTPClientImpl) Register(ctx context.Context, in *RegisterRequest, opts ...http.CallOption) (*UserReply, error) {
 	var out UserReply
 	pattern := "/api/users"
@@ -727,6 +1239,45 @@ func (c *RealWorldHTTPClientImpl) Register(ctx context.Context, in *RegisterRequ
 	return &out, nil
 }
 
+func (c *RealWorldHTTPClientImpl) RequestPasswordReset(ctx context.Context, in *PasswordResetRequest, opts ...http.CallOption) (*PasswordResetReply, error) {
+	var out PasswordResetReply
+	pattern := "/api/users/password/reset"
+	path := binding.EncodeURL(pattern, in, false)
+	opts = append(opts, http.Operation(OperationRealWorldRequestPasswordReset))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "POST", path, in, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *RealWorldHTTPClientImpl) ResendVerification(ctx context.Context, in *ResendVerificationRequest, opts ...http.CallOption) (*VerifyEmailReply, error) {
+	var out VerifyEmailReply
+	pattern := "/api/users/verify/resend"
+	path := binding.EncodeURL(pattern, in, false)
+	opts = append(opts, http.Operation(OperationRealWorldResendVerification))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "POST", path, in, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *RealWorldHTTPClientImpl) TestWebhook(ctx context.Context, in *TestWebhookRequest, opts ...http.CallOption) (*WebhookDeliveryReply, error) {
+	var out WebhookDeliveryReply
+	pattern := "/api/webhooks/{id}/test"
+	path := binding.EncodeURL(pattern, in, false)
+	opts = append(opts, http.Operation(OperationRealWorldTestWebhook))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "POST", path, in, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *RealWorldHTTPClientImpl) UnFavoriteArticle(ctx context.Context, in *UnFavoriteArticleRequest, opts ...http.CallOption) (*SingleArticleReply, error) {
 	var out SingleArticleReply
 	pattern := "/api/articles/{slug}/favorite"
@@ -766,6 +1317,19 @@ func (c *RealWorldHTTPClientImpl) UpdateArticle(ctx context.Context, in *UpdateA
 	return &out, nil
 }
 
+func (c *RealWorldHTTPClientImpl) UpdateNotificationPreferences(ctx context.Context, in *UpdateNotificationPreferencesRequest, opts ...http.CallOption) (*NotificationPreferencesReply, error) {
+	var out NotificationPreferencesReply
+	pattern := "/api/notifications/preferences"
+	path := binding.EncodeURL(pattern, in, false)
+	opts = append(opts, http.Operation(OperationRealWorldUpdateNotificationPreferences))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "PUT", path, in, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *RealWorldHTTPClientImpl) UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...http.CallOption) (*UserReply, error) {
 	var out UserReply
 	pattern := "/api/user"
@@ -778,3 +1342,16 @@ func (c *RealWorldHTTPClientImpl) UpdateUser(ctx context.Context, in *UpdateUser
 	}
 	return &out, nil
 }
+
+func (c *RealWorldHTTPClientImpl) VerifyEmail(ctx context.Context, in *VerifyEmailRequest, opts ...http.CallOption) (*VerifyEmailReply, error) {
+	var out VerifyEmailReply
+	pattern := "/api/users/verify"
+	path := binding.EncodeURL(pattern, in, false)
+	opts = append(opts, http.Operation(OperationRealWorldVerifyEmail))
+	opts = append(opts, http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "POST", path, in, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
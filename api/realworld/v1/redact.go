@@ -0,0 +1,48 @@
+package v1
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Redact methods are used by the kratos logging middleware instead of the
+// default %v of the request, so passwords and tokens never reach the logs.
+
+// secretFields are blanked anywhere in a redacted message.
+var secretFields = map[protoreflect.Name]struct{}{
+	"password":        {},
+	"currentPassword": {},
+	"token":           {},
+}
+
+func redact(m proto.Message) string {
+	c := proto.Clone(m)
+	mask(c.ProtoReflect())
+	return fmt.Sprintf("%+v", c)
+}
+
+func mask(m protoreflect.Message) {
+	var secrets []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.IsList() || fd.IsMap() {
+			return true
+		}
+		if fd.Message() != nil {
+			mask(v.Message())
+		} else if _, ok := secretFields[fd.Name()]; ok && fd.Kind() == protoreflect.StringKind {
+			secrets = append(secrets, fd)
+		}
+		return true
+	})
+	for _, fd := range secrets {
+		m.Set(fd, protoreflect.ValueOfString("***"))
+	}
+}
+
+func (x *LoginRequest) Redact() string                { return redact(x) }
+func (x *RegisterRequest) Redact() string             { return redact(x) }
+func (x *UpdateUserRequest) Redact() string           { return redact(x) }
+func (x *VerifyEmailRequest) Redact() string          { return redact(x) }
+func (x *ConfirmPasswordResetRequest) Redact() string { return redact(x) }
@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+// Validate methods are called by the server validation middleware before a
+// request reaches the service, for HTTP and gRPC alike. They only check
+// required fields; business rules stay in biz.
+
+func blank(field string) error {
+	return errors.New(422, field, "can't be blank")
+}
+
+func required(fields ...string) error {
+	for i := 0; i < len(fields); i += 2 {
+		if strings.TrimSpace(fields[i+1]) == "" {
+			return blank(fields[i])
+		}
+	}
+	return nil
+}
+
+func (x *LoginRequest) Validate() error {
+	if x.GetUser() == nil {
+		return blank("user")
+	}
+	return required("email", x.User.Email, "password", x.User.Password)
+}
+
+func (x *RegisterRequest) Validate() error {
+	if x.GetUser() == nil {
+		return blank("user")
+	}
+	return required("username", x.User.Username, "email", x.User.Email, "password", x.User.Password)
+}
+
+func (x *UpdateUserRequest) Validate() error {
+	if x.GetUser() == nil {
+		return blank("user")
+	}
+	return nil
+}
+
+func (x *GetProfileRequest) Validate() error   { return required("username", x.GetUsername()) }
+func (x *FollowUserRequest) Validate() error   { return required("username", x.GetUsername()) }
+func (x *UnFollowUserRequest) Validate() error { return required("username", x.GetUsername()) }
+
+func (x *GetArticleRequest) Validate() error        { return required("slug", x.GetSlug()) }
+func (x *DeleteArticleRequest) Validate() error     { return required("slug", x.GetSlug()) }
+func (x *FavoriteArticleRequest) Validate() error   { return required("slug", x.GetSlug()) }
+func (x *UnFavoriteArticleRequest) Validate() error { return required("slug", x.GetSlug()) }
+func (x *GetCommentRequest) Validate() error        { return required("slug", x.GetSlug()) }
+
+func (x *CreateArticleRequest) Validate() error {
+	if x.GetArticle() == nil {
+		return blank("article")
+	}
+	return required("title", x.Article.Title, "description", x.Article.Description, "body", x.Article.Body)
+}
+
+func (x *UpdateArticleRequest) Validate() error {
+	if x.GetArticle() == nil {
+		return blank("article")
+	}
+	return required("slug", x.GetSlug())
+}
+
+func (x *AddCommentRequest) Validate() error {
+	if x.GetComment() == nil {
+		return blank("comment")
+	}
+	return required("slug", x.GetSlug(), "body", x.Comment.Body)
+}
+
+func (x *DeleteCommentRequest) Validate() error {
+	if x.GetId() <= 0 {
+		return blank("id")
+	}
+	return required("slug", x.GetSlug())
+}
+
+func (x *VerifyEmailRequest) Validate() error { return required("token", x.GetToken()) }
+
+func (x *PasswordResetRequest) Validate() error {
+	if x.GetUser() == nil {
+		return blank("user")
+	}
+	return required("email", x.User.Email)
+}
+
+func (x *ConfirmPasswordResetRequest) Validate() error {
+	if x.GetUser() == nil {
+		return blank("user")
+	}
+	return required("token", x.Token, "password", x.User.Password)
+}
+
+func (x *ListNotificationsRequest) Validate() error {
+	if x.GetLimit() < 0 {
+		return errors.New(422, "limit", "must not be negative")
+	}
+	if x.GetOffset() < 0 {
+		return errors.New(422, "offset", "must not be negative")
+	}
+	return nil
+}
+
+func (x *MarkNotificationsReadRequest) Validate() error {
+	if len(x.GetIds()) == 0 {
+		return blank("ids")
+	}
+	return nil
+}
+
+func (x *UpdateNotificationPreferencesRequest) Validate() error {
+	if x.GetPreferences() == nil {
+		return blank("preferences")
+	}
+	return nil
+}
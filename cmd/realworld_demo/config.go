@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"realworld_demo/internal/conf"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/config/file"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// loadConfig 读取 -conf 指定的文件，再用 REALWORLD_ 开头的环境变量覆盖
+func loadConfig(path string) (config.Config, *conf.Bootstrap, error) {
+	c := config.New(
+		config.WithSource(
+			file.NewSource(path),
+			conf.NewEnvSource(conf.EnvPrefix),
+		),
+	)
+	if err := c.Load(); err != nil {
+		c.Close()
+		return nil, nil, err
+	}
+	var bc conf.Bootstrap
+	if err := c.Scan(&bc); err != nil {
+		c.Close()
+		return nil, nil, err
+	}
+	return c, &bc, nil
+}
+
+// runConfigCommand implements `config print [--redacted]`: it prints the
+// effective configuration after env overrides, then the validation result.
+func runConfigCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(stderr, "usage: realworld_demo [-conf path] config print [--redacted]")
+		return 2
+	}
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	redacted := fs.Bool("redacted", false, "mask secrets such as jwt.secret and the database password")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	c, bc, err := loadConfig(flagconf)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer c.Close()
+
+	out := bc
+	if *redacted {
+		out = bc.Redacted()
+	}
+	b, err := protojson.MarshalOptions{Multiline: true, UseProtoNames: true}.Marshal(out)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, string(b))
+
+	if err := bc.Validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// reloadableKeys are the top-level config keys that hold settings applied
+// at runtime, see conf.Reloader.
+var reloadableKeys = []string{"server", "log", "runtime"}
+
+// watchConfig re-reads the config whenever one of reloadableKeys changes.
+// The whole config is loaded again so env overrides still take precedence
+// over the edited file.
+func watchConfig(c config.Config, rld *conf.Reloader, logger log.Logger) error {
+	l := log.NewHelper(logger)
+	reload := func(key string, _ config.Value) {
+		nc, next, err := loadConfig(flagconf)
+		if err != nil {
+			l.Errorf("reload config (%s changed): %v", key, err)
+			return
+		}
+		nc.Close()
+		restart, err := rld.Apply(next)
+		if err != nil {
+			l.Errorf("reload config (%s changed), keeping the current one: %v", key, err)
+			return
+		}
+		l.Infof("config reloaded (%s changed)", key)
+		if len(restart) > 0 {
+			l.Warnf("config changes in %s take effect after restart", strings.Join(restart, ", "))
+		}
+	}
+	for _, key := range reloadableKeys {
+		if err := c.Watch(key, reload); err != nil && !errors.Is(err, config.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
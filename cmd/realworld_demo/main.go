@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
+	"realworld_demo/internal/biz"
 	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	"realworld_demo/internal/pkg/logging"
+	"realworld_demo/internal/pkg/markdown"
+	"realworld_demo/internal/server"
 
 	"github.com/go-kratos/kratos/v2"
-	"github.com/go-kratos/kratos/v2/config"
-	"github.com/go-kratos/kratos/v2/config/file"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware/tracing"
 	"github.com/go-kratos/kratos/v2/transport/grpc"
@@ -33,7 +39,26 @@ func init() {
 	flag.StringVar(&flagconf, "conf", "../../configs", "config path, eg: -conf config.yaml")
 }
 
-func newApp(logger log.Logger, gs *grpc.Server, hs *http.Server) *kratos.App {
+const defaultShutdownTimeout = 30 * time.Second
+
+// newApp 按以下顺序停机：readiness 失败并等待 drain_delay，让负载均衡摘除本实例；
+// HTTP/gRPC 和管理端口停止接受新连接，在 timeout 内等待处理中的请求完成，
+// 事件 relay 和 webhook dispatcher 同时停止轮询；
+// 之后在同一个 timeout 内把到期的 outbox 事件和 webhook 投递发送完，并清空渲染缓存，
+// 超时仍未发送的留给下次启动或其他实例；
+// 最后由 wire 的 cleanup 依次关闭 Redis 和数据库连接池
+func newApp(logger log.Logger, c *conf.Server, hc *health.Checker, gs *grpc.Server, hs *http.Server, as *server.AdminServer, relay *biz.EventRelay, wd *biz.WebhookDispatcher, md *markdown.Renderer) *kratos.App {
+	// 未配置 drain_delay 时不等待，适合没有负载均衡的本地环境
+	var drainDelay time.Duration
+	timeout := defaultShutdownTimeout
+	if d := c.GetShutdown().GetDrainDelay(); d != nil {
+		drainDelay = d.AsDuration()
+	}
+	if t := c.GetShutdown().GetTimeout(); t != nil {
+		timeout = t.AsDuration()
+	}
+	// 服务停止的截止时间，在 BeforeStop 结束时确定，最后的 flush 也不能超过它
+	var deadline time.Time
 	return kratos.New(
 		kratos.ID(id),
 		kratos.Name(Name),
@@ -43,13 +68,67 @@ func newApp(logger log.Logger, gs *grpc.Server, hs *http.Server) *kratos.App {
 		kratos.Server(
 			gs,
 			hs,
+			as,
+			relay,
+			wd,
 		),
+		kratos.StopTimeout(timeout),
+		// 停止前先让 readiness 失败，负载均衡不再转发新请求
+		kratos.BeforeStop(func(ctx context.Context) error {
+			hc.Shutdown()
+			if drainDelay > 0 {
+				log.NewHelper(logger).Infof("shutting down: not ready, draining for %s", drainDelay)
+				select {
+				case <-time.After(drainDelay):
+				case <-ctx.Done():
+				}
+			}
+			deadline = time.Now().Add(timeout)
+			return nil
+		}),
+		// 请求都已结束，不会再有新的事件写入；先投递 outbox，其中的事件可能产生新的 webhook 投递
+		kratos.AfterStop(func(ctx context.Context) error {
+			helper := log.NewHelper(logger)
+			if deadline.IsZero() {
+				// 服务启动失败时不经过 BeforeStop
+				deadline = time.Now().Add(timeout)
+			}
+			ctx, cancel := context.WithDeadline(ctx, deadline)
+			defer cancel()
+			if err := relay.Flush(ctx); err != nil {
+				helper.Errorf("flush outbox events: %v", err)
+			}
+			if err := wd.Flush(ctx); err != nil {
+				helper.Errorf("flush webhook deliveries: %v", err)
+			}
+			md.Flush()
+			helper.Info("servers stopped, closing resources")
+			return nil
+		}),
 	)
 }
 
 func main() {
 	flag.Parse()
-	logger := log.With(log.NewStdLogger(os.Stdout),
+	if flag.Arg(0) == "config" {
+		os.Exit(runConfigCommand(flag.Args()[1:], os.Stdout, os.Stderr))
+	}
+
+	c, bc, err := loadConfig(flagconf)
+	if err != nil {
+		panic(err)
+	}
+	defer c.Close()
+	if err := bc.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	base, err := logging.NewLogger(os.Stdout, bc.Log.GetFormat(), log.ParseLevel(bc.Log.GetLevel()))
+	if err != nil {
+		panic(err)
+	}
+	logger := log.With(base,
 		"ts", log.DefaultTimestamp,
 		"caller", log.DefaultCaller,
 		"service.id", id,
@@ -57,24 +136,37 @@ func main() {
 		"service.version", Version,
 		"trace.id", tracing.TraceID(),
 		"span.id", tracing.SpanID(),
+		"request.id", logging.RequestID(),
+		"user.id", logging.UserID(),
+		"operation", logging.Operation(),
 	)
-	c := config.New(
-		config.WithSource(
-			file.NewSource(flagconf),
-		),
-	)
-	defer c.Close()
-
-	if err := c.Load(); err != nil {
+	// 包级别的 log.Info 等也走同一个 logger
+	log.SetLogger(logger)
+	if err := setMeterProvider(); err != nil {
+		panic(err)
+	}
+	shutdownTracer, err := setTracerProvider(context.Background(), bc.Trace)
+	if err != nil {
 		panic(err)
 	}
+	defer func() {
+		// 退出前把尚未导出的 span 发送出去
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracer(ctx); err != nil {
+			log.NewHelper(logger).Errorf("shutdown tracer: %v", err)
+		}
+	}()
 
-	var bc conf.Bootstrap
-	if err := c.Scan(&bc); err != nil {
+	rld := conf.NewReloader(bc)
+	rld.Subscribe(func(bc *conf.Bootstrap) {
+		base.SetLevel(log.ParseLevel(bc.GetLog().GetLevel()))
+	})
+	if err := watchConfig(c, rld, logger); err != nil {
 		panic(err)
 	}
 
-	app, cleanup, err := wireApp(bc.Server, bc.Data, logger)
+	app, cleanup, err := wireApp(bc.Server, bc.Data, bc.Auth, bc.Jwt, rld, logger)
 	if err != nil {
 		panic(err)
 	}
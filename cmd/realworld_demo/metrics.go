@@ -0,0 +1,18 @@
+package main
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// setMeterProvider exports every OTel instrument through the default
+// Prometheus registry, which the HTTP server serves at /metrics.
+func setMeterProvider() error {
+	exporter, err := prometheus.New(prometheus.WithoutScopeInfo())
+	if err != nil {
+		return err
+	}
+	otel.SetMeterProvider(metricsdk.NewMeterProvider(metricsdk.WithReader(exporter)))
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"realworld_demo/internal/conf"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// setTracerProvider installs the global tracer provider and the W3C
+// traceparent/baggage propagator. The returned func flushes pending spans.
+func setTracerProvider(ctx context.Context, c *conf.Trace) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var (
+		exporter tracesdk.SpanExporter
+		err      error
+	)
+	switch c.GetExporter() {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		exporter, err = newOTLPExporter(ctx, c)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", c.GetExporter())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := c.GetSampleRatio()
+	if ratio <= 0 {
+		ratio = 1
+	}
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithBatcher(exporter),
+		tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.TraceIDRatioBased(ratio))),
+		tracesdk.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(Name),
+			semconv.ServiceVersion(Version),
+			semconv.ServiceInstanceID(id),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+func newOTLPExporter(ctx context.Context, c *conf.Trace) (tracesdk.SpanExporter, error) {
+	switch c.GetProtocol() {
+	case "", "grpc":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(c.GetEndpoint())}
+		if c.GetInsecure() {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case "http":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.GetEndpoint())}
+		if c.GetInsecure() {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown otlp protocol %q", c.GetProtocol())
+	}
+}
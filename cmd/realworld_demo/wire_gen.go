@@ -12,6 +12,7 @@ import (
 	"realworld_demo/internal/biz"
 	"realworld_demo/internal/conf"
 	"realworld_demo/internal/data"
+	"realworld_demo/internal/pkg/health"
 	"realworld_demo/internal/server"
 	"realworld_demo/internal/service"
 )
@@ -23,24 +24,87 @@ import (
 // Injectors from wire.go:
 
 // wireApp init kratos application.
-func wireApp(confServer *conf.Server, confData *conf.Data, logger log.Logger) (*kratos.App, func(), error) {
-	db := data.NewDB(confData)
-	dataData, cleanup, err := data.NewData(confData, logger, db)
+func wireApp(confServer *conf.Server, confData *conf.Data, auth *conf.Auth, jwt *conf.JWT, reloader *conf.Reloader, logger log.Logger) (*kratos.App, func(), error) {
+	db, err := data.NewDB(confData, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	checker := health.NewChecker()
+	dataData, cleanup, err := data.NewData(confData, logger, db, checker)
 	if err != nil {
 		return nil, nil, err
 	}
 	userRepo := data.NewUserRepo(dataData, logger)
 	profileRepo := data.NewProfileRepo(dataData, logger)
-	jwt := conf.NewJWT()
-	userUsecase := biz.NewUserUsecase(userRepo, profileRepo, logger, jwt)
+	userTokenRepo := data.NewUserTokenRepo(dataData, logger)
+	mailer, err := data.NewMailer(confData, logger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	hasher, err := biz.NewPasswordHasher(auth)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	policy, err := biz.NewPasswordPolicy(auth)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	transaction := data.NewTransaction(dataData)
+	outboxRepo := data.NewOutboxRepo(dataData, logger)
+	userUsecase := biz.NewUserUsecase(userRepo, profileRepo, userTokenRepo, transaction, outboxRepo, mailer, hasher, policy, logger, jwt, confData, reloader)
 	articleRepo := data.NewArticleRepo(dataData, logger)
 	commentRepo := data.NewCommentRepo(dataData, logger)
-	socialUsecase := biz.NewSocialUsecase(articleRepo, profileRepo, commentRepo, logger)
-	realWorldService := service.NewRealWorldService(userUsecase, socialUsecase, logger)
-	grpcServer := server.NewGRPCServer(confServer, realWorldService, logger)
-	httpServer := server.NewHTTPServer(confServer, jwt, realWorldService, logger)
-	app := newApp(logger, grpcServer, httpServer)
+	renderer, err := biz.NewMarkdownRenderer(reloader)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	notificationRepo := data.NewNotificationRepo(dataData, logger)
+	broker, cleanup2, err := data.NewBroker(confData, checker, logger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	socialUsecase := biz.NewSocialUsecase(articleRepo, profileRepo, commentRepo, userRepo, renderer, notificationRepo, transaction, outboxRepo, broker, reloader, logger)
+	objectStorage, err := data.NewObjectStorage(confData)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	mediaUsecase := biz.NewMediaUsecase(objectStorage, confData, logger)
+	notificationUsecase := biz.NewNotificationUsecase(notificationRepo, logger)
+	streamUsecase := biz.NewStreamUsecase(articleRepo, userTokenRepo, broker, logger)
+	webhookRepo := data.NewWebhookRepo(dataData, logger)
+	eventBus := biz.NewEventBus()
+	webhookUsecase := biz.NewWebhookUsecase(webhookRepo, eventBus, confData, auth, logger)
+	feedUsecase := biz.NewFeedUsecase(articleRepo, profileRepo, renderer, confData, logger)
+	realWorldService := service.NewRealWorldService(userUsecase, socialUsecase, mediaUsecase, notificationUsecase, streamUsecase, webhookUsecase, feedUsecase, logger)
+	limiter, cleanup3, err := server.NewRateLimiter(confServer, confData, checker, reloader, logger)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	grpcServer := server.NewGRPCServer(confServer, jwt, limiter, checker, realWorldService, logger)
+	httpServer, err := server.NewHTTPServer(confServer, confData, jwt, limiter, checker, reloader, realWorldService, logger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	adminServer := server.NewAdminServer(confServer, checker, reloader)
+	v := data.NewEventSinks(confData, logger)
+	eventRelay := biz.NewEventRelay(outboxRepo, eventBus, v, confData, logger)
+	webhookDispatcher := biz.NewWebhookDispatcher(webhookUsecase, confData, logger)
+	app := newApp(logger, confServer, checker, grpcServer, httpServer, adminServer, eventRelay, webhookDispatcher, renderer)
 	return app, func() {
+		cleanup3()
+		cleanup2()
 		cleanup()
 	}, nil
 }
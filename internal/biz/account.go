@@ -0,0 +1,186 @@
+package biz
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	auth "realworld_demo/internal/pkg/middleware"
+
+	"github.com/go-kratos/kratos/v2/errors"
+)
+
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+	TokenPurposeStream        = "stream"
+
+	verifyEmailTokenTTL   = 24 * time.Hour
+	resetPasswordTokenTTL = time.Hour
+)
+
+var (
+	// ErrInvalidToken is returned for unknown, used or expired tokens.
+	ErrInvalidToken = errors.New(422, "token", "is invalid or has expired")
+	// ErrEmailNotVerified is returned when an unverified user tries to post.
+	ErrEmailNotVerified = errors.Forbidden("email", "请先验证邮箱")
+)
+
+// UserToken is a single-use, time-limited token sent by mail.
+// Only the SHA-256 of the token is stored.
+type UserToken struct {
+	UserID    uint
+	Purpose   string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+type UserTokenRepo interface {
+	CreateToken(ctx context.Context, t *UserToken) error
+	// ConsumeToken marks a valid token as used and returns its owner.
+	// It returns ErrInvalidToken if the token is unknown, used or expired.
+	ConsumeToken(ctx context.Context, purpose, tokenHash string) (userID uint, err error)
+	// RevokeTokens invalidates every outstanding token of the user for purpose.
+	RevokeTokens(ctx context.Context, userID uint, purpose string) error
+}
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers mail, e.g. over SMTP or into a file for local testing.
+type Mailer interface {
+	Send(ctx context.Context, m *Mail) error
+}
+
+func newToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (uc *UserUsecase) link(path, token string) string {
+	base := strings.TrimSuffix(uc.mailc.GetLinkBaseUrl(), "/")
+	return base + path + "?token=" + url.QueryEscape(token)
+}
+
+// issueToken creates a token for u and mails the link built from path.
+func (uc *UserUsecase) issueToken(ctx context.Context, u *User, purpose string, ttl time.Duration, subject, path string) error {
+	token, hash, err := newToken()
+	if err != nil {
+		return err
+	}
+	if err := uc.tr.CreateToken(ctx, &UserToken{
+		UserID:    u.ID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+	return uc.mailer.Send(ctx, &Mail{
+		To:      u.Email,
+		Subject: subject,
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below within %s:\n\n%s\n\nIf you did not request this, ignore this mail.\n",
+			u.Username, ttl, uc.link(path, token)),
+	})
+}
+
+func (uc *UserUsecase) sendVerification(ctx context.Context, u *User) error {
+	return uc.issueToken(ctx, u, TokenPurposeVerifyEmail,
+		runtimeTTL(uc.rld.Current().GetRuntime().GetVerifyEmailTtl(), verifyEmailTokenTTL),
+		"Verify your email", "/verify-email")
+}
+
+// VerifyEmail consumes a verification token and marks its owner verified.
+func (uc *UserUsecase) VerifyEmail(ctx context.Context, token string) error {
+	if token == "" {
+		return ErrInvalidToken
+	}
+	uid, err := uc.tr.ConsumeToken(ctx, TokenPurposeVerifyEmail, hashToken(token))
+	if err != nil {
+		return err
+	}
+	return uc.ur.SetEmailVerified(ctx, uid, true)
+}
+
+// ResendVerification mails a new verification link to the current user.
+func (uc *UserUsecase) ResendVerification(ctx context.Context) error {
+	u, err := uc.ur.GetUserByID(ctx, auth.FromContext(ctx).UserID)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerified {
+		return errors.New(422, "email", "已验证")
+	}
+	if err := uc.tr.RevokeTokens(ctx, u.ID, TokenPurposeVerifyEmail); err != nil {
+		return err
+	}
+	return uc.sendVerification(ctx, u)
+}
+
+// RequestPasswordReset mails a reset link. Unknown emails are ignored so the
+// response does not reveal whether an account exists.
+func (uc *UserUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	if email == "" {
+		return errors.New(422, "email", "cannot be empty")
+	}
+	u, err := uc.ur.GetUserByEmail(ctx, email)
+	if errors.IsNotFound(err) {
+		uc.log.WithContext(ctx).Infof("忽略不存在邮箱的重置密码请求")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return uc.issueToken(ctx, u, TokenPurposeResetPassword,
+		runtimeTTL(uc.rld.Current().GetRuntime().GetResetPasswordTtl(), resetPasswordTokenTTL),
+		"Reset your password", "/reset-password")
+}
+
+// ResetPassword consumes a reset token and sets a new password. Other
+// outstanding reset tokens of the user are revoked. If the password cannot
+// be saved the token stays valid.
+func (uc *UserUsecase) ResetPassword(ctx context.Context, token, password string) error {
+	if token == "" {
+		return ErrInvalidToken
+	}
+	// 消费 token 之前还不知道用户，这里不做用户名相似度检查
+	if err := uc.checkPassword(password); err != nil {
+		return err
+	}
+	// 在同一事务中消费 token 和更新密码，失败时 token 不会被用掉
+	return uc.tx.InTx(ctx, func(ctx context.Context) error {
+		uid, err := uc.tr.ConsumeToken(ctx, TokenPurposeResetPassword, hashToken(token))
+		if err != nil {
+			return err
+		}
+		u, err := uc.ur.GetUserByID(ctx, uid)
+		if err != nil {
+			return err
+		}
+		if u.PasswordHash, err = uc.hashPassword(ctx, password); err != nil {
+			return err
+		}
+		if _, err := uc.ur.UpdateUser(ctx, u); err != nil {
+			return err
+		}
+		return uc.tr.RevokeTokens(ctx, uid, TokenPurposeResetPassword)
+	})
+}
@@ -0,0 +1,236 @@
+package biz
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/password"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type memUserRepo struct {
+	users map[uint]*User
+}
+
+func (r *memUserRepo) CreateUser(ctx context.Context, u *User) error {
+	u.ID = uint(len(r.users) + 1)
+	c := *u
+	r.users[u.ID] = &c
+	return nil
+}
+
+func (r *memUserRepo) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			c := *u
+			return &c, nil
+		}
+	}
+	return nil, errors.NotFound("user", "not found by email")
+}
+
+func (r *memUserRepo) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			c := *u
+			return &c, nil
+		}
+	}
+	return nil, errors.NotFound("user", "not found by username")
+}
+
+func (r *memUserRepo) GetUserByID(ctx context.Context, id uint) (*User, error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, errors.NotFound("user", "not found by id")
+	}
+	c := *u
+	return &c, nil
+}
+
+func (r *memUserRepo) UpdateUser(ctx context.Context, u *User) (*User, error) {
+	c := *u
+	r.users[u.ID] = &c
+	return u, nil
+}
+
+func (r *memUserRepo) SetEmailVerified(ctx context.Context, id uint, verified bool) error {
+	r.users[id].EmailVerified = verified
+	return nil
+}
+
+type memTokenRepo struct {
+	tokens []*UserToken
+	used   map[string]bool
+}
+
+func (r *memTokenRepo) CreateToken(ctx context.Context, t *UserToken) error {
+	r.tokens = append(r.tokens, t)
+	return nil
+}
+
+func (r *memTokenRepo) ConsumeToken(ctx context.Context, purpose, hash string) (uint, error) {
+	for _, t := range r.tokens {
+		if t.TokenHash == hash && t.Purpose == purpose && !r.used[hash] && t.ExpiresAt.After(time.Now()) {
+			r.used[hash] = true
+			return t.UserID, nil
+		}
+	}
+	return 0, ErrInvalidToken
+}
+
+func (r *memTokenRepo) RevokeTokens(ctx context.Context, userID uint, purpose string) error {
+	for _, t := range r.tokens {
+		if t.UserID == userID && t.Purpose == purpose {
+			r.used[t.TokenHash] = true
+		}
+	}
+	return nil
+}
+
+type memMailer []*Mail
+
+func (m *memMailer) Send(ctx context.Context, mail *Mail) error {
+	*m = append(*m, mail)
+	return nil
+}
+
+// tokenFromMail 从邮件正文的链接中取出 token
+func tokenFromMail(m *Mail) string {
+	for _, line := range strings.Split(m.Body, "\n") {
+		if i := strings.Index(line, "?token="); i >= 0 {
+			t, _ := url.QueryUnescape(line[i+len("?token="):])
+			return t
+		}
+	}
+	return ""
+}
+
+func newTestUserUsecase() (*UserUsecase, *memUserRepo, *memMailer) {
+	ur := &memUserRepo{users: map[uint]*User{}}
+	tr := &memTokenRepo{used: map[string]bool{}}
+	mailer := &memMailer{}
+	hasher, _ := password.NewHasher(password.Bcrypt, bcrypt.MinCost, password.Argon2Params{})
+	policy, _ := password.NewPolicy(0, 0, "", true)
+	uc := NewUserUsecase(ur, nil, tr, &memTransaction{}, newMemOutboxRepo(), mailer, hasher, policy, log.DefaultLogger,
+		&conf.JWT{Secret: "secret"},
+		&conf.Data{Mail: &conf.Data_Mail{LinkBaseUrl: "http://localhost:3000/"}}, nil)
+	return uc, ur, mailer
+}
+
+func TestVerifyEmail(t *testing.T) {
+	a := assert.New(t)
+	uc, ur, mailer := newTestUserUsecase()
+	ctx := context.Background()
+
+	_, err := uc.Register(ctx, "alice", "alice@example.com", "correct horse")
+	a.NoError(err)
+	a.Len(*mailer, 1)
+	a.Equal([]string{EventUserRegistered}, uc.or.(*memOutboxRepo).types())
+	a.Contains((*mailer)[0].Body, "http://localhost:3000/verify-email?token=")
+	a.False(ur.users[1].EmailVerified)
+
+	token := tokenFromMail((*mailer)[0])
+	a.NoError(uc.VerifyEmail(ctx, token))
+	a.True(ur.users[1].EmailVerified)
+	// token 只能使用一次
+	a.ErrorIs(uc.VerifyEmail(ctx, token), ErrInvalidToken)
+}
+
+func TestResetPassword(t *testing.T) {
+	a := assert.New(t)
+	uc, ur, mailer := newTestUserUsecase()
+	ctx := context.Background()
+
+	_, err := uc.Register(ctx, "bob", "bob@example.com", "old horse battery")
+	a.NoError(err)
+
+	// 不存在的邮箱不报错，也不发信
+	a.NoError(uc.RequestPasswordReset(ctx, "nobody@example.com"))
+	a.Len(*mailer, 1)
+
+	a.NoError(uc.RequestPasswordReset(ctx, "bob@example.com"))
+	a.NoError(uc.RequestPasswordReset(ctx, "bob@example.com"))
+	a.Len(*mailer, 3)
+	first, second := tokenFromMail((*mailer)[1]), tokenFromMail((*mailer)[2])
+
+	a.NoError(uc.ResetPassword(ctx, second, "new horse battery"))
+	a.True(verifyPassword(ur.users[1].PasswordHash, "new horse battery"))
+	a.ErrorIs(uc.ResetPassword(ctx, second, "another horse"), ErrInvalidToken)
+	// 重置成功后其他未使用的 token 失效
+	a.ErrorIs(uc.ResetPassword(ctx, first, "another horse"), ErrInvalidToken)
+}
+
+// tokenTransaction 在 fn 失败时恢复 token 的使用状态，模拟事务回滚
+type tokenTransaction struct {
+	tr *memTokenRepo
+}
+
+func (t *tokenTransaction) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	used := make(map[string]bool, len(t.tr.used))
+	for k, v := range t.tr.used {
+		used[k] = v
+	}
+	if err := fn(ctx); err != nil {
+		t.tr.used = used
+		return err
+	}
+	return nil
+}
+
+type failingUserRepo struct {
+	*memUserRepo
+}
+
+func (failingUserRepo) UpdateUser(ctx context.Context, u *User) (*User, error) {
+	return nil, errors.InternalServer("db", "connection reset")
+}
+
+func TestResetPasswordKeepsTokenOnFailure(t *testing.T) {
+	a := assert.New(t)
+	uc, ur, mailer := newTestUserUsecase()
+	ctx := context.Background()
+	uc.tx = &tokenTransaction{tr: uc.tr.(*memTokenRepo)}
+
+	_, err := uc.Register(ctx, "bob", "bob@example.com", "old horse battery")
+	a.NoError(err)
+	a.NoError(uc.RequestPasswordReset(ctx, "bob@example.com"))
+	token := tokenFromMail((*mailer)[1])
+
+	uc.ur = failingUserRepo{ur}
+	a.Error(uc.ResetPassword(ctx, token, "new horse battery"))
+
+	// 保存失败后同一个链接仍然可用
+	uc.ur = ur
+	a.NoError(uc.ResetPassword(ctx, token, "new horse battery"))
+	a.True(verifyPassword(ur.users[1].PasswordHash, "new horse battery"))
+}
+
+func TestRuntimeSettings(t *testing.T) {
+	a := assert.New(t)
+	uc, _, mailer := newTestUserUsecase()
+	ctx := context.Background()
+	uc.rld = conf.NewReloader(&conf.Bootstrap{Runtime: &conf.Runtime{
+		Features:       map[string]bool{FeatureRegistration: false},
+		VerifyEmailTtl: durationpb.New(2 * time.Hour),
+	}})
+
+	_, err := uc.Register(ctx, "carol", "carol@example.com", "correct horse")
+	a.ErrorIs(err, ErrRegistrationDisabled)
+
+	uc.rld = conf.NewReloader(&conf.Bootstrap{Runtime: &conf.Runtime{
+		VerifyEmailTtl: durationpb.New(2 * time.Hour),
+	}})
+	_, err = uc.Register(ctx, "carol", "carol@example.com", "correct horse")
+	a.NoError(err)
+	a.Contains((*mailer)[0].Body, "within 2h0m0s")
+}
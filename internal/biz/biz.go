@@ -1,6 +1,51 @@
 package biz
 
-import "github.com/google/wire"
+import (
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/markdown"
+	"realworld_demo/internal/pkg/password"
+
+	"github.com/google/wire"
+)
 
 // ProviderSet is biz providers. 依赖注入的集合
-var ProviderSet = wire.NewSet(NewSocialUsecase, NewUserUsecase)
+var ProviderSet = wire.NewSet(NewSocialUsecase, NewUserUsecase, NewMediaUsecase, NewNotificationUsecase,
+	NewStreamUsecase, NewFeedUsecase, NewEventBus, NewEventRelay,
+	NewWebhookUsecase, NewWebhookDispatcher, NewMarkdownRenderer, NewPasswordHasher, NewPasswordPolicy)
+
+// NewMarkdownRenderer .
+func NewMarkdownRenderer(rld *conf.Reloader) (*markdown.Renderer, error) {
+	var opts []markdown.Option
+	if n := rld.Current().GetRuntime().GetMarkdownCacheSize(); n > 0 {
+		opts = append(opts, markdown.WithCacheSize(int(n)))
+	}
+	r := markdown.NewRenderer(opts...)
+	if err := observeMarkdownCache(r); err != nil {
+		return nil, err
+	}
+	rld.Subscribe(func(bc *conf.Bootstrap) {
+		if n := bc.GetRuntime().GetMarkdownCacheSize(); n > 0 {
+			r.SetCacheSize(int(n))
+		}
+	})
+	return r, nil
+}
+
+// NewPasswordHasher .
+func NewPasswordHasher(c *conf.Auth) (*password.Hasher, error) {
+	hc := c.GetHasher()
+	ac := hc.GetArgon2()
+	return password.NewHasher(hc.GetAlgorithm(), int(hc.GetBcryptCost()), password.Argon2Params{
+		Time:       ac.GetTime(),
+		Memory:     ac.GetMemory(),
+		Threads:    uint8(ac.GetThreads()),
+		KeyLength:  ac.GetKeyLength(),
+		SaltLength: ac.GetSaltLength(),
+	})
+}
+
+// NewPasswordPolicy .
+func NewPasswordPolicy(c *conf.Auth) (*password.Policy, error) {
+	pc := c.GetPasswordPolicy()
+	return password.NewPolicy(int(pc.GetMinLength()), int(pc.GetMaxLength()), pc.GetBreachedList(), pc.GetRejectSimilar())
+}
@@ -0,0 +1,144 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Domain events are recorded in the outbox in the transaction of the change
+// and delivered by EventRelay after commit.
+const (
+	EventUserRegistered   = "user.registered"
+	EventArticlePublished = "article.published"
+	EventArticleFavorited = "article.favorited"
+	EventCommentAdded     = "comment.added"
+	EventUserFollowed     = "user.followed"
+)
+
+// DomainEvent is a change other systems may react to. Data holds the
+// payload matching Type. Delivery is at least once: consumers dedupe by ID.
+type DomainEvent struct {
+	ID         uint            `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Decode unmarshals the payload into v.
+func (e *DomainEvent) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+type UserRegistered struct {
+	UserID   uint   `json:"userId"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type ArticlePublished struct {
+	ArticleID uint     `json:"articleId"`
+	Slug      string   `json:"slug"`
+	Title     string   `json:"title"`
+	AuthorID  uint     `json:"authorId"`
+	TagList   []string `json:"tagList"`
+}
+
+type ArticleFavorited struct {
+	ArticleID uint   `json:"articleId"`
+	Slug      string `json:"slug"`
+	AuthorID  uint   `json:"authorId"`
+	UserID    uint   `json:"userId"`
+}
+
+type CommentAdded struct {
+	CommentID       uint   `json:"commentId"`
+	ArticleID       uint   `json:"articleId"`
+	Slug            string `json:"slug"`
+	ArticleAuthorID uint   `json:"articleAuthorId"`
+	AuthorID        uint   `json:"authorId"`
+}
+
+type UserFollowed struct {
+	FollowerID  uint `json:"followerId"`
+	FollowingID uint `json:"followingId"`
+}
+
+// OutboxEntry is an event in the outbox with its delivery state.
+type OutboxEntry struct {
+	Event    *DomainEvent
+	Attempts int
+	// names of the sinks that already accepted the event, skipped on retry
+	Delivered []string
+}
+
+type OutboxRepo interface {
+	// Add stores events with the ctx's transaction and sets their IDs.
+	Add(ctx context.Context, events ...*DomainEvent) error
+	// Claim leases up to limit events that are due, oldest first. Events
+	// whose lease expires without Done or Retry are claimed again.
+	Claim(ctx context.Context, owner string, lease time.Duration, limit int) ([]*OutboxEntry, error)
+	// Done marks the event delivered to all sinks.
+	Done(ctx context.Context, id uint) error
+	// Retry stores the failed attempt recorded in e. The event is due again
+	// at next, or never when dead.
+	Retry(ctx context.Context, e *OutboxEntry, next time.Time, lastErr string, dead bool) error
+}
+
+// recordEvent adds an event to the outbox; call it inside the transaction
+// of the change so that both are committed or neither.
+func recordEvent(ctx context.Context, or OutboxRepo, typ string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal %s event: %w", typ, err)
+	}
+	return or.Add(ctx, &DomainEvent{Type: typ, OccurredAt: time.Now(), Data: b})
+}
+
+// EventSink receives the events relayed from the outbox. An error makes the
+// relay retry the event later, so Deliver must tolerate duplicates.
+type EventSink interface {
+	// Name identifies the sink in the outbox, keep it stable across restarts.
+	Name() string
+	Deliver(ctx context.Context, e *DomainEvent) error
+}
+
+// EventHandler handles an event delivered by EventBus.
+type EventHandler func(ctx context.Context, e *DomainEvent) error
+
+// EventBus is the sink for in-process subscribers. When a handler fails the
+// event is delivered to all handlers again, so they must be idempotent.
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]EventHandler
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: map[string][]EventHandler{}}
+}
+
+// Subscribe calls h for events of type typ, or of every type when typ is
+// empty.
+func (b *EventBus) Subscribe(typ string, h EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[typ] = append(b.handlers[typ], h)
+}
+
+func (b *EventBus) Name() string { return "bus" }
+
+func (b *EventBus) Deliver(ctx context.Context, e *DomainEvent) error {
+	b.mu.RLock()
+	hs := append(append([]EventHandler(nil), b.handlers[e.Type]...), b.handlers[""]...)
+	b.mu.RUnlock()
+	var errs []error
+	for _, h := range hs {
+		if err := h(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
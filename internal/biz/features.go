@@ -0,0 +1,40 @@
+package biz
+
+import (
+	"time"
+
+	"realworld_demo/internal/conf"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// 功能开关，对应配置 runtime.features，未配置时为默认值，可热更新
+const (
+	FeatureRegistration      = "registration"
+	FeatureEmailVerification = "email_verification"
+)
+
+var defaultFeatures = map[string]bool{
+	FeatureRegistration:      true,
+	FeatureEmailVerification: true,
+}
+
+// ErrRegistrationDisabled is returned by Register when the registration
+// feature is switched off.
+var ErrRegistrationDisabled = errors.Forbidden("registration", "暂停注册")
+
+func featureEnabled(rld *conf.Reloader, name string) bool {
+	if on, ok := rld.Current().GetRuntime().GetFeatures()[name]; ok {
+		return on
+	}
+	return defaultFeatures[name]
+}
+
+// runtimeTTL returns the configured duration, or def when it is not set.
+func runtimeTTL(d *durationpb.Duration, def time.Duration) time.Duration {
+	if v := d.AsDuration(); v > 0 {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,144 @@
+package biz
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/feed"
+	"realworld_demo/internal/pkg/markdown"
+)
+
+const (
+	defaultFeedTitle  = "Conduit"
+	defaultFeedLimit  = 20
+	defaultFeedMaxAge = 5 * time.Minute
+)
+
+// FeedQuery selects the articles of a feed; the zero value is the feed of
+// all articles.
+type FeedQuery struct {
+	Author string
+	Tag    string
+}
+
+type FeedUsecase struct {
+	ar       ArticleRepo
+	pr       ProfileRepo
+	md       *markdown.Renderer
+	title    string
+	linkBase string
+	apiBase  string
+	limit    int64
+	maxAge   time.Duration
+
+	log *log.Helper
+}
+
+func NewFeedUsecase(ar ArticleRepo, pr ProfileRepo, md *markdown.Renderer, c *conf.Data, logger log.Logger) *FeedUsecase {
+	fc := c.GetFeeds()
+	uc := &FeedUsecase{
+		ar:       ar,
+		pr:       pr,
+		md:       md,
+		title:    fc.GetTitle(),
+		linkBase: fc.GetLinkBaseUrl(),
+		apiBase:  fc.GetApiBaseUrl(),
+		limit:    int64(fc.GetLimit()),
+		maxAge:   fc.GetMaxAge().AsDuration(),
+		log:      log.NewHelper(logger),
+	}
+	if uc.title == "" {
+		uc.title = defaultFeedTitle
+	}
+	if uc.linkBase == "" {
+		uc.linkBase = c.GetMail().GetLinkBaseUrl()
+	}
+	uc.linkBase = strings.TrimSuffix(uc.linkBase, "/")
+	if uc.apiBase == "" {
+		uc.apiBase = uc.linkBase
+	}
+	uc.apiBase = strings.TrimSuffix(uc.apiBase, "/")
+	if uc.limit <= 0 {
+		uc.limit = defaultFeedLimit
+	}
+	if fc.GetMaxAge() == nil {
+		uc.maxAge = defaultFeedMaxAge
+	}
+	return uc
+}
+
+// MaxAge is how long clients may cache a feed.
+func (uc *FeedUsecase) MaxAge() time.Duration {
+	return uc.maxAge
+}
+
+// FeedURL is the absolute URL of the feed served at path.
+func (uc *FeedUsecase) FeedURL(path string) string {
+	return uc.apiBase + path
+}
+
+// Feed returns the newest articles matching q, newest first. The caller
+// sets FeedURL from uc.FeedURL. Updated is the latest update of the articles, zero for an
+// empty feed.
+func (uc *FeedUsecase) Feed(ctx context.Context, q FeedQuery) (*feed.Feed, error) {
+	f := &feed.Feed{Title: uc.title, Link: uc.linkBase + "/"}
+	opts := []ListOption{ListLimit(uc.limit)}
+	switch {
+	case q.Author != "":
+		p, err := uc.pr.GetProfile(ctx, q.Author)
+		if err != nil {
+			return nil, err
+		}
+		f.Title += ": " + p.Username
+		f.Description = "Articles by " + p.Username
+		f.Link = uc.profileLink(p.Username)
+		opts = append(opts, ListAuthor(p.Username))
+	case q.Tag != "":
+		f.Title += ": #" + q.Tag
+		f.Description = "Articles tagged " + q.Tag
+		opts = append(opts, ListTag(q.Tag))
+	}
+
+	articles, err := uc.ar.List(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range articles {
+		it := &feed.Item{
+			Title:      a.Title,
+			Link:       uc.linkBase + "/article/" + url.PathEscape(a.Slug),
+			Summary:    a.Description,
+			Categories: a.TagList,
+			Published:  parseTime(a.CreatedAt),
+			Updated:    parseTime(a.UpdatedAt),
+		}
+		if res, err := uc.md.Render(a.Body); err != nil {
+			uc.log.WithContext(ctx).Errorf("渲染文章 %s 失败: %v", a.Slug, err)
+		} else {
+			it.ContentHTML = res.HTML
+		}
+		if a.Author != nil {
+			it.Author = feed.Author{Name: a.Author.Username, URL: uc.profileLink(a.Author.Username)}
+		}
+		if it.Updated.After(f.Updated) {
+			f.Updated = it.Updated
+		}
+		f.Items = append(f.Items, it)
+	}
+	return f, nil
+}
+
+func (uc *FeedUsecase) profileLink(username string) string {
+	return uc.linkBase + "/profile/" + url.PathEscape(username)
+}
+
+// parseTime reads the RFC 3339 timestamps of Article.
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
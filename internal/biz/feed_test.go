@@ -0,0 +1,70 @@
+package biz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/markdown"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// listArticleRepo 只实现 List，记录收到的选项
+type listArticleRepo struct {
+	ArticleRepo
+	articles []*Article
+	opts     ListOptions
+}
+
+func (r *listArticleRepo) List(ctx context.Context, opts ...ListOption) ([]*Article, error) {
+	r.opts = ListOptions{}
+	for _, opt := range opts {
+		opt(&r.opts)
+	}
+	return r.articles, nil
+}
+
+func TestFeed(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	ar := &listArticleRepo{articles: []*Article{
+		{Slug: "new", Title: "New", Body: "**hi**", TagList: []string{"go"}, CreatedAt: "2024-05-02T00:00:00Z", UpdatedAt: "2024-05-02T00:00:00Z", Author: &Profile{Username: "alice"}},
+		{Slug: "old", Title: "Old", CreatedAt: "2024-05-01T00:00:00Z", UpdatedAt: "2024-05-03T00:00:00Z", Author: &Profile{Username: "bob"}},
+	}}
+	pr := &memProfileRepo{profiles: map[string]*Profile{"alice": {ID: 1, Username: "alice"}}}
+	uc := NewFeedUsecase(ar, pr, markdown.NewRenderer(), &conf.Data{Mail: &conf.Data_Mail{LinkBaseUrl: "http://localhost:3000/"}}, log.DefaultLogger)
+	a.Equal(defaultFeedMaxAge, uc.MaxAge())
+
+	f, err := uc.Feed(ctx, FeedQuery{})
+	a.NoError(err)
+	a.Equal("Conduit", f.Title)
+	a.Equal("http://localhost:3000/", f.Link)
+	a.Equal(int64(defaultFeedLimit), ar.opts.Limit)
+	// 最后修改时间取所有文章中最新的更新时间
+	a.Equal(time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC), f.Updated)
+	if a.Len(f.Items, 2) {
+		it := f.Items[0]
+		a.Equal("http://localhost:3000/article/new", it.Link)
+		a.Equal("<p><strong>hi</strong></p>\n", it.ContentHTML)
+		a.Equal("http://localhost:3000/profile/alice", it.Author.URL)
+		a.Equal([]string{"go"}, it.Categories)
+	}
+
+	f, err = uc.Feed(ctx, FeedQuery{Author: "alice"})
+	a.NoError(err)
+	a.Equal("Conduit: alice", f.Title)
+	a.Equal("http://localhost:3000/profile/alice", f.Link)
+	a.Equal("alice", ar.opts.Filters["author"])
+
+	_, err = uc.Feed(ctx, FeedQuery{Author: "nobody"})
+	a.True(errors.IsNotFound(err))
+
+	_, err = uc.Feed(ctx, FeedQuery{Tag: "go"})
+	a.NoError(err)
+	a.Equal("go", ar.opts.Tag)
+	a.Empty(ar.opts.Filters)
+}
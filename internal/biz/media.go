@@ -0,0 +1,178 @@
+package biz
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"realworld_demo/internal/conf"
+	auth "realworld_demo/internal/pkg/middleware"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"golang.org/x/image/draw"
+)
+
+const (
+	defaultMaxUploadSize = 5 << 20
+	// 防止解压炸弹
+	maxImagePixels = 40_000_000
+)
+
+// AvatarSizes are the square thumbnail edges generated for every avatar.
+var AvatarSizes = []int{64, 128, 256}
+
+var imageExts = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// ObjectStorage stores uploaded files and returns their public URL.
+type ObjectStorage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (url string, err error)
+	Delete(ctx context.Context, key string) error
+}
+
+type Upload struct {
+	URL        string
+	Thumbnails map[int]string
+}
+
+type MediaUsecase struct {
+	st      ObjectStorage
+	maxSize int64
+
+	log *log.Helper
+}
+
+func NewMediaUsecase(st ObjectStorage, c *conf.Data, logger log.Logger) *MediaUsecase {
+	maxSize := c.GetStorage().GetMaxSize()
+	if maxSize <= 0 {
+		maxSize = defaultMaxUploadSize
+	}
+	return &MediaUsecase{st: st, maxSize: maxSize, log: log.NewHelper(logger)}
+}
+
+// MaxSize is the largest accepted upload in bytes.
+func (uc *MediaUsecase) MaxSize() int64 {
+	return uc.maxSize
+}
+
+// readImage reads r, enforcing the size limit and checking that the content
+// really is one of the accepted image types.
+func (uc *MediaUsecase) readImage(r io.Reader) (b []byte, contentType string, err error) {
+	b, err = io.ReadAll(io.LimitReader(r, uc.maxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(b)) > uc.maxSize {
+		return nil, "", errors.New(413, "file", fmt.Sprintf("不能超过 %d 字节", uc.maxSize))
+	}
+	if len(b) == 0 {
+		return nil, "", errors.New(422, "file", "cannot be empty")
+	}
+	contentType = http.DetectContentType(b)
+	if _, ok := imageExts[contentType]; !ok {
+		return nil, "", errors.New(415, "file", "不支持的文件类型 "+contentType)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, "", errors.New(422, "file", "无法解析的图片")
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, "", errors.New(422, "file", "图片尺寸过大")
+	}
+	return b, contentType, nil
+}
+
+func newObjectName() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// UploadImage stores an article image as-is.
+func (uc *MediaUsecase) UploadImage(ctx context.Context, r io.Reader) (*Upload, error) {
+	cu := auth.FromContext(ctx)
+	b, ct, err := uc.readImage(r)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("images/%d/%s.%s", cu.UserID, newObjectName(), imageExts[ct])
+	url, err := uc.st.Put(ctx, key, bytes.NewReader(b), int64(len(b)), ct)
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("保存图片失败: %v", err)
+		return nil, errors.InternalServer("file", "保存图片失败")
+	}
+	return &Upload{URL: url}, nil
+}
+
+// UploadAvatar resizes the image to AvatarSizes and stores every thumbnail.
+// URL points at the largest one and can be used as UpdateUser.Image.
+func (uc *MediaUsecase) UploadAvatar(ctx context.Context, r io.Reader) (*Upload, error) {
+	cu := auth.FromContext(ctx)
+	b, ct, err := uc.readImage(r)
+	if err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, errors.New(422, "file", "无法解析的图片")
+	}
+
+	// gif 头像只保留第一帧，统一存为 png
+	if ct == "image/gif" {
+		ct = "image/png"
+	}
+	name := newObjectName()
+	rv := &Upload{Thumbnails: make(map[int]string, len(AvatarSizes))}
+	for _, size := range AvatarSizes {
+		var buf bytes.Buffer
+		if err := encodeImage(&buf, thumbnail(src, size), ct); err != nil {
+			return nil, err
+		}
+		key := fmt.Sprintf("avatars/%d/%s_%d.%s", cu.UserID, name, size, imageExts[ct])
+		url, err := uc.st.Put(ctx, key, &buf, int64(buf.Len()), ct)
+		if err != nil {
+			uc.log.WithContext(ctx).Errorf("保存头像失败: %v", err)
+			return nil, errors.InternalServer("file", "保存头像失败")
+		}
+		rv.Thumbnails[size] = url
+		rv.URL = url
+	}
+	return rv, nil
+}
+
+// thumbnail center-crops src to a square and scales it to size x size.
+func thumbnail(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	edge := b.Dx()
+	if b.Dy() < edge {
+		edge = b.Dy()
+	}
+	x0 := b.Min.X + (b.Dx()-edge)/2
+	y0 := b.Min.Y + (b.Dy()-edge)/2
+	crop := image.Rect(x0, y0, x0+edge, y0+edge)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+	return dst
+}
+
+func encodeImage(w io.Writer, img image.Image, contentType string) error {
+	switch contentType {
+	case "image/jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 90})
+	default:
+		return png.Encode(w, img)
+	}
+}
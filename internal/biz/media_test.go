@@ -0,0 +1,66 @@
+package biz
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"realworld_demo/internal/conf"
+	auth "realworld_demo/internal/pkg/middleware"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type memStorage map[string][]byte
+
+func (m memStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (string, error) {
+	b, err := io.ReadAll(r)
+	m[key] = b
+	return "/uploads/" + key, err
+}
+
+func (m memStorage) Delete(ctx context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
+func TestUploadAvatar(t *testing.T) {
+	a := assert.New(t)
+	st := memStorage{}
+	uc := NewMediaUsecase(st, &conf.Data{Storage: &conf.Data_Storage{MaxSize: 1 << 20}}, log.DefaultLogger)
+	ctx := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 7})
+
+	var buf bytes.Buffer
+	a.NoError(png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 300, 200))))
+	rv, err := uc.UploadAvatar(ctx, &buf)
+	a.NoError(err)
+	a.Len(rv.Thumbnails, len(AvatarSizes))
+	a.Equal(rv.Thumbnails[256], rv.URL)
+	a.True(strings.HasPrefix(rv.URL, "/uploads/avatars/7/"))
+
+	for _, size := range AvatarSizes {
+		key := strings.TrimPrefix(rv.Thumbnails[size], "/uploads/")
+		cfg, err := png.DecodeConfig(bytes.NewReader(st[key]))
+		a.NoError(err)
+		a.Equal(size, cfg.Width)
+		a.Equal(size, cfg.Height)
+	}
+}
+
+func TestUploadImageValidation(t *testing.T) {
+	a := assert.New(t)
+	uc := NewMediaUsecase(memStorage{}, &conf.Data{Storage: &conf.Data_Storage{MaxSize: 16}}, log.DefaultLogger)
+	ctx := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 7})
+
+	_, err := uc.UploadImage(ctx, strings.NewReader("<html></html>"))
+	a.Equal(415, int(errors.FromError(err).Code))
+
+	_, err = uc.UploadImage(ctx, strings.NewReader(strings.Repeat("x", 17)))
+	a.Equal(413, int(errors.FromError(err).Code))
+}
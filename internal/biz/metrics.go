@@ -0,0 +1,68 @@
+package biz
+
+import (
+	"context"
+
+	"realworld_demo/internal/pkg/markdown"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// 业务指标。仪表在包初始化时从全局 MeterProvider 创建，main 设置好
+// Prometheus exporter 之后才会真正导出
+var (
+	meter = otel.Meter("realworld_demo/internal/biz")
+
+	registrationsCounter, _ = meter.Int64Counter("realworld_registrations",
+		metric.WithDescription("Users registered."))
+	loginsCounter, _ = meter.Int64Counter("realworld_logins",
+		metric.WithDescription("Login attempts by result."))
+	articlesCreatedCounter, _ = meter.Int64Counter("realworld_articles_created",
+		metric.WithDescription("Articles created."))
+	commentsCreatedCounter, _ = meter.Int64Counter("realworld_comments_created",
+		metric.WithDescription("Comments created."))
+	favoritesCounter, _ = meter.Int64Counter("realworld_favorites",
+		metric.WithDescription("Article favorites and unfavorites by action."))
+	webhookDeliveriesCounter, _ = meter.Int64Counter("realworld_webhook_deliveries",
+		metric.WithDescription("Webhook delivery attempts by resulting status."))
+)
+
+func countLogin(ctx context.Context, ok bool) {
+	result := "failure"
+	if ok {
+		result = "success"
+	}
+	loginsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+}
+
+func countFavorite(ctx context.Context, action string) {
+	favoritesCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("action", action)))
+}
+
+func countWebhookDelivery(ctx context.Context, status string) {
+	webhookDeliveriesCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("status", status)))
+}
+
+// observeMarkdownCache exports the renderer cache counters; the hit ratio is
+// hits / (hits + misses).
+func observeMarkdownCache(r *markdown.Renderer) error {
+	hits, err := meter.Int64ObservableCounter("realworld_markdown_cache_hits",
+		metric.WithDescription("Markdown render cache hits."))
+	if err != nil {
+		return err
+	}
+	misses, err := meter.Int64ObservableCounter("realworld_markdown_cache_misses",
+		metric.WithDescription("Markdown render cache misses."))
+	if err != nil {
+		return err
+	}
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		h, m := r.CacheStats()
+		o.ObserveInt64(hits, int64(h))
+		o.ObserveInt64(misses, int64(m))
+		return nil
+	}, hits, misses)
+	return err
+}
@@ -0,0 +1,171 @@
+package biz
+
+import (
+	"context"
+	"time"
+
+	auth "realworld_demo/internal/pkg/middleware"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	NotificationFollow   = "follow"
+	NotificationFavorite = "favorite"
+	NotificationComment  = "comment"
+
+	defaultNotificationLimit = 20
+	maxNotificationLimit     = 100
+)
+
+// Notification tells UserID that ActorID followed them, favorited their
+// article or commented on it.
+type Notification struct {
+	ID        uint
+	UserID    uint
+	ActorID   uint
+	Actor     *Profile
+	Type      string
+	ArticleID uint
+	// ArticleSlug and ArticleTitle are filled when listing
+	ArticleSlug  string
+	ArticleTitle string
+	CommentID    uint
+	Read         bool
+	CreatedAt    time.Time
+}
+
+// NotificationPreferences are the kinds of notifications a user wants.
+// Users without stored preferences get all of them.
+type NotificationPreferences struct {
+	Follow   bool
+	Favorite bool
+	Comment  bool
+}
+
+func (p *NotificationPreferences) allows(typ string) bool {
+	switch typ {
+	case NotificationFollow:
+		return p.Follow
+	case NotificationFavorite:
+		return p.Favorite
+	case NotificationComment:
+		return p.Comment
+	}
+	return false
+}
+
+// NotificationPreferencesUpdate holds the preferences present in a request.
+type NotificationPreferencesUpdate struct {
+	Follow   *bool
+	Favorite *bool
+	Comment  *bool
+}
+
+type NotificationRepo interface {
+	Create(ctx context.Context, n *Notification) error
+	List(ctx context.Context, userID uint, unreadOnly bool, limit, offset int64) ([]*Notification, error)
+	UnreadCount(ctx context.Context, userID uint) (int64, error)
+	// MarkRead only touches notifications that belong to userID.
+	MarkRead(ctx context.Context, userID uint, ids []uint) error
+	MarkAllRead(ctx context.Context, userID uint) error
+	GetPreferences(ctx context.Context, userID uint) (*NotificationPreferences, error)
+	SavePreferences(ctx context.Context, userID uint, p *NotificationPreferences) error
+}
+
+type NotificationUsecase struct {
+	nr  NotificationRepo
+	log *log.Helper
+}
+
+func NewNotificationUsecase(nr NotificationRepo, logger log.Logger) *NotificationUsecase {
+	return &NotificationUsecase{nr: nr, log: log.NewHelper(logger)}
+}
+
+// notify stores n for its recipient unless it is about their own action or
+// they turned this kind of notification off. Call it with the ctx of the
+// transaction that performs the action.
+func notify(ctx context.Context, nr NotificationRepo, n *Notification) error {
+	if n.UserID == 0 || n.UserID == n.ActorID {
+		return nil
+	}
+	p, err := nr.GetPreferences(ctx, n.UserID)
+	if err != nil {
+		return err
+	}
+	if !p.allows(n.Type) {
+		return nil
+	}
+	return nr.Create(ctx, n)
+}
+
+// List returns the notifications of the current user, newest first, and
+// the number of unread ones.
+func (uc *NotificationUsecase) List(ctx context.Context, unreadOnly bool, limit, offset int64) ([]*Notification, int64, error) {
+	uid := auth.FromContext(ctx).UserID
+	switch {
+	case limit <= 0:
+		limit = defaultNotificationLimit
+	case limit > maxNotificationLimit:
+		limit = maxNotificationLimit
+	}
+	if offset < 0 {
+		return nil, 0, errors.New(422, "offset", "must not be negative")
+	}
+	rv, err := uc.nr.List(ctx, uid, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := uc.nr.UnreadCount(ctx, uid)
+	if err != nil {
+		return nil, 0, err
+	}
+	return rv, unread, nil
+}
+
+func (uc *NotificationUsecase) UnreadCount(ctx context.Context) (int64, error) {
+	return uc.nr.UnreadCount(ctx, auth.FromContext(ctx).UserID)
+}
+
+// MarkRead marks the given notifications of the current user read and
+// returns the remaining unread count. Unknown ids are ignored.
+func (uc *NotificationUsecase) MarkRead(ctx context.Context, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, errors.New(422, "ids", "can't be blank")
+	}
+	uid := auth.FromContext(ctx).UserID
+	if err := uc.nr.MarkRead(ctx, uid, ids); err != nil {
+		return 0, err
+	}
+	return uc.nr.UnreadCount(ctx, uid)
+}
+
+func (uc *NotificationUsecase) MarkAllRead(ctx context.Context) error {
+	return uc.nr.MarkAllRead(ctx, auth.FromContext(ctx).UserID)
+}
+
+func (uc *NotificationUsecase) GetPreferences(ctx context.Context) (*NotificationPreferences, error) {
+	return uc.nr.GetPreferences(ctx, auth.FromContext(ctx).UserID)
+}
+
+func (uc *NotificationUsecase) UpdatePreferences(ctx context.Context, in *NotificationPreferencesUpdate) (*NotificationPreferences, error) {
+	uid := auth.FromContext(ctx).UserID
+	p, err := uc.nr.GetPreferences(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if in.Follow != nil {
+		p.Follow = *in.Follow
+	}
+	if in.Favorite != nil {
+		p.Favorite = *in.Favorite
+	}
+	if in.Comment != nil {
+		p.Comment = *in.Comment
+	}
+	if err := uc.nr.SavePreferences(ctx, uid, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,248 @@
+package biz
+
+import (
+	"context"
+	"testing"
+
+	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/pubsub"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type memNotificationRepo struct {
+	notifications []*Notification
+	prefs         map[uint]*NotificationPreferences
+}
+
+func newMemNotificationRepo() *memNotificationRepo {
+	return &memNotificationRepo{prefs: map[uint]*NotificationPreferences{}}
+}
+
+func (r *memNotificationRepo) Create(ctx context.Context, n *Notification) error {
+	n.ID = uint(len(r.notifications) + 1)
+	r.notifications = append(r.notifications, n)
+	return nil
+}
+
+func (r *memNotificationRepo) List(ctx context.Context, userID uint, unreadOnly bool, limit, offset int64) ([]*Notification, error) {
+	var rv []*Notification
+	for i := len(r.notifications) - 1; i >= 0; i-- {
+		n := r.notifications[i]
+		if n.UserID == userID && !(unreadOnly && n.Read) {
+			rv = append(rv, n)
+		}
+	}
+	if offset >= int64(len(rv)) {
+		return nil, nil
+	}
+	rv = rv[offset:]
+	if limit < int64(len(rv)) {
+		rv = rv[:limit]
+	}
+	return rv, nil
+}
+
+func (r *memNotificationRepo) UnreadCount(ctx context.Context, userID uint) (n int64, err error) {
+	for _, x := range r.notifications {
+		if x.UserID == userID && !x.Read {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memNotificationRepo) MarkRead(ctx context.Context, userID uint, ids []uint) error {
+	for _, x := range r.notifications {
+		for _, id := range ids {
+			if x.ID == id && x.UserID == userID {
+				x.Read = true
+			}
+		}
+	}
+	return nil
+}
+
+func (r *memNotificationRepo) MarkAllRead(ctx context.Context, userID uint) error {
+	for _, x := range r.notifications {
+		if x.UserID == userID {
+			x.Read = true
+		}
+	}
+	return nil
+}
+
+func (r *memNotificationRepo) GetPreferences(ctx context.Context, userID uint) (*NotificationPreferences, error) {
+	if p, ok := r.prefs[userID]; ok {
+		c := *p
+		return &c, nil
+	}
+	return &NotificationPreferences{Follow: true, Favorite: true, Comment: true}, nil
+}
+
+func (r *memNotificationRepo) SavePreferences(ctx context.Context, userID uint, p *NotificationPreferences) error {
+	c := *p
+	r.prefs[userID] = &c
+	return nil
+}
+
+// memTransaction 直接执行 fn，记录执行次数
+type memTransaction struct {
+	calls int
+}
+
+func (t *memTransaction) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.calls++
+	return fn(ctx)
+}
+
+type memProfileRepo struct {
+	profiles map[string]*Profile
+	follows  map[[2]uint]bool
+}
+
+func (r *memProfileRepo) GetProfile(ctx context.Context, username string) (*Profile, error) {
+	p, ok := r.profiles[username]
+	if !ok {
+		return nil, errors.NotFound("user", "not found by username")
+	}
+	c := *p
+	return &c, nil
+}
+
+func (r *memProfileRepo) FollowUser(ctx context.Context, currentUserID uint, followingID uint) (bool, error) {
+	k := [2]uint{currentUserID, followingID}
+	if r.follows[k] {
+		return false, nil
+	}
+	r.follows[k] = true
+	return true, nil
+}
+
+func (r *memProfileRepo) UnfollowUser(ctx context.Context, currentUserID uint, followingID uint) error {
+	delete(r.follows, [2]uint{currentUserID, followingID})
+	return nil
+}
+
+func (r *memProfileRepo) GetUserFollowingStatus(ctx context.Context, currentUserID uint, userIDs []uint) ([]bool, error) {
+	rv := make([]bool, len(userIDs))
+	for i, id := range userIDs {
+		rv[i] = r.follows[[2]uint{currentUserID, id}]
+	}
+	return rv, nil
+}
+
+func (r *memProfileRepo) ListFollowerIDs(ctx context.Context, userID uint) (ids []uint, err error) {
+	for k := range r.follows {
+		if k[1] == userID {
+			ids = append(ids, k[0])
+		}
+	}
+	return ids, nil
+}
+
+func TestNotify(t *testing.T) {
+	a := assert.New(t)
+	nr := newMemNotificationRepo()
+	ctx := context.Background()
+
+	// 自己的操作不通知
+	a.NoError(notify(ctx, nr, &Notification{UserID: 1, ActorID: 1, Type: NotificationFollow}))
+	a.Empty(nr.notifications)
+
+	a.NoError(notify(ctx, nr, &Notification{UserID: 1, ActorID: 2, Type: NotificationFavorite}))
+	a.Len(nr.notifications, 1)
+
+	nr.prefs[1] = &NotificationPreferences{Follow: true, Comment: true}
+	a.NoError(notify(ctx, nr, &Notification{UserID: 1, ActorID: 2, Type: NotificationFavorite}))
+	a.Len(nr.notifications, 1)
+	a.NoError(notify(ctx, nr, &Notification{UserID: 1, ActorID: 2, Type: NotificationComment}))
+	a.Len(nr.notifications, 2)
+}
+
+func TestFollowUserNotifies(t *testing.T) {
+	a := assert.New(t)
+	nr := newMemNotificationRepo()
+	tx := &memTransaction{}
+	pr := &memProfileRepo{
+		profiles: map[string]*Profile{"alice": {ID: 1, Username: "alice"}},
+		follows:  map[[2]uint]bool{},
+	}
+	ur := &memUserRepo{users: map[uint]*User{}}
+	or := newMemOutboxRepo()
+	uc := NewSocialUsecase(nil, pr, nil, ur, nil, nr, tx, or, pubsub.NewMemoryBroker(0), nil, log.DefaultLogger)
+	ctx := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 2})
+
+	_, err := uc.FollowUser(ctx, "alice")
+	a.NoError(err)
+	a.Equal(1, tx.calls)
+	a.True(pr.follows[[2]uint{2, 1}])
+	if a.Len(nr.notifications, 1) {
+		n := nr.notifications[0]
+		a.Equal(uint(1), n.UserID)
+		a.Equal(uint(2), n.ActorID)
+		a.Equal(NotificationFollow, n.Type)
+	}
+	a.Equal([]string{EventUserFollowed}, or.types())
+
+	// 已经关注时不再通知
+	_, err = uc.FollowUser(ctx, "alice")
+	a.NoError(err)
+	a.Len(nr.notifications, 1)
+	a.Equal([]string{EventUserFollowed}, or.types())
+}
+
+func TestNotificationUsecase(t *testing.T) {
+	a := assert.New(t)
+	nr := newMemNotificationRepo()
+	uc := NewNotificationUsecase(nr, log.DefaultLogger)
+	ctx := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 1})
+
+	for i := 0; i < 3; i++ {
+		a.NoError(nr.Create(ctx, &Notification{UserID: 1, ActorID: 2, Type: NotificationFollow}))
+	}
+	a.NoError(nr.Create(ctx, &Notification{UserID: 3, ActorID: 2, Type: NotificationFollow}))
+
+	list, unread, err := uc.List(ctx, false, 0, 0)
+	a.NoError(err)
+	a.Len(list, 3)
+	a.Equal(int64(3), unread)
+	a.Equal(uint(3), list[0].ID)
+
+	// 别人的通知不受影响
+	unread, err = uc.MarkRead(ctx, []uint{1, 4})
+	a.NoError(err)
+	a.Equal(int64(2), unread)
+	a.False(nr.notifications[3].Read)
+
+	list, _, err = uc.List(ctx, true, 0, 0)
+	a.NoError(err)
+	a.Len(list, 2)
+
+	_, err = uc.MarkRead(ctx, nil)
+	a.Error(err)
+
+	a.NoError(uc.MarkAllRead(ctx))
+	unread, err = uc.UnreadCount(ctx)
+	a.NoError(err)
+	a.Zero(unread)
+}
+
+func TestUpdateNotificationPreferences(t *testing.T) {
+	a := assert.New(t)
+	nr := newMemNotificationRepo()
+	uc := NewNotificationUsecase(nr, log.DefaultLogger)
+	ctx := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 1})
+
+	off := false
+	p, err := uc.UpdatePreferences(ctx, &NotificationPreferencesUpdate{Favorite: &off})
+	a.NoError(err)
+	a.Equal(&NotificationPreferences{Follow: true, Favorite: false, Comment: true}, p)
+
+	p, err = uc.GetPreferences(ctx)
+	a.NoError(err)
+	a.False(p.Favorite)
+	a.True(p.Follow)
+}
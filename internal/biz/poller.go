@@ -0,0 +1,108 @@
+package biz
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// poller runs a batch function every interval until stopped. A full batch
+// is followed by the next one at once, so a backlog is drained quickly.
+type poller struct {
+	name      string
+	interval  time.Duration
+	batchSize int
+	log       *log.Helper
+
+	mu     sync.Mutex
+	stop   chan struct{}
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+func newPoller(name string, interval time.Duration, batchSize int, helper *log.Helper) *poller {
+	return &poller{name: name, interval: interval, batchSize: batchSize, log: helper, stop: make(chan struct{})}
+}
+
+// run calls batch until stop; batch returns how many items it handled.
+func (p *poller) run(ctx context.Context, batch func(ctx context.Context) (int, error)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	p.mu.Lock()
+	p.done, p.cancel = make(chan struct{}), cancel
+	p.mu.Unlock()
+	defer close(p.done)
+
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+	for {
+		n, err := batch(ctx)
+		if err != nil && ctx.Err() == nil {
+			p.log.Errorf("%s: %v", p.name, err)
+		}
+		// 一批取满说明还有积压，立即继续
+		if n >= p.batchSize && err == nil {
+			select {
+			case <-p.stop:
+				return nil
+			default:
+				continue
+			}
+		}
+		select {
+		case <-p.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// shutdown waits for the batch in progress; when ctx ends first the batch
+// is cancelled.
+func (p *poller) shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	done, cancel := p.done, p.cancel
+	p.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// drain calls batch until a batch is not full, fails or ctx ends. It is the
+// last pass after shutdown, so nothing due is left for the next start.
+func (p *poller) drain(ctx context.Context, batch func(ctx context.Context) (int, error)) error {
+	for ctx.Err() == nil {
+		n, err := batch(ctx)
+		if err != nil || n < p.batchSize {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// retryBackoff doubles the wait after each failed attempt, from base up to
+// maxBackoff.
+func retryBackoff(base, maxBackoff time.Duration, attempts int) time.Duration {
+	d := base
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
@@ -33,3 +33,19 @@ func ListCurrentUsername(name string) ListOption {
 		o.CurrentUsername = name
 	}
 }
+
+func ListTag(tag string) ListOption {
+	return func(o *ListOptions) {
+		o.Tag = tag
+	}
+}
+
+// ListAuthor keeps the articles written by the user with this username.
+func ListAuthor(username string) ListOption {
+	return func(o *ListOptions) {
+		if o.Filters == nil {
+			o.Filters = make(map[string]string)
+		}
+		o.Filters["author"] = username
+	}
+}
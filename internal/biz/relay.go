@@ -0,0 +1,126 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"realworld_demo/internal/conf"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	defaultRelayInterval    = time.Second
+	defaultRelayBatchSize   = 100
+	defaultRelayMaxAttempts = 20
+
+	// 租约内未完成的事件会被其他实例重新领取
+	relayLease      = 5 * time.Minute
+	relayMaxBackoff = time.Hour
+	maxLastErrorLen = 1024
+)
+
+// workerID identifies this process in claims of the outbox and deliveries.
+func workerID() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// EventRelay delivers the events in the outbox to the bus and the sinks. It
+// runs as a kratos server: Start polls until Stop. Several instances may
+// run at once, each event is claimed by one of them at a time.
+type EventRelay struct {
+	or          OutboxRepo
+	sinks       []EventSink
+	maxAttempts int
+	owner       string
+	p           *poller
+	log         *log.Helper
+}
+
+func NewEventRelay(or OutboxRepo, bus *EventBus, sinks []EventSink, c *conf.Data, logger log.Logger) *EventRelay {
+	ec := c.GetEvents()
+	interval, batchSize := defaultRelayInterval, defaultRelayBatchSize
+	if d := ec.GetPollInterval().AsDuration(); d > 0 {
+		interval = d
+	}
+	if n := ec.GetBatchSize(); n > 0 {
+		batchSize = int(n)
+	}
+	r := &EventRelay{
+		or:          or,
+		sinks:       append([]EventSink{bus}, sinks...),
+		maxAttempts: defaultRelayMaxAttempts,
+		owner:       workerID(),
+		log:         log.NewHelper(logger),
+	}
+	if n := ec.GetMaxAttempts(); n > 0 {
+		r.maxAttempts = int(n)
+	}
+	r.p = newPoller("relay outbox events", interval, batchSize, r.log)
+	return r
+}
+
+// Start relays until Stop is called.
+func (r *EventRelay) Start(ctx context.Context) error {
+	r.log.Infof("[Relay] delivering outbox events to %d sinks every %s", len(r.sinks), r.p.interval)
+	return r.p.run(ctx, r.Relay)
+}
+
+// Stop waits for the batch in progress; when ctx ends first, the deliveries
+// are cancelled and the remaining events are claimed again after the lease.
+func (r *EventRelay) Stop(ctx context.Context) error {
+	return r.p.shutdown(ctx)
+}
+
+// Flush delivers the events that are due, after Stop and before the
+// database is closed.
+func (r *EventRelay) Flush(ctx context.Context) error {
+	return r.p.drain(ctx, r.Relay)
+}
+
+// Relay delivers one batch of due events and returns how many were claimed.
+func (r *EventRelay) Relay(ctx context.Context) (int, error) {
+	entries, err := r.or.Claim(ctx, r.owner, relayLease, r.p.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range entries {
+		if err := r.deliver(ctx, e); err != nil {
+			return len(entries), err
+		}
+	}
+	return len(entries), nil
+}
+
+// deliver sends e to the sinks that have not accepted it yet. Only errors
+// updating the outbox are returned.
+func (r *EventRelay) deliver(ctx context.Context, e *OutboxEntry) error {
+	var failed error
+	for _, s := range r.sinks {
+		if slices.Contains(e.Delivered, s.Name()) {
+			continue
+		}
+		if err := s.Deliver(ctx, e.Event); err != nil {
+			failed = fmt.Errorf("%s: %w", s.Name(), err)
+			continue
+		}
+		e.Delivered = append(e.Delivered, s.Name())
+	}
+	if failed == nil {
+		return r.or.Done(ctx, e.Event.ID)
+	}
+
+	e.Attempts++
+	dead := e.Attempts >= r.maxAttempts
+	lastErr := truncateError(failed)
+	if dead {
+		r.log.WithContext(ctx).Errorf("give up %s event %d after %d attempts: %s", e.Event.Type, e.Event.ID, e.Attempts, lastErr)
+	} else {
+		r.log.WithContext(ctx).Warnf("deliver %s event %d (attempt %d): %s", e.Event.Type, e.Event.ID, e.Attempts, lastErr)
+	}
+	return r.or.Retry(ctx, e, time.Now().Add(retryBackoff(r.p.interval, relayMaxBackoff, e.Attempts)), lastErr, dead)
+}
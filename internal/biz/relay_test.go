@@ -0,0 +1,215 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"realworld_demo/internal/conf"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type memOutboxEvent struct {
+	entry   OutboxEntry
+	next    time.Time
+	done    bool
+	dead    bool
+	lastErr string
+}
+
+type memOutboxRepo struct {
+	mu     sync.Mutex
+	events []*memOutboxEvent
+}
+
+func newMemOutboxRepo() *memOutboxRepo {
+	return &memOutboxRepo{}
+}
+
+func (r *memOutboxRepo) Add(ctx context.Context, events ...*DomainEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range events {
+		e.ID = uint(len(r.events) + 1)
+		r.events = append(r.events, &memOutboxEvent{entry: OutboxEntry{Event: e}, next: e.OccurredAt})
+	}
+	return nil
+}
+
+func (r *memOutboxRepo) Claim(ctx context.Context, owner string, lease time.Duration, limit int) ([]*OutboxEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rv []*OutboxEntry
+	for _, e := range r.events {
+		if !e.done && !e.dead && !e.next.After(time.Now()) && len(rv) < limit {
+			c := e.entry
+			c.Delivered = append([]string(nil), c.Delivered...)
+			rv = append(rv, &c)
+		}
+	}
+	return rv, nil
+}
+
+func (r *memOutboxRepo) Done(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[id-1].done = true
+	return nil
+}
+
+func (r *memOutboxRepo) Retry(ctx context.Context, e *OutboxEntry, next time.Time, lastErr string, dead bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	x := r.events[e.Event.ID-1]
+	x.entry.Attempts, x.entry.Delivered = e.Attempts, e.Delivered
+	x.next, x.lastErr, x.dead = next, lastErr, dead
+	return nil
+}
+
+func (r *memOutboxRepo) types() (rv []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.events {
+		rv = append(rv, e.entry.Event.Type)
+	}
+	return rv
+}
+
+// flakySink 前 failures 次投递失败
+type flakySink struct {
+	name      string
+	failures  int
+	delivered []uint
+}
+
+func (s *flakySink) Name() string { return s.name }
+
+func (s *flakySink) Deliver(ctx context.Context, e *DomainEvent) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.delivered = append(s.delivered, e.ID)
+	return nil
+}
+
+func newTestRelay(or OutboxRepo, bus *EventBus, sinks ...EventSink) *EventRelay {
+	return NewEventRelay(or, bus, sinks, &conf.Data{Events: &conf.Data_Events{
+		PollInterval: durationpb.New(time.Millisecond),
+		MaxAttempts:  3,
+	}}, log.DefaultLogger)
+}
+
+func TestEventBus(t *testing.T) {
+	a := assert.New(t)
+	bus := NewEventBus()
+	var got []string
+	bus.Subscribe(EventUserFollowed, func(ctx context.Context, e *DomainEvent) error {
+		got = append(got, "followed")
+		return nil
+	})
+	bus.Subscribe("", func(ctx context.Context, e *DomainEvent) error {
+		got = append(got, "all")
+		return errors.New("boom")
+	})
+
+	a.Error(bus.Deliver(context.Background(), &DomainEvent{Type: EventUserFollowed}))
+	a.Equal([]string{"followed", "all"}, got)
+	a.Error(bus.Deliver(context.Background(), &DomainEvent{Type: EventCommentAdded}))
+	a.Equal([]string{"followed", "all", "all"}, got)
+}
+
+func TestEventRelay(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	or := newMemOutboxRepo()
+	a.NoError(recordEvent(ctx, or, EventUserFollowed, UserFollowed{FollowerID: 2, FollowingID: 1}))
+
+	bus := NewEventBus()
+	var received []*UserFollowed
+	bus.Subscribe(EventUserFollowed, func(ctx context.Context, e *DomainEvent) error {
+		var p UserFollowed
+		if err := e.Decode(&p); err != nil {
+			return err
+		}
+		received = append(received, &p)
+		return nil
+	})
+	sink := &flakySink{name: "flaky", failures: 1}
+	r := newTestRelay(or, bus, sink)
+
+	n, err := r.Relay(ctx)
+	a.NoError(err)
+	a.Equal(1, n)
+	a.Len(received, 1)
+	a.Empty(sink.delivered)
+	a.Equal(1, or.events[0].entry.Attempts)
+	a.Contains(or.events[0].lastErr, "flaky: unavailable")
+
+	// 重试时跳过已经接收的 sink
+	time.Sleep(5 * time.Millisecond)
+	n, err = r.Relay(ctx)
+	a.NoError(err)
+	a.Equal(1, n)
+	a.Len(received, 1)
+	a.Equal([]uint{1}, sink.delivered)
+	a.True(or.events[0].done)
+	a.Equal(&UserFollowed{FollowerID: 2, FollowingID: 1}, received[0])
+
+	n, err = r.Relay(ctx)
+	a.NoError(err)
+	a.Zero(n)
+}
+
+func TestEventRelayDeadLetter(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	or := newMemOutboxRepo()
+	a.NoError(recordEvent(ctx, or, EventCommentAdded, CommentAdded{CommentID: 1}))
+	r := newTestRelay(or, NewEventBus(), &flakySink{name: "down", failures: 100})
+
+	for i := 0; i < 5; i++ {
+		_, err := r.Relay(ctx)
+		a.NoError(err)
+		time.Sleep(10 * time.Millisecond)
+	}
+	a.True(or.events[0].dead)
+	a.Equal(3, or.events[0].entry.Attempts)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	a := assert.New(t)
+	a.Equal(time.Second, retryBackoff(time.Second, time.Hour, 1))
+	a.Equal(4*time.Second, retryBackoff(time.Second, time.Hour, 3))
+	a.Equal(time.Hour, retryBackoff(time.Second, time.Hour, 30))
+}
+
+func TestEventRelayStartStop(t *testing.T) {
+	a := assert.New(t)
+	or := newMemOutboxRepo()
+	sink := &flakySink{name: "sink"}
+	r := newTestRelay(or, NewEventBus(), sink)
+	// 未启动时 Stop 直接返回
+	a.NoError(newTestRelay(or, NewEventBus()).Stop(context.Background()))
+
+	started := make(chan error)
+	go func() { started <- r.Start(context.Background()) }()
+	a.NoError(recordEvent(context.Background(), or, EventUserRegistered, UserRegistered{UserID: 1}))
+	a.Eventually(func() bool {
+		or.mu.Lock()
+		defer or.mu.Unlock()
+		return or.events[0].done
+	}, time.Second, time.Millisecond)
+	a.NoError(r.Stop(context.Background()))
+	a.NoError(<-started)
+
+	// 停止之后写入的事件由最后的 Flush 投递
+	a.NoError(recordEvent(context.Background(), or, EventUserRegistered, UserRegistered{UserID: 2}))
+	a.NoError(r.Flush(context.Background()))
+	a.Equal([]uint{1, 2}, sink.delivered)
+}
@@ -3,30 +3,51 @@ package biz
 import (
 	"context"
 	"errors"
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	kerrors "github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
 
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/markdown"
 	"realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/pubsub"
 )
 
 type ArticleRepo interface {
 	List(ctx context.Context, opts ...ListOption) ([]*Article, error)
 	Get(ctx context.Context, slug string) (*Article, error)
 	Create(ctx context.Context, a *Article) (*Article, error)
+	// Update changes the non-empty fields of article a.ID and increments its
+	// version. When a.Version is set and the stored version differs it
+	// returns ErrArticleVersionConflict.
 	Update(ctx context.Context, a *Article) (*Article, error)
 	Delete(ctx context.Context, a *Article) error
 	GetArticle(ctx context.Context, aid uint) (*Article, error)
 
-	Favorite(ctx context.Context, currentUserID uint, aid uint) error
+	// Favorite toggles the favorite and reports whether it was added.
+	Favorite(ctx context.Context, currentUserID uint, aid uint) (favorited bool, err error)
 	Unfavorite(ctx context.Context, currentUserID uint, aid uint) error
 	GetFavoritesStatus(ctx context.Context, currentUserID uint, as []*Article) (favorited []bool, err error)
 
 	ListTags(ctx context.Context) ([]Tag, error)
 }
 
+// ErrArticleVersionConflict means the article was modified after the
+// version an update is based on.
+var ErrArticleVersionConflict = errors.New("article version conflict")
+
+// ArticleVersionConflict is the error returned to clients; the metadata
+// carries the current version so they can reload and retry.
+func ArticleVersionConflict(current uint32) *kerrors.Error {
+	return kerrors.Conflict("version", fmt.Sprintf("article has been modified, current version is %d", current)).
+		WithMetadata(map[string]string{"version": strconv.FormatUint(uint64(current), 10)})
+}
+
 type CommentRepo interface {
 	Create(ctx context.Context, c *Comment) (*Comment, error)
 	Get(ctx context.Context, id uint) (*Comment, error)
@@ -35,9 +56,16 @@ type CommentRepo interface {
 }
 
 type SocialUsecase struct {
-	ar ArticleRepo
-	cr CommentRepo
-	pr ProfileRepo
+	ar  ArticleRepo
+	cr  CommentRepo
+	pr  ProfileRepo
+	ur  UserRepo
+	md  *markdown.Renderer
+	nr  NotificationRepo
+	tx  Transaction
+	or  OutboxRepo
+	ps  pubsub.Broker
+	rld *conf.Reloader
 
 	log *log.Helper
 }
@@ -53,6 +81,13 @@ type Article struct {
 	TagList        []string
 	Favorited      bool
 	FavoritesCount uint32
+	// Version 每次修改加一。更新时非零表示修改基于的版本，见 ArticleRepo.Update
+	Version uint32
+
+	// 由 Body 渲染得到，只读
+	BodyHTML    string
+	TOC         []markdown.Heading
+	ReadingTime uint32
 
 	AuthorUserID uint
 
@@ -91,8 +126,66 @@ func NewSocialUsecase(
 	ar ArticleRepo,
 	pr ProfileRepo,
 	cr CommentRepo,
+	ur UserRepo,
+	md *markdown.Renderer,
+	nr NotificationRepo,
+	tx Transaction,
+	or OutboxRepo,
+	ps pubsub.Broker,
+	rld *conf.Reloader,
 	logger log.Logger) *SocialUsecase {
-	return &SocialUsecase{ar: ar, cr: cr, pr: pr, log: log.NewHelper(logger)}
+	return &SocialUsecase{ar: ar, cr: cr, pr: pr, ur: ur, md: md, nr: nr, tx: tx, or: or, ps: ps, rld: rld, log: log.NewHelper(logger)}
+}
+
+// requireVerified rejects users who have not verified their email yet,
+// unless the email_verification feature is switched off.
+func (uc *SocialUsecase) requireVerified(ctx context.Context) error {
+	if !featureEnabled(uc.rld, FeatureEmailVerification) {
+		return nil
+	}
+	u, err := uc.ur.GetUserByID(ctx, auth.FromContext(ctx).UserID)
+	if err != nil {
+		return err
+	}
+	if !u.EmailVerified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// renderBody fills the sanitized HTML, table of contents and reading time of a.
+func (uc *SocialUsecase) renderBody(ctx context.Context, a *Article) {
+	res, err := uc.md.Render(a.Body)
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("渲染文章 %s 失败: %v", a.Slug, err)
+		return
+	}
+	a.BodyHTML = res.HTML
+	a.TOC = res.TOC
+	a.ReadingTime = res.ReadingTime
+}
+
+// actor returns the profile of the current user for events.
+func (uc *SocialUsecase) actor(ctx context.Context) *Profile {
+	uid := auth.FromContext(ctx).UserID
+	u, err := uc.ur.GetUserByID(ctx, uid)
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("load user %d for event: %v", uid, err)
+		return &Profile{ID: uid}
+	}
+	return &Profile{ID: u.ID, Username: u.Username, Bio: u.Bio, Image: u.Image}
+}
+
+// publishNotification sends n to the streams of its recipient, if notify
+// stored it.
+func (uc *SocialUsecase) publishNotification(ctx context.Context, n *Notification) {
+	if n.ID == 0 {
+		return
+	}
+	if n.Actor == nil {
+		n.Actor = uc.actor(ctx)
+	}
+	publish(ctx, uc.ps, uc.log, notificationsTopic(n.UserID), &Event{Type: EventNotification, Notification: n})
 }
 
 func (uc *SocialUsecase) GetProfile(ctx context.Context, username string) (rv *Profile, err error) {
@@ -106,10 +199,23 @@ func (uc *SocialUsecase) FollowUser(ctx context.Context, username string) (rv *P
 	if err != nil {
 		return nil, err
 	}
-	err = uc.pr.FollowUser(ctx, cu.UserID, fu.ID)
+	// 关注、通知和领域事件在同一个事务中写入
+	n := &Notification{UserID: fu.ID, ActorID: cu.UserID, Type: NotificationFollow}
+	err = uc.tx.InTx(ctx, func(ctx context.Context) error {
+		// 重复关注不再通知，也不记录事件
+		followed, err := uc.pr.FollowUser(ctx, cu.UserID, fu.ID)
+		if err != nil || !followed {
+			return err
+		}
+		if err := notify(ctx, uc.nr, n); err != nil {
+			return err
+		}
+		return recordEvent(ctx, uc.or, EventUserFollowed, UserFollowed{FollowerID: cu.UserID, FollowingID: fu.ID})
+	})
 	if err != nil {
 		return nil, err
 	}
+	uc.publishNotification(ctx, n)
 	rv, err = uc.pr.GetProfile(ctx, username)
 	if err != nil {
 		return nil, err
@@ -135,20 +241,55 @@ func (uc *SocialUsecase) UnfollowUser(ctx context.Context, username string) (rv
 }
 
 func (uc *SocialUsecase) GetArticle(ctx context.Context, slug string) (rv *Article, err error) {
-	return uc.ar.Get(ctx, slug)
+	rv, err = uc.ar.Get(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	uc.renderBody(ctx, rv)
+	return rv, nil
 }
 
 func (uc *SocialUsecase) CreateArticle(ctx context.Context, in *Article) (rv *Article, err error) {
+	if err := uc.requireVerified(ctx); err != nil {
+		return nil, err
+	}
 	u := auth.FromContext(ctx)
 	in.Slug = slugify(in.Title)
 	in.AuthorUserID = u.UserID
-	a, err := uc.ar.Create(ctx, in)
+	var a *Article
+	err = uc.tx.InTx(ctx, func(ctx context.Context) (err error) {
+		if a, err = uc.ar.Create(ctx, in); err != nil {
+			return err
+		}
+		return recordEvent(ctx, uc.or, EventArticlePublished, ArticlePublished{
+			ArticleID: a.ID, Slug: a.Slug, Title: a.Title, AuthorID: u.UserID, TagList: a.TagList,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
+	articlesCreatedCounter.Add(ctx, 1)
+	uc.renderBody(ctx, a)
+	if a.Author == nil || a.Author.Username == "" {
+		a.Author = uc.actor(ctx)
+	}
+	uc.publishToFollowers(ctx, u.UserID, &Event{Type: EventArticle, Article: a})
 	return a, err
 }
 
+// publishToFollowers sends e to the feed streams of the followers of
+// authorID.
+func (uc *SocialUsecase) publishToFollowers(ctx context.Context, authorID uint, e *Event) {
+	ids, err := uc.pr.ListFollowerIDs(ctx, authorID)
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("publish %s event to followers of %d: %v", e.Type, authorID, err)
+		return
+	}
+	for _, id := range ids {
+		publish(ctx, uc.ps, uc.log, feedTopic(id), e)
+	}
+}
+
 func (uc *SocialUsecase) DeleteArticle(ctx context.Context, slug string) (err error) {
 	a, err := uc.ar.Get(ctx, slug)
 	if err != nil {
@@ -161,10 +302,41 @@ func (uc *SocialUsecase) DeleteArticle(ctx context.Context, slug string) (err er
 }
 
 func (uc *SocialUsecase) AddComment(ctx context.Context, slug string, in *Comment) (rv *Comment, err error) {
+	if err := uc.requireVerified(ctx); err != nil {
+		return nil, err
+	}
+	a, err := uc.ar.Get(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
 	u := auth.FromContext(ctx)
 	in.AuthorID = u.UserID
 	in.Article = &Article{Slug: slug}
-	return uc.cr.Create(ctx, in)
+	n := &Notification{
+		UserID: a.Author.ID, ActorID: u.UserID, Type: NotificationComment,
+		ArticleID: a.ID, ArticleSlug: a.Slug, ArticleTitle: a.Title,
+	}
+	err = uc.tx.InTx(ctx, func(ctx context.Context) error {
+		if rv, err = uc.cr.Create(ctx, in); err != nil {
+			return err
+		}
+		n.CommentID = rv.ID
+		if err := notify(ctx, uc.nr, n); err != nil {
+			return err
+		}
+		return recordEvent(ctx, uc.or, EventCommentAdded, CommentAdded{
+			CommentID: rv.ID, ArticleID: a.ID, Slug: a.Slug, ArticleAuthorID: a.Author.ID, AuthorID: u.UserID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	commentsCreatedCounter.Add(ctx, 1)
+	rv.Author = uc.actor(ctx)
+	n.Actor = rv.Author
+	publish(ctx, uc.ps, uc.log, commentsTopic(a.ID), &Event{Type: EventComment, Comment: rv})
+	uc.publishNotification(ctx, n)
+	return rv, nil
 }
 
 func (uc *SocialUsecase) ListComments(ctx context.Context, slug string) (rv []*Comment, err error) {
@@ -188,6 +360,9 @@ func (uc *SocialUsecase) FeedArticles(ctx context.Context, opts ...ListOption) (
 	if err != nil {
 		return nil, err
 	}
+	for _, a := range rv {
+		uc.renderBody(ctx, a)
+	}
 	return rv, nil
 }
 
@@ -196,6 +371,9 @@ func (uc *SocialUsecase) ListArticles(ctx context.Context, opts ...ListOption) (
 	if err != nil {
 		return nil, err
 	}
+	for _, a := range rv {
+		uc.renderBody(ctx, a)
+	}
 	return rv, nil
 }
 
@@ -207,8 +385,22 @@ func (uc *SocialUsecase) UpdateArticle(ctx context.Context, in *Article) (rv *Ar
 	if !a.verifyAuthor(auth.FromContext(ctx).UserID) {
 		return nil, errors.New("no permission 401")
 	}
+	// a 可能读自落后的从库，版本只由 ArticleRepo.Update 在主库上的条件更新检查，
+	// 冲突时从主库读取当前版本
+	in.ID = a.ID
 	rv, err = uc.ar.Update(ctx, in)
-	return nil, nil
+	if errors.Is(err, ErrArticleVersionConflict) {
+		cur, gerr := uc.ar.GetArticle(ctx, a.ID)
+		if gerr != nil {
+			return nil, gerr
+		}
+		return nil, ArticleVersionConflict(cur.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	uc.renderBody(ctx, rv)
+	return rv, nil
 }
 
 func (uc *SocialUsecase) GetTags(ctx context.Context) (rv []Tag, err error) {
@@ -221,14 +413,38 @@ func (uc *SocialUsecase) FavoriteArticle(ctx context.Context, slug string) (rv *
 		return nil, err
 	}
 	cu := auth.FromContext(ctx)
-	err = uc.ar.Favorite(ctx, cu.UserID, a.ID)
+	n := &Notification{
+		UserID: a.Author.ID, ActorID: cu.UserID, Type: NotificationFavorite,
+		ArticleID: a.ID, ArticleSlug: a.Slug, ArticleTitle: a.Title,
+	}
+	// Favorite 是开关，favorited 为 false 表示这次取消了收藏
+	var favorited bool
+	err = uc.tx.InTx(ctx, func(ctx context.Context) (err error) {
+		favorited, err = uc.ar.Favorite(ctx, cu.UserID, a.ID)
+		if err != nil || !favorited {
+			return err
+		}
+		if err := notify(ctx, uc.nr, n); err != nil {
+			return err
+		}
+		return recordEvent(ctx, uc.or, EventArticleFavorited, ArticleFavorited{
+			ArticleID: a.ID, Slug: a.Slug, AuthorID: a.Author.ID, UserID: cu.UserID,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
+	uc.publishNotification(ctx, n)
+	if favorited {
+		countFavorite(ctx, "favorite")
+	} else {
+		countFavorite(ctx, "unfavorite")
+	}
 	a, err = uc.ar.GetArticle(ctx, a.ID)
 	if err != nil {
 		return nil, err
 	}
+	uc.renderBody(ctx, a)
 	return a, nil
 }
 
@@ -242,5 +458,7 @@ func (uc *SocialUsecase) UnfavoriteArticle(ctx context.Context, slug string) (rv
 	if err != nil {
 		return nil, err
 	}
+	countFavorite(ctx, "unfavorite")
+	uc.renderBody(ctx, a)
 	return a, nil
 }
@@ -0,0 +1,87 @@
+package biz
+
+import (
+	"context"
+	"testing"
+
+	"realworld_demo/internal/pkg/markdown"
+	auth "realworld_demo/internal/pkg/middleware"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// versionedArticleRepo 保存一篇文章，Update 按版本做条件更新
+type versionedArticleRepo struct {
+	ArticleRepo
+	a *Article
+	// concurrent 在 Get 之后、Update 之前模拟另一个请求的修改
+	concurrent bool
+	// stale 模拟落后的从库，Get 返回上一个版本
+	stale bool
+}
+
+func (r *versionedArticleRepo) Get(ctx context.Context, slug string) (*Article, error) {
+	if slug != r.a.Slug {
+		return nil, errors.NotFound("article", "not found")
+	}
+	c := *r.a
+	if r.stale {
+		c.Version--
+	}
+	if r.concurrent {
+		r.a.Version++
+	}
+	return &c, nil
+}
+
+func (r *versionedArticleRepo) GetArticle(ctx context.Context, id uint) (*Article, error) {
+	c := *r.a
+	return &c, nil
+}
+
+func (r *versionedArticleRepo) Update(ctx context.Context, a *Article) (*Article, error) {
+	if a.Version != 0 && a.Version != r.a.Version {
+		return nil, ErrArticleVersionConflict
+	}
+	if a.Body != "" {
+		r.a.Body = a.Body
+	}
+	r.a.Version++
+	return r.GetArticle(ctx, a.ID)
+}
+
+func TestUpdateArticleVersion(t *testing.T) {
+	a := assert.New(t)
+	ar := &versionedArticleRepo{a: &Article{ID: 1, Slug: "hello", Body: "v1", Version: 1, Author: &Profile{ID: 1}}}
+	uc := NewSocialUsecase(ar, nil, nil, nil, markdown.NewRenderer(), nil, nil, nil, nil, nil, log.DefaultLogger)
+	ctx := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 1})
+
+	rv, err := uc.UpdateArticle(ctx, &Article{Slug: "hello", Body: "v2", Version: 1})
+	a.NoError(err)
+	a.Equal(uint32(2), rv.Version)
+	a.Equal("<p>v2</p>\n", rv.BodyHTML)
+
+	// 没有版本时无条件更新
+	rv, err = uc.UpdateArticle(ctx, &Article{Slug: "hello", Body: "v3"})
+	a.NoError(err)
+	a.Equal(uint32(3), rv.Version)
+
+	_, err = uc.UpdateArticle(ctx, &Article{Slug: "hello", Body: "stale", Version: 2})
+	a.True(errors.IsConflict(err))
+	a.Equal("3", errors.FromError(err).Metadata["version"])
+
+	// 从库落后时，正确的版本仍然可以更新
+	ar.stale = true
+	rv, err = uc.UpdateArticle(ctx, &Article{Slug: "hello", Body: "v4", Version: 3})
+	a.NoError(err)
+	a.Equal(uint32(4), rv.Version)
+
+	// 读取之后被别人修改，冲突由条件更新发现
+	ar.concurrent = true
+	_, err = uc.UpdateArticle(ctx, &Article{Slug: "hello", Body: "late", Version: 4})
+	a.True(errors.IsConflict(err))
+	a.Equal("5", errors.FromError(err).Metadata["version"])
+	a.Equal("v4", ar.a.Body)
+}
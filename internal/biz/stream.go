@@ -0,0 +1,182 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/pubsub"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	EventComment      = "comment"
+	EventArticle      = "article"
+	EventNotification = "notification"
+)
+
+// Event is sent to the streams subscribed to it. The field matching Type is
+// set.
+type Event struct {
+	Type         string        `json:"type"`
+	Comment      *Comment      `json:"comment,omitempty"`
+	Article      *Article      `json:"article,omitempty"`
+	Notification *Notification `json:"notification,omitempty"`
+}
+
+func commentsTopic(articleID uint) string {
+	return fmt.Sprintf("article.%d.comments", articleID)
+}
+
+// feedTopic receives the new articles of the users userID follows. Articles
+// are published to the followers at creation, so a stream sees follows made
+// after it started.
+func feedTopic(userID uint) string {
+	return fmt.Sprintf("user.%d.feed", userID)
+}
+
+func notificationsTopic(userID uint) string {
+	return fmt.Sprintf("user.%d.notifications", userID)
+}
+
+// publish is called after the change has been committed, so a failure only
+// costs the real-time update and is logged.
+func publish(ctx context.Context, b pubsub.Broker, helper *log.Helper, topic string, e *Event) {
+	payload, err := json.Marshal(e)
+	if err == nil {
+		err = b.Publish(ctx, topic, payload)
+	}
+	if err != nil {
+		helper.WithContext(ctx).Errorf("publish %s event to %s: %v", e.Type, topic, err)
+	}
+}
+
+// StreamFilter selects the events of a stream.
+type StreamFilter struct {
+	// new comments on this article
+	ArticleSlug string
+	// new articles by the users the current user follows
+	Feed bool
+	// new notifications of the current user
+	Notifications bool
+}
+
+// Stream delivers events until it is closed.
+type Stream struct {
+	sub  pubsub.Subscription
+	c    chan *Event
+	done chan struct{}
+	once sync.Once
+	log  *log.Helper
+}
+
+func (s *Stream) Events() <-chan *Event { return s.c }
+
+// Close may be called more than once.
+func (s *Stream) Close() (err error) {
+	s.once.Do(func() {
+		close(s.done)
+		err = s.sub.Close()
+	})
+	return err
+}
+
+func (s *Stream) decode() {
+	defer close(s.c)
+	for m := range s.sub.C() {
+		var e Event
+		if err := json.Unmarshal(m.Payload, &e); err != nil {
+			s.log.Errorf("decode event from %s: %v", m.Topic, err)
+			continue
+		}
+		select {
+		case s.c <- &e:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// 流的 ticket 出现在 URL 中，只能使用一次，很快过期
+const streamTicketTTL = time.Minute
+
+type StreamUsecase struct {
+	ar  ArticleRepo
+	tr  UserTokenRepo
+	ps  pubsub.Broker
+	log *log.Helper
+}
+
+func NewStreamUsecase(ar ArticleRepo, tr UserTokenRepo, ps pubsub.Broker, logger log.Logger) *StreamUsecase {
+	return &StreamUsecase{ar: ar, tr: tr, ps: ps, log: log.NewHelper(logger)}
+}
+
+// IssueTicket returns a ticket that authenticates one stream request of
+// the current user. Browsers cannot set headers on EventSource and
+// WebSocket requests, so the ticket is passed in the URL instead of the
+// long-lived token.
+func (uc *StreamUsecase) IssueTicket(ctx context.Context) (string, time.Duration, error) {
+	ticket, hash, err := newToken()
+	if err != nil {
+		return "", 0, err
+	}
+	t := &UserToken{
+		UserID:    auth.FromContext(ctx).UserID,
+		Purpose:   TokenPurposeStream,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(streamTicketTTL),
+	}
+	if err := uc.tr.CreateToken(ctx, t); err != nil {
+		return "", 0, err
+	}
+	return ticket, streamTicketTTL, nil
+}
+
+// RedeemTicket consumes a ticket and returns ctx with its owner as the
+// current user.
+func (uc *StreamUsecase) RedeemTicket(ctx context.Context, ticket string) (context.Context, error) {
+	uid, err := uc.tr.ConsumeToken(ctx, TokenPurposeStream, hashToken(ticket))
+	if errors.Is(err, ErrInvalidToken) {
+		return nil, auth.ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return auth.WithContext(ctx, &auth.CurrentUser{UserID: uid}), nil
+}
+
+// Subscribe opens a stream of the events selected by f for the current
+// user. Delivery is best effort: clients reload what they show after
+// reconnecting.
+func (uc *StreamUsecase) Subscribe(ctx context.Context, f StreamFilter) (*Stream, error) {
+	uid := auth.FromContext(ctx).UserID
+	var topics []string
+	if f.ArticleSlug != "" {
+		a, err := uc.ar.Get(ctx, f.ArticleSlug)
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, commentsTopic(a.ID))
+	}
+	if f.Feed {
+		topics = append(topics, feedTopic(uid))
+	}
+	if f.Notifications {
+		topics = append(topics, notificationsTopic(uid))
+	}
+	if len(topics) == 0 {
+		return nil, errors.New(422, "stream", "choose at least one of article, feed and notifications")
+	}
+	sub, err := uc.ps.Subscribe(ctx, topics...)
+	if err != nil {
+		return nil, err
+	}
+	s := &Stream{sub: sub, c: make(chan *Event), done: make(chan struct{}), log: uc.log}
+	go s.decode()
+	return s, nil
+}
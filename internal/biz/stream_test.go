@@ -0,0 +1,100 @@
+package biz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/pubsub"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func nextEvent(t *testing.T, s *Stream) *Event {
+	t.Helper()
+	select {
+	case e := <-s.Events():
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
+
+func TestStreamNotifications(t *testing.T) {
+	a := assert.New(t)
+	ps := pubsub.NewMemoryBroker(0)
+	ur := &memUserRepo{users: map[uint]*User{}}
+	_ = ur.CreateUser(context.Background(), &User{Username: "alice"})
+	_ = ur.CreateUser(context.Background(), &User{Username: "bob"})
+	pr := &memProfileRepo{
+		profiles: map[string]*Profile{"alice": {ID: 1, Username: "alice"}},
+		follows:  map[[2]uint]bool{},
+	}
+	sc := NewSocialUsecase(nil, pr, nil, ur, nil, newMemNotificationRepo(), &memTransaction{}, newMemOutboxRepo(), ps, nil, log.DefaultLogger)
+	stc := NewStreamUsecase(nil, nil, ps, log.DefaultLogger)
+
+	alice := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 1})
+	bob := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 2})
+
+	_, err := stc.Subscribe(alice, StreamFilter{})
+	a.Error(err)
+
+	s, err := stc.Subscribe(alice, StreamFilter{Notifications: true})
+	a.NoError(err)
+	defer s.Close()
+
+	_, err = sc.FollowUser(bob, "alice")
+	a.NoError(err)
+	e := nextEvent(t, s)
+	a.Equal(EventNotification, e.Type)
+	if a.NotNil(e.Notification) {
+		a.Equal(NotificationFollow, e.Notification.Type)
+		a.Equal("bob", e.Notification.Actor.Username)
+	}
+}
+
+func TestStreamFeed(t *testing.T) {
+	a := assert.New(t)
+	ps := pubsub.NewMemoryBroker(0)
+	pr := &memProfileRepo{follows: map[[2]uint]bool{}}
+	sc := NewSocialUsecase(nil, pr, nil, nil, nil, nil, nil, nil, ps, nil, log.DefaultLogger)
+	stc := NewStreamUsecase(nil, nil, ps, log.DefaultLogger)
+	ctx := context.Background()
+
+	// 还没有关注任何人时也可以订阅，之后的关注立即生效
+	s, err := stc.Subscribe(auth.WithContext(ctx, &auth.CurrentUser{UserID: 2}), StreamFilter{Feed: true})
+	a.NoError(err)
+	_, err = pr.FollowUser(ctx, 2, 1)
+	a.NoError(err)
+	// 关注了 1，没有关注 3
+	sc.publishToFollowers(ctx, 3, &Event{Type: EventArticle, Article: &Article{Slug: "other"}})
+	sc.publishToFollowers(ctx, 1, &Event{Type: EventArticle, Article: &Article{Slug: "followed"}})
+	e := nextEvent(t, s)
+	a.Equal("followed", e.Article.Slug)
+
+	a.NoError(s.Close())
+	_, ok := <-s.Events()
+	a.False(ok)
+	a.NoError(s.Close())
+}
+
+func TestStreamTicket(t *testing.T) {
+	a := assert.New(t)
+	stc := NewStreamUsecase(nil, &memTokenRepo{used: map[string]bool{}}, pubsub.NewMemoryBroker(0), log.DefaultLogger)
+	alice := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 1})
+
+	ticket, ttl, err := stc.IssueTicket(alice)
+	a.NoError(err)
+	a.Equal(streamTicketTTL, ttl)
+
+	ctx, err := stc.RedeemTicket(context.Background(), ticket)
+	a.NoError(err)
+	a.Equal(uint(1), auth.FromContext(ctx).UserID)
+
+	// 只能使用一次
+	_, err = stc.RedeemTicket(context.Background(), ticket)
+	a.ErrorIs(err, auth.ErrTokenInvalid)
+}
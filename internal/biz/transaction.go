@@ -0,0 +1,9 @@
+package biz
+
+import "context"
+
+// Transaction runs fn in a database transaction. Repos called with the ctx
+// passed to fn take part in it; nested calls join the outer transaction.
+type Transaction interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
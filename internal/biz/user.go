@@ -5,19 +5,20 @@ import (
 	"fmt"
 	"realworld_demo/internal/conf"
 	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/password"
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
-	ID           uint
-	Email        string
-	Username     string
-	Bio          string
-	Image        string
-	PasswordHash string
+	ID            uint
+	Email         string
+	Username      string
+	Bio           string
+	Image         string
+	PasswordHash  string
+	EmailVerified bool
 }
 
 type UserLogin struct {
@@ -28,28 +29,18 @@ type UserLogin struct {
 	Image    string
 }
 
+// UserUpdate 中为 nil 的字段保持不变
 type UserUpdate struct {
-	Email    string
-	Username string
-	Password string
-	Bio      string
-	Image    string
-}
-
-// hashPassword 使用 bcrypt 对密码进行哈希处理
-func hashPassword(pwd string) string {
-	b, err := bcrypt.GenerateFromPassword([]byte(pwd), bcrypt.DefaultCost)
-	if err != nil {
-		panic(err)
-	}
-	return string(b)
+	Email           *string
+	Username        *string
+	Password        *string
+	Bio             *string
+	Image           *string
+	CurrentPassword string
 }
 
 func verifyPassword(hashed, input string) bool {
-	if err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(input)); err != nil {
-		return false
-	}
-	return true
+	return password.Verify(hashed, input)
 }
 
 type UserRepo interface {
@@ -58,19 +49,32 @@ type UserRepo interface {
 	GetUserByUsername(ctx context.Context, username string) (*User, error)
 	GetUserByID(ctx context.Context, id uint) (*User, error)
 	UpdateUser(ctx context.Context, user *User) (*User, error)
+	SetEmailVerified(ctx context.Context, id uint, verified bool) error
 }
 
 type ProfileRepo interface {
 	GetProfile(ctx context.Context, username string) (*Profile, error)
-	FollowUser(ctx context.Context, currentUserID uint, followingID uint) error
+	// FollowUser reports whether the follow was added, false when it
+	// already existed.
+	FollowUser(ctx context.Context, currentUserID uint, followingID uint) (followed bool, err error)
 	UnfollowUser(ctx context.Context, currentUserID uint, followingID uint) error
 	GetUserFollowingStatus(ctx context.Context, currentUserID uint, userIDs []uint) (following []bool, err error)
+	// ListFollowerIDs returns the users following userID.
+	ListFollowerIDs(ctx context.Context, userID uint) ([]uint, error)
 }
 
 type UserUsecase struct {
-	ur   UserRepo
-	pr   ProfileRepo
-	jwtc *conf.JWT
+	ur     UserRepo
+	pr     ProfileRepo
+	tr     UserTokenRepo
+	tx     Transaction
+	or     OutboxRepo
+	mailer Mailer
+	hasher *password.Hasher
+	policy *password.Policy
+	jwtc   *conf.JWT
+	mailc  *conf.Data_Mail
+	rld    *conf.Reloader
 
 	log *log.Helper
 }
@@ -84,52 +88,111 @@ type Profile struct {
 }
 
 func NewUserUsecase(ur UserRepo,
-	pr ProfileRepo, logger log.Logger, jwtc *conf.JWT) *UserUsecase {
-	return &UserUsecase{ur: ur, pr: pr, jwtc: jwtc, log: log.NewHelper(logger)}
+	pr ProfileRepo, tr UserTokenRepo, tx Transaction, or OutboxRepo, mailer Mailer, hasher *password.Hasher, policy *password.Policy,
+	logger log.Logger, jwtc *conf.JWT, dc *conf.Data, rld *conf.Reloader) *UserUsecase {
+	return &UserUsecase{ur: ur, pr: pr, tr: tr, tx: tx, or: or, mailer: mailer, hasher: hasher, policy: policy,
+		jwtc: jwtc, mailc: dc.GetMail(), rld: rld, log: log.NewHelper(logger)}
+}
+
+// checkPassword 按密码策略校验，identities 为用户名和邮箱
+func (uc *UserUsecase) checkPassword(pwd string, identities ...string) error {
+	if err := uc.policy.Check(pwd, identities...); err != nil {
+		return errors.New(422, "password", err.Error())
+	}
+	return nil
+}
+
+func (uc *UserUsecase) hashPassword(ctx context.Context, pwd string) (string, error) {
+	h, err := uc.hasher.Hash(pwd)
+	if errors.Is(err, password.ErrTooLong) {
+		return "", errors.New(422, "password", "is too long")
+	}
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("密码哈希失败: %v", err)
+		return "", errors.InternalServer("user", "密码哈希失败")
+	}
+	return h, nil
+}
+
+// rehashPassword 在登录成功后用当前配置重新哈希密码，失败不影响登录
+func (uc *UserUsecase) rehashPassword(ctx context.Context, u *User, pwd string) {
+	h, err := uc.hasher.Hash(pwd)
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("重新哈希密码失败: %v", err)
+		return
+	}
+	u.PasswordHash = h
+	if _, err := uc.ur.UpdateUser(ctx, u); err != nil {
+		uc.log.WithContext(ctx).Errorf("保存重新哈希的密码失败: %v", err)
+	}
 }
 
 func (uc *UserUsecase) generateToken(userID uint) string {
-	return auth.GenerateToken(uc.jwtc.Secret, userID)
+	return auth.NewToken(uc.jwtc.GetSecret(), userID, uc.jwtc.GetExpiresIn().AsDuration())
 }
 
 func (uc *UserUsecase) Register(ctx context.Context, username, email, password string) (*UserLogin, error) {
-	uc.log.Infof("开始注册用户: email=%s, username=%s", email, username)
+	if !featureEnabled(uc.rld, FeatureRegistration) {
+		return nil, ErrRegistrationDisabled
+	}
+	uc.log.WithContext(ctx).Infof("开始注册用户: email=%s, username=%s", email, username)
 
 	// 检查邮箱是否已存在
 	_, err := uc.ur.GetUserByEmail(ctx, email)
 	if err == nil {
-		uc.log.Errorf("注册失败: 邮箱 %s 已存在", email)
+		uc.log.WithContext(ctx).Errorf("注册失败: 邮箱 %s 已存在", email)
 		return nil, errors.New(422, "email", "已被注册")
 	} else if !errors.Is(err, errors.NotFound("user", "not found by email")) {
-		uc.log.Errorf("查询用户邮箱时发生错误: %v", err)
+		uc.log.WithContext(ctx).Errorf("查询用户邮箱时发生错误: %v", err)
 		return nil, errors.InternalServer("user", "查询用户邮箱时发生错误")
 	}
+	if err := uc.checkPassword(password, username, email); err != nil {
+		return nil, err
+	}
+	hash, err := uc.hashPassword(ctx, password)
+	if err != nil {
+		return nil, err
+	}
 	// 创建用户
 	u := &User{
 		Email:        email,
 		Username:     username,
-		PasswordHash: hashPassword(password),
+		PasswordHash: hash,
 	}
 
-	if err := uc.ur.CreateUser(ctx, u); err != nil {
-		uc.log.Errorf("创建用户失败: %v", err)
+	// 用户和 UserRegistered 事件在同一个事务中写入
+	err = uc.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := uc.ur.CreateUser(ctx, u); err != nil {
+			return err
+		}
+		return recordEvent(ctx, uc.or, EventUserRegistered, UserRegistered{UserID: u.ID, Username: u.Username, Email: u.Email})
+	})
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("创建用户失败: %v", err)
 		return nil, errors.InternalServer("user", fmt.Sprintf("创建用户失败: %v", err))
 	}
 
+	registrationsCounter.Add(ctx, 1)
+
 	// 确保用户ID已被设置
 	if u.ID == 0 {
-		uc.log.Error("用户ID未被设置，无法生成token")
+		uc.log.WithContext(ctx).Error("用户ID未被设置，无法生成token")
 		return nil, errors.InternalServer("user", "创建用户后ID未设置")
 	}
 
+	// 发送验证邮件，失败时用户可以重新发送
+	if err := uc.sendVerification(ctx, u); err != nil {
+		uc.log.WithContext(ctx).Errorf("发送验证邮件失败: %v", err)
+	}
+
 	// 生成token
 	token := uc.generateToken(u.ID)
 	if token == "" {
-		uc.log.Error("生成token失败")
+		uc.log.WithContext(ctx).Error("生成token失败")
 		return nil, errors.InternalServer("user", "生成token失败")
 	}
 
-	uc.log.Infof("用户注册成功: id=%d, email=%s, username=%s, token=%s", u.ID, email, username, token)
+	uc.log.WithContext(ctx).Infof("用户注册成功: id=%d, username=%s", u.ID, username)
 
 	// 返回用户信息
 	return &UserLogin{
@@ -146,12 +209,22 @@ func (uc *UserUsecase) Login(ctx context.Context, email, password string) (*User
 		return nil, errors.New(422, "email", "cannot be empty")
 	}
 	u, err := uc.ur.GetUserByEmail(ctx, email)
+	// 邮箱不存在与密码错误返回相同的错误，避免泄露账号是否存在，也便于统计失败次数
+	if errors.IsNotFound(err) {
+		countLogin(ctx, false)
+		return nil, errors.Unauthorized("user", "登录失败，账号或密码错误")
+	}
 	if err != nil {
 		return nil, err
 	}
 	if !verifyPassword(u.PasswordHash, password) {
+		countLogin(ctx, false)
 		return nil, errors.Unauthorized("user", "登录失败，账号或密码错误")
 	}
+	countLogin(ctx, true)
+	if uc.hasher.NeedsRehash(u.PasswordHash) {
+		uc.rehashPassword(ctx, u, password)
+	}
 
 	return &UserLogin{
 		Email:    u.Email,
@@ -177,14 +250,69 @@ func (uc *UserUsecase) UpdateUser(ctx context.Context, uu *UserUpdate) (*UserLog
 	if err != nil {
 		return nil, err
 	}
-	u.Email = uu.Email
-	u.Image = uu.Image
-	u.PasswordHash = hashPassword(uu.Password)
-	u.Bio = uu.Bio
+	emailChanged := uu.Email != nil && *uu.Email != u.Email
+	if emailChanged {
+		if *uu.Email == "" {
+			return nil, errors.New(422, "email", "cannot be empty")
+		}
+		if taken, err := isTaken(uc.ur.GetUserByEmail(ctx, *uu.Email)); err != nil {
+			return nil, err
+		} else if taken {
+			return nil, errors.New(422, "email", "已被注册")
+		}
+		u.Email = *uu.Email
+		u.EmailVerified = false
+	}
+	if uu.Username != nil && *uu.Username != u.Username {
+		if *uu.Username == "" {
+			return nil, errors.New(422, "username", "cannot be empty")
+		}
+		if taken, err := isTaken(uc.ur.GetUserByUsername(ctx, *uu.Username)); err != nil {
+			return nil, err
+		} else if taken {
+			return nil, errors.New(422, "username", "已被使用")
+		}
+		u.Username = *uu.Username
+	}
+	passwordChanged := uu.Password != nil
+	if passwordChanged {
+		if *uu.Password == "" {
+			return nil, errors.New(422, "password", "cannot be empty")
+		}
+		if !verifyPassword(u.PasswordHash, uu.CurrentPassword) {
+			return nil, errors.New(422, "currentPassword", "is invalid")
+		}
+		if err := uc.checkPassword(*uu.Password, u.Username, u.Email); err != nil {
+			return nil, err
+		}
+		if u.PasswordHash, err = uc.hashPassword(ctx, *uu.Password); err != nil {
+			return nil, err
+		}
+	}
+	if uu.Bio != nil {
+		u.Bio = *uu.Bio
+	}
+	if uu.Image != nil {
+		u.Image = *uu.Image
+	}
 	u, err = uc.ur.UpdateUser(ctx, u)
 	if err != nil {
 		return nil, err
 	}
+	if passwordChanged {
+		if err := uc.tr.RevokeTokens(ctx, u.ID, TokenPurposeResetPassword); err != nil {
+			uc.log.WithContext(ctx).Errorf("撤销重置密码 token 失败: %v", err)
+		}
+	}
+	if emailChanged {
+		// 新邮箱需要重新验证，旧邮箱的验证链接作废
+		if err := uc.tr.RevokeTokens(ctx, u.ID, TokenPurposeVerifyEmail); err != nil {
+			uc.log.WithContext(ctx).Errorf("撤销验证 token 失败: %v", err)
+		}
+		if err := uc.sendVerification(ctx, u); err != nil {
+			uc.log.WithContext(ctx).Errorf("发送验证邮件失败: %v", err)
+		}
+	}
 	return &UserLogin{
 		Email:    u.Email,
 		Username: u.Username,
@@ -193,3 +321,14 @@ func (uc *UserUsecase) UpdateUser(ctx context.Context, uu *UserUpdate) (*UserLog
 		Token:    uc.generateToken(u.ID),
 	}, nil
 }
+
+// isTaken 把按邮箱/用户名查询的结果转换为是否已被占用
+func isTaken(_ *User, err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
@@ -1,14 +1,21 @@
 package biz
 
 import (
+	"context"
+	"strings"
 	"testing"
 
+	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/password"
+
 	"github.com/davecgh/go-spew/spew"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestHashPassword(t *testing.T) {
-	s := hashPassword("abc1")
+	uc, _, _ := newTestUserUsecase()
+	s, err := uc.hashPassword(context.Background(), "abc1")
+	assert.NoError(t, err)
 	spew.Dump(s)
 }
 
@@ -20,3 +27,88 @@ func TestVerifyPassword(t *testing.T) {
 	a.False(verifyPassword("$2a$10$9glSiir1PnzNgZzM1hXXaemPWECsyoYfWKbcr62APlXDvjl4yeTaK", "abc1"))
 	a.False(verifyPassword("$2a$10$ssssd", "abc"))
 }
+
+func TestUpdateUser(t *testing.T) {
+	a := assert.New(t)
+	uc, ur, mailer := newTestUserUsecase()
+	ctx := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 1})
+	str := func(s string) *string { return &s }
+
+	_, err := uc.Register(ctx, "alice", "alice@example.com", "correct horse")
+	a.NoError(err)
+	_, err = uc.Register(ctx, "bob", "bob@example.com", "correct horse")
+	a.NoError(err)
+	ur.users[1].EmailVerified = true
+	hash := ur.users[1].PasswordHash
+
+	// 只修改 bio，其他字段保持不变
+	u, err := uc.UpdateUser(ctx, &UserUpdate{Bio: str("hello")})
+	a.NoError(err)
+	a.Equal("hello", u.Bio)
+	a.Equal("alice", u.Username)
+	a.Equal(hash, ur.users[1].PasswordHash)
+	a.True(ur.users[1].EmailVerified)
+
+	_, err = uc.UpdateUser(ctx, &UserUpdate{Username: str("bob")})
+	a.Error(err)
+	_, err = uc.UpdateUser(ctx, &UserUpdate{Email: str("bob@example.com")})
+	a.Error(err)
+
+	u, err = uc.UpdateUser(ctx, &UserUpdate{Username: str("alice2")})
+	a.NoError(err)
+	a.Equal("alice2", u.Username)
+
+	// 修改邮箱后需要重新验证
+	sent := len(*mailer)
+	_, err = uc.UpdateUser(ctx, &UserUpdate{Email: str("new@example.com")})
+	a.NoError(err)
+	a.False(ur.users[1].EmailVerified)
+	a.Len(*mailer, sent+1)
+	a.Equal("new@example.com", (*mailer)[sent].To)
+
+	// 修改密码必须提供当前密码
+	_, err = uc.UpdateUser(ctx, &UserUpdate{Password: str("purple monkey dishwasher")})
+	a.Error(err)
+	_, err = uc.UpdateUser(ctx, &UserUpdate{Password: str("purple monkey dishwasher"), CurrentPassword: "wrong"})
+	a.Error(err)
+	// 新密码也要符合密码策略
+	_, err = uc.UpdateUser(ctx, &UserUpdate{Password: str("alice2-secret"), CurrentPassword: "correct horse"})
+	a.Error(err)
+	_, err = uc.UpdateUser(ctx, &UserUpdate{Password: str("purple monkey dishwasher"), CurrentPassword: "correct horse"})
+	a.NoError(err)
+	a.True(verifyPassword(ur.users[1].PasswordHash, "purple monkey dishwasher"))
+}
+
+func TestRegisterPasswordPolicy(t *testing.T) {
+	a := assert.New(t)
+	uc, _, _ := newTestUserUsecase()
+	ctx := context.Background()
+
+	for _, pwd := range []string{"", "short", "password123", "carol-rocks!"} {
+		_, err := uc.Register(ctx, "carol", "carol@example.com", pwd)
+		a.Error(err, pwd)
+	}
+	_, err := uc.Register(ctx, "carol", "carol@example.com", "correct horse")
+	a.NoError(err)
+}
+
+func TestLoginRehash(t *testing.T) {
+	a := assert.New(t)
+	uc, ur, _ := newTestUserUsecase()
+	ctx := context.Background()
+
+	_, err := uc.Register(ctx, "dave", "dave@example.com", "correct horse")
+	a.NoError(err)
+	old := ur.users[1].PasswordHash
+
+	// 参数不变时不重新哈希
+	_, err = uc.Login(ctx, "dave@example.com", "correct horse")
+	a.NoError(err)
+	a.Equal(old, ur.users[1].PasswordHash)
+
+	uc.hasher, _ = password.NewHasher(password.Argon2id, 0, password.Argon2Params{Memory: 1024, Time: 1, Threads: 1})
+	_, err = uc.Login(ctx, "dave@example.com", "correct horse")
+	a.NoError(err)
+	a.True(strings.HasPrefix(ur.users[1].PasswordHash, "$argon2id$"))
+	a.True(verifyPassword(ur.users[1].PasswordHash, "correct horse"))
+}
@@ -0,0 +1,432 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"realworld_demo/internal/conf"
+	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/webhook"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	// EventWebhookTest is sent by WebhookUsecase.Test.
+	EventWebhookTest = "webhook.test"
+
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+
+	defaultWebhookTimeout      = 10 * time.Second
+	defaultWebhookMaxAttempts  = 8
+	defaultWebhookRetryBackoff = 30 * time.Second
+	defaultWebhookInterval     = time.Second
+	defaultWebhooksPerUser     = 10
+	webhookMaxBackoff          = time.Hour
+	webhookBatchSize           = 50
+	webhookLease               = 5 * time.Minute
+
+	defaultDeliveryLimit = 20
+	maxDeliveryLimit     = 100
+)
+
+// webhookEvents are the events webhooks can subscribe to. Events mapped to
+// false are only available to global webhooks.
+var webhookEvents = map[string]bool{
+	EventArticlePublished: true,
+	EventCommentAdded:     true,
+	EventArticleFavorited: true,
+	EventUserFollowed:     true,
+	EventUserRegistered:   false,
+}
+
+// Webhook receives the events it subscribes to that concern its owner, or
+// all of them when it is global.
+type Webhook struct {
+	ID        uint
+	UserID    uint
+	URL       string
+	Secret    string
+	Events    []string
+	Global    bool
+	CreatedAt time.Time
+}
+
+// WebhookDelivery is an event sent, or to be sent, to a webhook, with the
+// result of the last attempt.
+type WebhookDelivery struct {
+	ID        uint
+	WebhookID uint
+	// filled by ClaimDeliveries
+	Webhook        *Webhook
+	EventID        uint
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+	Duration       time.Duration
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// WebhookAudience selects the owners of the webhooks that get an event,
+// besides the global webhooks.
+type WebhookAudience struct {
+	UserIDs []uint
+	// the followers of this user
+	FollowersOf uint
+}
+
+type WebhookRepo interface {
+	Create(ctx context.Context, w *Webhook) error
+	Get(ctx context.Context, id uint) (*Webhook, error)
+	List(ctx context.Context, userID uint) ([]*Webhook, error)
+	Count(ctx context.Context, userID uint) (int64, error)
+	// Delete removes the webhook and its deliveries.
+	Delete(ctx context.Context, id uint) error
+	// ListSubscribed returns the webhooks subscribed to typ that are global
+	// or owned by the audience.
+	ListSubscribed(ctx context.Context, typ string, aud WebhookAudience) ([]*Webhook, error)
+
+	// CreateDeliveries stores ds and sets their IDs. An event is stored once
+	// per webhook, duplicates are ignored.
+	CreateDeliveries(ctx context.Context, ds ...*WebhookDelivery) error
+	// ClaimDeliveries leases up to limit pending deliveries that are due.
+	ClaimDeliveries(ctx context.Context, owner string, lease time.Duration, limit int) ([]*WebhookDelivery, error)
+	// UpdateDelivery stores the result of an attempt and releases the lease.
+	UpdateDelivery(ctx context.Context, d *WebhookDelivery) error
+	// ListDeliveries returns the deliveries of a webhook, newest first.
+	ListDeliveries(ctx context.Context, webhookID uint, limit, offset int64) ([]*WebhookDelivery, error)
+}
+
+type WebhookUsecase struct {
+	wr          WebhookRepo
+	client      *webhook.Client
+	admins      []uint
+	maxPerUser  int64
+	maxAttempts int
+	backoff     time.Duration
+	timeout     time.Duration
+	log         *log.Helper
+}
+
+// NewWebhookUsecase subscribes to bus to queue a delivery for every webhook
+// interested in an event.
+func NewWebhookUsecase(wr WebhookRepo, bus *EventBus, c *conf.Data, ac *conf.Auth, logger log.Logger) *WebhookUsecase {
+	wc := c.GetWebhooks()
+	uc := &WebhookUsecase{
+		wr:          wr,
+		maxPerUser:  defaultWebhooksPerUser,
+		maxAttempts: defaultWebhookMaxAttempts,
+		backoff:     defaultWebhookRetryBackoff,
+		timeout:     defaultWebhookTimeout,
+		log:         log.NewHelper(logger),
+	}
+	for _, id := range ac.GetAdminIds() {
+		uc.admins = append(uc.admins, uint(id))
+	}
+	if n := wc.GetMaxPerUser(); n > 0 {
+		uc.maxPerUser = int64(n)
+	}
+	if n := wc.GetMaxAttempts(); n > 0 {
+		uc.maxAttempts = int(n)
+	}
+	if d := wc.GetRetryBackoff().AsDuration(); d > 0 {
+		uc.backoff = d
+	}
+	if d := wc.GetTimeout().AsDuration(); d > 0 {
+		uc.timeout = d
+	}
+	uc.client = webhook.NewClient(uc.timeout, wc.GetAllowPrivateNetworks())
+	bus.Subscribe("", uc.enqueue)
+	return uc
+}
+
+func (uc *WebhookUsecase) isAdmin(userID uint) bool {
+	return slices.Contains(uc.admins, userID)
+}
+
+// Create registers a webhook for the current user. The secret is only
+// returned here.
+func (uc *WebhookUsecase) Create(ctx context.Context, w *Webhook) (*Webhook, error) {
+	uid := auth.FromContext(ctx).UserID
+	if err := webhook.CheckURL(w.URL); err != nil {
+		return nil, errors.New(422, "url", err.Error())
+	}
+	if len(w.Events) == 0 {
+		return nil, errors.New(422, "events", "can't be blank")
+	}
+	for _, e := range w.Events {
+		forUsers, ok := webhookEvents[e]
+		if !ok {
+			return nil, errors.New(422, "events", fmt.Sprintf("unknown event %q", e))
+		}
+		if !forUsers && !w.Global {
+			return nil, errors.New(422, "events", fmt.Sprintf("%s is only available to global webhooks", e))
+		}
+	}
+	if w.Global && !uc.isAdmin(uid) {
+		return nil, errors.Forbidden("webhook", "only admins can create global webhooks")
+	}
+	n, err := uc.wr.Count(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if n >= uc.maxPerUser {
+		return nil, errors.New(422, "webhook", fmt.Sprintf("at most %d webhooks per user", uc.maxPerUser))
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	rv := &Webhook{UserID: uid, URL: w.URL, Secret: secret, Events: slices.Compact(slices.Sorted(slices.Values(w.Events))), Global: w.Global}
+	if err := uc.wr.Create(ctx, rv); err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+func (uc *WebhookUsecase) List(ctx context.Context) ([]*Webhook, error) {
+	return uc.wr.List(ctx, auth.FromContext(ctx).UserID)
+}
+
+// get returns a webhook of the current user; webhooks of others are not
+// found.
+func (uc *WebhookUsecase) get(ctx context.Context, id uint) (*Webhook, error) {
+	w, err := uc.wr.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if w.UserID != auth.FromContext(ctx).UserID {
+		return nil, errors.NotFound("webhook", "not found")
+	}
+	return w, nil
+}
+
+func (uc *WebhookUsecase) Delete(ctx context.Context, id uint) error {
+	if _, err := uc.get(ctx, id); err != nil {
+		return err
+	}
+	return uc.wr.Delete(ctx, id)
+}
+
+// ListDeliveries returns the delivery log of a webhook, newest first.
+func (uc *WebhookUsecase) ListDeliveries(ctx context.Context, id uint, limit, offset int64) ([]*WebhookDelivery, error) {
+	if _, err := uc.get(ctx, id); err != nil {
+		return nil, err
+	}
+	switch {
+	case limit <= 0:
+		limit = defaultDeliveryLimit
+	case limit > maxDeliveryLimit:
+		limit = maxDeliveryLimit
+	}
+	if offset < 0 {
+		return nil, errors.New(422, "offset", "must not be negative")
+	}
+	return uc.wr.ListDeliveries(ctx, id, limit, offset)
+}
+
+// Test sends a webhook.test event right away and returns the logged
+// delivery. It is not retried. The attempt is bounded by the webhook
+// timeout rather than the request deadline, which is usually shorter.
+func (uc *WebhookUsecase) Test(ctx context.Context, id uint) (*WebhookDelivery, error) {
+	w, err := uc.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(map[string]uint{"webhookId": w.ID})
+	payload, err := json.Marshal(&DomainEvent{Type: EventWebhookTest, OccurredAt: time.Now(), Data: data})
+	if err != nil {
+		return nil, err
+	}
+	// 先记录再发送，请求头里带上投递 ID；发送期间还未到期，不会被 dispatcher 领取
+	d := &WebhookDelivery{
+		WebhookID:     w.ID,
+		EventType:     EventWebhookTest,
+		Payload:       payload,
+		Status:        WebhookDeliveryPending,
+		NextAttemptAt: time.Now().Add(2 * uc.timeout),
+	}
+	if err := uc.wr.CreateDeliveries(ctx, d); err != nil {
+		return nil, err
+	}
+	// 请求的 deadline 比 webhook 超时短，慢的接收方会被误判为失败，结果也写不回去，
+	// 这条 pending 记录随后会被 dispatcher 当作普通投递重试
+	ctx = context.WithoutCancel(ctx)
+	sctx, cancel := context.WithTimeout(ctx, uc.timeout)
+	defer cancel()
+	uc.attempt(sctx, w, d, false)
+	if err := uc.wr.UpdateDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// enqueue queues a delivery of e for every interested webhook. The relay
+// may call it more than once for an event; the repo ignores duplicates.
+func (uc *WebhookUsecase) enqueue(ctx context.Context, e *DomainEvent) error {
+	if _, ok := webhookEvents[e.Type]; !ok {
+		return nil
+	}
+	aud, err := webhookAudience(e)
+	if err != nil {
+		return err
+	}
+	hooks, err := uc.wr.ListSubscribed(ctx, e.Type, aud)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	ds := make([]*WebhookDelivery, 0, len(hooks))
+	for _, w := range hooks {
+		ds = append(ds, &WebhookDelivery{
+			WebhookID:     w.ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Payload:       payload,
+			Status:        WebhookDeliveryPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+	return uc.wr.CreateDeliveries(ctx, ds...)
+}
+
+// webhookAudience returns who an event concerns: the author's followers for
+// a new article, the recipient for the others. Users are not told about
+// their own actions.
+func webhookAudience(e *DomainEvent) (aud WebhookAudience, err error) {
+	switch e.Type {
+	case EventArticlePublished:
+		var p ArticlePublished
+		err = e.Decode(&p)
+		aud.FollowersOf = p.AuthorID
+	case EventCommentAdded:
+		var p CommentAdded
+		if err = e.Decode(&p); p.ArticleAuthorID != p.AuthorID {
+			aud.UserIDs = []uint{p.ArticleAuthorID}
+		}
+	case EventArticleFavorited:
+		var p ArticleFavorited
+		if err = e.Decode(&p); p.AuthorID != p.UserID {
+			aud.UserIDs = []uint{p.AuthorID}
+		}
+	case EventUserFollowed:
+		var p UserFollowed
+		err = e.Decode(&p)
+		aud.UserIDs = []uint{p.FollowingID}
+	}
+	if err != nil {
+		return aud, fmt.Errorf("decode %s event %d: %w", e.Type, e.ID, err)
+	}
+	return aud, nil
+}
+
+// attempt sends d to w and records the result in d. Failed deliveries are
+// scheduled again with exponential backoff when retry is set, until
+// maxAttempts.
+func (uc *WebhookUsecase) attempt(ctx context.Context, w *Webhook, d *WebhookDelivery, retry bool) {
+	resp, err := uc.client.Send(ctx, &webhook.Request{
+		URL:        w.URL,
+		Secret:     w.Secret,
+		Event:      d.EventType,
+		DeliveryID: d.ID,
+		Body:       d.Payload,
+	})
+	d.Attempts++
+	d.ResponseStatus, d.ResponseBody, d.Duration = 0, "", 0
+	if resp != nil {
+		d.ResponseStatus, d.ResponseBody, d.Duration = resp.Status, resp.Body, resp.Duration
+	}
+	switch {
+	case err == nil:
+		d.Status, d.Error = WebhookDeliverySucceeded, ""
+	case retry && d.Attempts < uc.maxAttempts:
+		d.Status, d.Error = WebhookDeliveryPending, truncateError(err)
+		d.NextAttemptAt = time.Now().Add(retryBackoff(uc.backoff, webhookMaxBackoff, d.Attempts))
+	default:
+		d.Status, d.Error = WebhookDeliveryFailed, truncateError(err)
+	}
+	countWebhookDelivery(ctx, d.Status)
+}
+
+func truncateError(err error) string {
+	s := err.Error()
+	if len(s) > maxLastErrorLen {
+		s = s[:maxLastErrorLen]
+	}
+	return s
+}
+
+// WebhookDispatcher sends the queued deliveries. Like EventRelay it runs as
+// a kratos server and several instances may run at once.
+type WebhookDispatcher struct {
+	uc    *WebhookUsecase
+	owner string
+	p     *poller
+	log   *log.Helper
+}
+
+func NewWebhookDispatcher(uc *WebhookUsecase, c *conf.Data, logger log.Logger) *WebhookDispatcher {
+	interval := defaultWebhookInterval
+	if d := c.GetWebhooks().GetPollInterval().AsDuration(); d > 0 {
+		interval = d
+	}
+	helper := log.NewHelper(logger)
+	return &WebhookDispatcher{
+		uc:    uc,
+		owner: workerID(),
+		p:     newPoller("dispatch webhooks", interval, webhookBatchSize, helper),
+		log:   helper,
+	}
+}
+
+func (d *WebhookDispatcher) Start(ctx context.Context) error {
+	return d.p.run(ctx, d.Dispatch)
+}
+
+func (d *WebhookDispatcher) Stop(ctx context.Context) error {
+	return d.p.shutdown(ctx)
+}
+
+// Flush sends the deliveries that are due, including those the last relay
+// pass enqueued, after Stop and before the database is closed.
+func (d *WebhookDispatcher) Flush(ctx context.Context) error {
+	return d.p.drain(ctx, d.Dispatch)
+}
+
+// Dispatch sends one batch of due deliveries and returns how many were
+// claimed.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context) (int, error) {
+	ds, err := d.uc.wr.ClaimDeliveries(ctx, d.owner, webhookLease, d.p.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, x := range ds {
+		if x.Webhook == nil {
+			// webhook 在领取之后被删除
+			continue
+		}
+		d.uc.attempt(ctx, x.Webhook, x, true)
+		if x.Status == WebhookDeliveryFailed {
+			d.log.WithContext(ctx).Warnf("webhook %d: give up delivery %d after %d attempts: %s", x.WebhookID, x.ID, x.Attempts, x.Error)
+		}
+		if err := d.uc.wr.UpdateDelivery(ctx, x); err != nil {
+			return len(ds), err
+		}
+	}
+	return len(ds), nil
+}
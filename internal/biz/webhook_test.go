@@ -0,0 +1,303 @@
+package biz
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"realworld_demo/internal/conf"
+	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/webhook"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type memWebhookRepo struct {
+	mu         sync.Mutex
+	webhooks   []*Webhook
+	deliveries []*WebhookDelivery
+	// followers[id] 是关注 id 的用户
+	followers map[uint][]uint
+}
+
+func (r *memWebhookRepo) Create(ctx context.Context, w *Webhook) error {
+	w.ID = uint(len(r.webhooks) + 1)
+	r.webhooks = append(r.webhooks, w)
+	return nil
+}
+
+func (r *memWebhookRepo) Get(ctx context.Context, id uint) (*Webhook, error) {
+	for _, w := range r.webhooks {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return nil, errors.NotFound("webhook", "not found")
+}
+
+func (r *memWebhookRepo) List(ctx context.Context, userID uint) (rv []*Webhook, err error) {
+	for _, w := range r.webhooks {
+		if w.UserID == userID {
+			rv = append(rv, w)
+		}
+	}
+	return rv, nil
+}
+
+func (r *memWebhookRepo) Count(ctx context.Context, userID uint) (int64, error) {
+	rv, _ := r.List(ctx, userID)
+	return int64(len(rv)), nil
+}
+
+func (r *memWebhookRepo) Delete(ctx context.Context, id uint) error {
+	r.webhooks = slices.DeleteFunc(r.webhooks, func(w *Webhook) bool { return w.ID == id })
+	return nil
+}
+
+func (r *memWebhookRepo) ListSubscribed(ctx context.Context, typ string, aud WebhookAudience) (rv []*Webhook, err error) {
+	for _, w := range r.webhooks {
+		if slices.Contains(w.Events, typ) &&
+			(w.Global || slices.Contains(aud.UserIDs, w.UserID) || slices.Contains(r.followers[aud.FollowersOf], w.UserID)) {
+			rv = append(rv, w)
+		}
+	}
+	return rv, nil
+}
+
+func (r *memWebhookRepo) CreateDeliveries(ctx context.Context, ds ...*WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range ds {
+		if d.EventID != 0 && slices.ContainsFunc(r.deliveries, func(x *WebhookDelivery) bool {
+			return x.WebhookID == d.WebhookID && x.EventID == d.EventID
+		}) {
+			continue
+		}
+		d.ID = uint(len(r.deliveries) + 1)
+		d.CreatedAt = time.Now()
+		r.deliveries = append(r.deliveries, d)
+	}
+	return nil
+}
+
+func (r *memWebhookRepo) ClaimDeliveries(ctx context.Context, owner string, lease time.Duration, limit int) (rv []*WebhookDelivery, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.deliveries {
+		if d.Status == WebhookDeliveryPending && !d.NextAttemptAt.After(time.Now()) && len(rv) < limit {
+			c := *d
+			c.Webhook, _ = r.Get(ctx, d.WebhookID)
+			rv = append(rv, &c)
+		}
+	}
+	return rv, nil
+}
+
+func (r *memWebhookRepo) UpdateDelivery(ctx context.Context, d *WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := *d
+	c.Webhook = nil
+	r.deliveries[d.ID-1] = &c
+	return nil
+}
+
+func (r *memWebhookRepo) ListDeliveries(ctx context.Context, webhookID uint, limit, offset int64) (rv []*WebhookDelivery, err error) {
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		if r.deliveries[i].WebhookID == webhookID {
+			rv = append(rv, r.deliveries[i])
+		}
+	}
+	return rv, nil
+}
+
+func newTestWebhookUsecase(wr WebhookRepo, bus *EventBus) *WebhookUsecase {
+	return NewWebhookUsecase(wr, bus, &conf.Data{Webhooks: &conf.Webhooks{
+		MaxAttempts:          2,
+		MaxPerUser:           2,
+		RetryBackoff:         durationpb.New(time.Millisecond),
+		AllowPrivateNetworks: true,
+	}}, &conf.Auth{AdminIds: []uint32{9}}, log.DefaultLogger)
+}
+
+func TestCreateWebhook(t *testing.T) {
+	a := assert.New(t)
+	uc := newTestWebhookUsecase(&memWebhookRepo{}, NewEventBus())
+	alice := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 1})
+	admin := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 9})
+
+	for _, w := range []*Webhook{
+		{URL: "localhost/hook", Events: []string{EventCommentAdded}},
+		{URL: "https://example.com/hook"},
+		{URL: "https://example.com/hook", Events: []string{"article.deleted"}},
+		{URL: "https://example.com/hook", Events: []string{EventUserRegistered}},
+	} {
+		_, err := uc.Create(alice, w)
+		a.Equal(422, errors.Code(err), w)
+	}
+	_, err := uc.Create(alice, &Webhook{URL: "https://example.com/hook", Events: []string{EventUserRegistered}, Global: true})
+	a.True(errors.IsForbidden(err))
+
+	w, err := uc.Create(alice, &Webhook{URL: "https://example.com/hook", Events: []string{EventCommentAdded, EventArticlePublished, EventCommentAdded}})
+	a.NoError(err)
+	a.Equal([]string{EventArticlePublished, EventCommentAdded}, w.Events)
+	a.NotEmpty(w.Secret)
+	_, err = uc.Create(alice, &Webhook{URL: "https://example.com/2", Events: []string{EventUserFollowed}})
+	a.NoError(err)
+	_, err = uc.Create(alice, &Webhook{URL: "https://example.com/3", Events: []string{EventUserFollowed}})
+	a.Equal(422, errors.Code(err))
+
+	_, err = uc.Create(admin, &Webhook{URL: "https://example.com/all", Events: []string{EventUserRegistered}, Global: true})
+	a.NoError(err)
+
+	// 别人的 webhook 不可见
+	a.True(errors.IsNotFound(uc.Delete(admin, w.ID)))
+	a.NoError(uc.Delete(alice, w.ID))
+}
+
+func TestWebhookEnqueue(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	wr := &memWebhookRepo{followers: map[uint][]uint{2: {1}}}
+	bus := NewEventBus()
+	newTestWebhookUsecase(wr, bus)
+	_ = wr.Create(ctx, &Webhook{UserID: 1, URL: "https://a.example", Events: []string{EventArticlePublished, EventCommentAdded}})
+	_ = wr.Create(ctx, &Webhook{UserID: 2, URL: "https://b.example", Events: []string{EventCommentAdded}})
+	_ = wr.Create(ctx, &Webhook{UserID: 9, URL: "https://all.example", Events: []string{EventUserRegistered}, Global: true})
+
+	or := newMemOutboxRepo()
+	a.NoError(recordEvent(ctx, or, EventArticlePublished, ArticlePublished{ArticleID: 1, AuthorID: 2}))
+	a.NoError(recordEvent(ctx, or, EventCommentAdded, CommentAdded{ArticleID: 1, ArticleAuthorID: 2, AuthorID: 1}))
+	// 评论自己的文章不通知
+	a.NoError(recordEvent(ctx, or, EventCommentAdded, CommentAdded{ArticleID: 1, ArticleAuthorID: 2, AuthorID: 2}))
+	a.NoError(recordEvent(ctx, or, EventUserRegistered, UserRegistered{UserID: 3}))
+	for _, e := range or.events {
+		a.NoError(bus.Deliver(ctx, e.entry.Event))
+	}
+	// 至少一次投递，重复的事件只记录一次
+	a.NoError(bus.Deliver(ctx, or.events[0].entry.Event))
+
+	var got [][2]uint
+	for _, d := range wr.deliveries {
+		got = append(got, [2]uint{d.WebhookID, d.EventID})
+		a.Equal(WebhookDeliveryPending, d.Status)
+	}
+	a.Equal([][2]uint{{1, 1}, {2, 2}, {3, 4}}, got)
+}
+
+func TestWebhookDispatch(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	wr := &memWebhookRepo{}
+	uc := newTestWebhookUsecase(wr, NewEventBus())
+	alice := auth.WithContext(ctx, &auth.CurrentUser{UserID: 1})
+
+	var mu sync.Mutex
+	fail := true
+	var received []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		hook, _ := wr.Get(ctx, 1)
+		if webhook.Verify(hook.Secret, r.Header, body, time.Minute) != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received = append(received, r.Header.Get(webhook.HeaderEvent))
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("try later"))
+		}
+	}))
+	defer ts.Close()
+
+	w, err := uc.Create(alice, &Webhook{URL: ts.URL, Events: []string{EventUserFollowed}})
+	a.NoError(err)
+
+	// 测试事件立即发送，失败不重试
+	d, err := uc.Test(alice, w.ID)
+	a.NoError(err)
+	a.Equal(WebhookDeliveryFailed, d.Status)
+	a.Equal(503, d.ResponseStatus)
+	a.Equal("try later", d.ResponseBody)
+	_, err = uc.Test(auth.WithContext(ctx, &auth.CurrentUser{UserID: 2}), w.ID)
+	a.True(errors.IsNotFound(err))
+
+	dispatcher := NewWebhookDispatcher(uc, &conf.Data{}, log.DefaultLogger)
+	a.NoError(uc.enqueue(ctx, &DomainEvent{ID: 5, Type: EventUserFollowed, Data: []byte(`{"followerId":2,"followingId":1}`)}))
+	n, err := dispatcher.Dispatch(ctx)
+	a.NoError(err)
+	a.Equal(1, n)
+	d = wr.deliveries[1]
+	a.Equal(WebhookDeliveryPending, d.Status)
+	a.Equal(1, d.Attempts)
+	a.Contains(d.Error, "503")
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	_, err = dispatcher.Dispatch(ctx)
+	a.NoError(err)
+	d = wr.deliveries[1]
+	a.Equal(WebhookDeliverySucceeded, d.Status)
+	a.Equal(2, d.Attempts)
+	a.Empty(d.Error)
+	a.Equal([]string{EventWebhookTest, EventUserFollowed, EventUserFollowed}, received)
+
+	deliveries, err := uc.ListDeliveries(alice, w.ID, 0, 0)
+	a.NoError(err)
+	a.Len(deliveries, 2)
+}
+
+func TestWebhookTestOutlivesRequest(t *testing.T) {
+	a := assert.New(t)
+	wr := &memWebhookRepo{}
+	uc := newTestWebhookUsecase(wr, NewEventBus())
+	alice := auth.WithContext(context.Background(), &auth.CurrentUser{UserID: 1})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer ts.Close()
+	w, err := uc.Create(alice, &Webhook{URL: ts.URL, Events: []string{EventUserFollowed}})
+	a.NoError(err)
+
+	// 接收方比请求的 deadline 慢，仍按 webhook 超时发送并记录结果
+	ctx, cancel := context.WithTimeout(alice, 10*time.Millisecond)
+	defer cancel()
+	d, err := uc.Test(ctx, w.ID)
+	a.NoError(err)
+	a.Equal(WebhookDeliverySucceeded, d.Status)
+	a.Equal(WebhookDeliverySucceeded, wr.deliveries[0].Status)
+}
+
+func TestWebhookDispatchGivesUp(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	wr := &memWebhookRepo{}
+	uc := newTestWebhookUsecase(wr, NewEventBus())
+	_ = wr.Create(ctx, &Webhook{UserID: 1, URL: ts.URL, Events: []string{EventUserFollowed}})
+	a.NoError(uc.enqueue(ctx, &DomainEvent{ID: 1, Type: EventUserFollowed, Data: []byte(`{"followingId":1}`)}))
+
+	dispatcher := NewWebhookDispatcher(uc, &conf.Data{}, log.DefaultLogger)
+	for i := 0; i < 4; i++ {
+		_, err := dispatcher.Dispatch(ctx)
+		a.NoError(err)
+		time.Sleep(5 * time.Millisecond)
+	}
+	a.Equal(WebhookDeliveryFailed, wr.deliveries[0].Status)
+	a.Equal(2, wr.deliveries[0].Attempts)
+}
@@ -1,11 +1,14 @@
 package conf
 
-import "github.com/google/wire"
-
-// ProviderSet is conf providers.
-var ProviderSet = wire.NewSet(NewJWT)
+// LocalDir is the directory local uploads are written to.
+func (x *Data_Storage) LocalDir() string {
+	if dir := x.GetLocal().GetDir(); dir != "" {
+		return dir
+	}
+	return "./uploads"
+}
 
-// NewJWT 创建一个新的JWT配置
+// NewJWT 返回本地开发和测试用的 JWT 配置，线上从 conf.Bootstrap.Jwt 读取
 func NewJWT() *JWT {
 	return &JWT{
 		Secret: "realworld_demo_secret_key",
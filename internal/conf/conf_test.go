@@ -0,0 +1,121 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/config/file"
+	"github.com/stretchr/testify/assert"
+)
+
+func load(t *testing.T, env ...string) (*Bootstrap, error) {
+	src := &envSource{prefix: EnvPrefix, environ: func() []string { return env }}
+	c := config.New(config.WithSource(file.NewSource("../../configs"), src))
+	defer c.Close()
+	if err := c.Load(); err != nil {
+		return nil, err
+	}
+	var bc Bootstrap
+	if err := c.Scan(&bc); err != nil {
+		return nil, err
+	}
+	return &bc, nil
+}
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	a := assert.New(t)
+	bc, err := load(t, "REALWORLD_JWT_SECRET=0123456789abcdef0123456789abcdef")
+	a.NoError(err)
+	a.NoError(bc.Validate())
+
+	// 配置文件中没有密钥，忘记设置时不能启动
+	bc, err = load(t)
+	a.NoError(err)
+	a.EqualError(bc.Validate(), "invalid config:\n  jwt.secret: is required")
+}
+
+func TestEnvOverrides(t *testing.T) {
+	a := assert.New(t)
+	bc, err := load(t,
+		"REALWORLD_DATA_DATABASE_DSN=app:s3cret@tcp(db:3306)/realworld",
+		"REALWORLD_DATA_DATABASE_CONNECT_TIMEOUT=1m30s",
+		"REALWORLD_SERVER_RATE_LIMIT_TRUST_PROXY_HEADERS=true",
+		"REALWORLD_SERVER_HTTP_CORS_ALLOWED_ORIGINS=https://a.example.com, https://b.example.com",
+		"REALWORLD_AUTH_HASHER_BCRYPT_COST=12",
+		"REALWORLD_JWT_SECRET=0123456789abcdef0123456789abcdef",
+		"OTHER_DATA_DATABASE_DSN=ignored",
+	)
+	a.NoError(err)
+	a.Equal("app:s3cret@tcp(db:3306)/realworld", bc.Data.Database.Dsn)
+	a.Equal(int64(90), bc.Data.Database.ConnectTimeout.Seconds)
+	a.True(bc.Server.RateLimit.TrustProxyHeaders)
+	a.Equal([]string{"https://a.example.com", "https://b.example.com"}, bc.Server.Http.Cors.AllowedOrigins)
+	a.Equal(int32(12), bc.Auth.Hasher.BcryptCost)
+	a.Equal("0123456789abcdef0123456789abcdef", bc.Jwt.Secret)
+	// 文件中的其他配置保持不变
+	a.Equal("0.0.0.0:8000", bc.Server.Http.Addr)
+
+	_, err = load(t, "REALWORLD_DATA_DATABSE_DSN=x")
+	a.ErrorContains(err, `unknown config key "data_databse_dsn"`)
+	_, err = load(t, "REALWORLD_DATA_DATABASE=x")
+	a.ErrorContains(err, "database is a section")
+	_, err = load(t, "REALWORLD_SERVER_RATE_LIMIT_RULES=x")
+	a.ErrorContains(err, "can only be set in config files")
+}
+
+func TestValidate(t *testing.T) {
+	a := assert.New(t)
+	bc, err := load(t,
+		"REALWORLD_DATA_DATABASE_DSN= ",
+		"REALWORLD_JWT_SECRET=short",
+		"REALWORLD_SERVER_HTTP_CORS_ALLOW_CREDENTIALS=true",
+		"REALWORLD_TRACE_EXPORTER=jaeger",
+		"REALWORLD_DATA_PUBSUB_DRIVER=kafka",
+		"REALWORLD_DATA_EVENTS_MAX_ATTEMPTS=-1",
+		"REALWORLD_SERVER_ADMIN_ADDR=0.0.0.0:8000",
+		"REALWORLD_DATA_EVENTS_WEBHOOK_URLS=https://events.example.com/hook",
+	)
+	a.NoError(err)
+	err = bc.Validate()
+	a.Error(err)
+	for _, want := range []string{
+		"data.database.dsn: is required",
+		"jwt.secret: must be at least 32 bytes",
+		`server.http.cors.allowed_origins: must list origins instead of "*"`,
+		`trace.exporter: must be one of none, stdout, otlp, got "jaeger"`,
+		`data.pubsub.driver: must be one of memory, redis, got "kafka"`,
+		"data.events.max_attempts: must not be negative",
+		"server.admin.addr: must differ from server.http.addr and server.grpc.addr",
+		"data.events.webhook.secret: is required",
+	} {
+		a.Contains(err.Error(), want)
+	}
+	a.Len(strings.Split(err.Error(), "\n"), 9)
+}
+
+func TestRedacted(t *testing.T) {
+	a := assert.New(t)
+	bc := &Bootstrap{
+		Data: &Data{
+			Database: &Data_Database{
+				Dsn:         "root:123456@tcp(127.0.0.1:3306)/realworld?parseTime=True",
+				ReplicaDsns: []string{"ro:654321@tcp(replica:3306)/realworld"},
+			},
+			Mail:    &Data_Mail{Smtp: &Data_Mail_SMTP{Username: "bot", Password: "pw"}},
+			Storage: &Data_Storage{S3: &Data_Storage_S3{AccessKey: "ak", SecretKey: "sk", Bucket: "b"}},
+		},
+		Jwt: &JWT{Secret: "0123456789abcdef0123456789abcdef"},
+	}
+	r := bc.Redacted()
+	a.Equal("root:***@tcp(127.0.0.1:3306)/realworld?parseTime=True", r.Data.Database.Dsn)
+	a.Equal([]string{"ro:***@tcp(replica:3306)/realworld"}, r.Data.Database.ReplicaDsns)
+	a.Equal("***", r.Data.Mail.Smtp.Password)
+	a.Equal("bot", r.Data.Mail.Smtp.Username)
+	a.Equal("***", r.Data.Storage.S3.SecretKey)
+	a.Equal("***", r.Data.Storage.S3.AccessKey)
+	a.Equal("b", r.Data.Storage.S3.Bucket)
+	a.Equal("***", r.Jwt.Secret)
+	// 原配置不变
+	a.Equal("0123456789abcdef0123456789abcdef", bc.Jwt.Secret)
+}
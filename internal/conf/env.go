@@ -0,0 +1,187 @@
+package conf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// EnvPrefix is the prefix of environment variables that override the
+// config files, e.g. REALWORLD_DATA_DATABASE_DSN for data.database.dsn.
+const EnvPrefix = "REALWORLD_"
+
+type envSource struct {
+	prefix  string
+	environ func() []string
+}
+
+// NewEnvSource returns a config source built from environment variables
+// named after the Bootstrap field path. Put it after the file source so it
+// takes precedence. Repeated fields take a comma separated list; lists of
+// messages can only be set in files.
+func NewEnvSource(prefix string) config.Source {
+	return &envSource{prefix: prefix, environ: os.Environ}
+}
+
+func (s *envSource) Load() ([]*config.KeyValue, error) {
+	root := map[string]interface{}{}
+	desc := (&Bootstrap{}).ProtoReflect().Descriptor()
+	for _, e := range s.environ() {
+		name, value, ok := strings.Cut(e, "=")
+		if !ok || !strings.HasPrefix(name, s.prefix) {
+			continue
+		}
+		path, field, err := resolveEnv(desc, strings.ToLower(strings.TrimPrefix(name, s.prefix)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		v, err := envValue(field, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		m := root
+		for _, p := range path[:len(path)-1] {
+			next, ok := m[p].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				m[p] = next
+			}
+			m = next
+		}
+		m[path[len(path)-1]] = v
+	}
+	if len(root) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	return []*config.KeyValue{{Key: "env", Value: b, Format: "json"}}, nil
+}
+
+// resolveEnv maps data_database_connect_timeout to the field path
+// [data database connect_timeout]. Field names contain underscores too, so
+// every split is tried against the descriptor.
+func resolveEnv(md protoreflect.MessageDescriptor, name string) ([]string, protoreflect.FieldDescriptor, error) {
+	path, fd, err := resolveField(md, name)
+	if errors.Is(err, errUnknownKey) {
+		return nil, nil, fmt.Errorf("%w %q", errUnknownKey, name)
+	}
+	return path, fd, err
+}
+
+var errUnknownKey = errors.New("unknown config key")
+
+func resolveField(md protoreflect.MessageDescriptor, name string) ([]string, protoreflect.FieldDescriptor, error) {
+	parts := strings.Split(name, "_")
+	for i := len(parts); i > 0; i-- {
+		fd := md.Fields().ByName(protoreflect.Name(strings.Join(parts[:i], "_")))
+		if fd == nil {
+			continue
+		}
+		if i == len(parts) {
+			if fd.Message() != nil && !isDuration(fd) && !fd.IsList() {
+				return nil, nil, fmt.Errorf("%s is a section, set one of its fields", fd.Name())
+			}
+			return []string{string(fd.Name())}, fd, nil
+		}
+		if fd.IsMap() && fd.MapKey().Kind() == protoreflect.StringKind {
+			// runtime_features_registration -> runtime.features["registration"]
+			return []string{string(fd.Name()), strings.Join(parts[i:], "_")}, fd.MapValue(), nil
+		}
+		if fd.Message() == nil || isDuration(fd) || fd.IsList() || fd.IsMap() {
+			continue
+		}
+		rest, leaf, err := resolveField(fd.Message(), strings.Join(parts[i:], "_"))
+		if err == nil {
+			return append([]string{string(fd.Name())}, rest...), leaf, nil
+		}
+		if !errors.Is(err, errUnknownKey) {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, errUnknownKey
+}
+
+func isDuration(fd protoreflect.FieldDescriptor) bool {
+	return fd.Message() != nil && fd.Message().FullName() == "google.protobuf.Duration"
+}
+
+func envValue(fd protoreflect.FieldDescriptor, s string) (interface{}, error) {
+	if fd.IsList() {
+		if fd.Message() != nil {
+			return nil, fmt.Errorf("%s can only be set in config files", fd.Name())
+		}
+		var list []interface{}
+		for _, item := range strings.Split(s, ",") {
+			v, err := scalarValue(fd, strings.TrimSpace(item))
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+	return scalarValue(fd, s)
+}
+
+func scalarValue(fd protoreflect.FieldDescriptor, s string) (interface{}, error) {
+	if isDuration(fd) {
+		// protojson 只接受秒，这里也允许 1m30s 这样的写法
+		d, err := parseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return strconv.ParseBool(s)
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return strconv.ParseInt(s, 10, 64)
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+		return strconv.ParseUint(s, 10, 64)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return strconv.ParseFloat(s, 64)
+	}
+	return s, nil
+}
+
+func (s *envSource) Watch() (config.Watcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &envWatcher{ctx: ctx, cancel: cancel}, nil
+}
+
+// envWatcher never reports changes: the environment is fixed for the
+// lifetime of the process.
+type envWatcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (w *envWatcher) Next() ([]*config.KeyValue, error) {
+	<-w.ctx.Done()
+	return nil, w.ctx.Err()
+}
+
+func (w *envWatcher) Stop() error {
+	w.cancel()
+	return nil
+}
+
+func parseDuration(s string) (string, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s", nil
+}
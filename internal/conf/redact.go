@@ -0,0 +1,57 @@
+package conf
+
+import (
+	"regexp"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// secretFields hold credentials; Redacted masks them wherever they appear.
+var secretFields = map[protoreflect.Name]struct{}{
+	"secret":     {},
+	"secret_key": {},
+	"access_key": {},
+	"password":   {},
+}
+
+// dsnPassword matches the password of user:password@tcp(host)/db.
+var dsnPassword = regexp.MustCompile(`:[^:@/]*@`)
+
+// Redacted returns a copy of x that is safe to print.
+func (x *Bootstrap) Redacted() *Bootstrap {
+	c := proto.Clone(x).(*Bootstrap)
+	redact(c.ProtoReflect())
+	return c
+}
+
+func redact(m protoreflect.Message) {
+	type update struct {
+		fd protoreflect.FieldDescriptor
+		v  string
+	}
+	var updates []update
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.Name() == "replica_dsns":
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				list.Set(i, protoreflect.ValueOfString(dsnPassword.ReplaceAllString(list.Get(i).String(), ":***@")))
+			}
+		case fd.IsList() || fd.IsMap():
+		case fd.Message() != nil:
+			redact(v.Message())
+		case fd.Kind() != protoreflect.StringKind || v.String() == "":
+		case fd.Name() == "dsn":
+			updates = append(updates, update{fd, dsnPassword.ReplaceAllString(v.String(), ":***@")})
+		default:
+			if _, ok := secretFields[fd.Name()]; ok {
+				updates = append(updates, update{fd, "***"})
+			}
+		}
+		return true
+	})
+	for _, u := range updates {
+		m.Set(u.fd, protoreflect.ValueOfString(u.v))
+	}
+}
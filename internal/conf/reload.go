@@ -0,0 +1,103 @@
+package conf
+
+import (
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Reloader holds the effective configuration and applies the parts that can
+// change at runtime. Everything else keeps its startup value until restart.
+type Reloader struct {
+	mu      sync.RWMutex
+	current *Bootstrap
+	subs    []func(*Bootstrap)
+}
+
+func NewReloader(bc *Bootstrap) *Reloader {
+	return &Reloader{current: bc}
+}
+
+// Current returns the effective configuration. It must not be modified.
+// A nil Reloader returns nil, so getters fall back to defaults.
+func (r *Reloader) Current() *Bootstrap {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// Subscribe registers fn to be called with the new configuration after
+// every applied reload.
+func (r *Reloader) Subscribe(fn func(*Bootstrap)) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs = append(r.subs, fn)
+}
+
+// Apply validates next and, if it is valid, takes over its reloadable
+// settings. It returns the sections that changed but only take effect after
+// a restart.
+func (r *Reloader) Apply(next *Bootstrap) (restart []string, err error) {
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	cur := r.current
+	merged := proto.Clone(cur).(*Bootstrap)
+	if merged.Server == nil {
+		merged.Server = &Server{}
+	}
+	if merged.Server.Http == nil {
+		merged.Server.Http = &Server_HTTP{}
+	}
+	if merged.Server.RateLimit == nil {
+		merged.Server.RateLimit = &Server_RateLimit{}
+	}
+	if merged.Log == nil {
+		merged.Log = &Log{}
+	}
+	merged.Server.Http.Cors = next.GetServer().GetHttp().GetCors()
+	merged.Server.RateLimit.Rules = next.GetServer().GetRateLimit().GetRules()
+	merged.Server.RateLimit.Lockout = next.GetServer().GetRateLimit().GetLockout()
+	merged.Log.Level = next.GetLog().GetLevel()
+	merged.Runtime = next.GetRuntime()
+	r.current = merged
+	subs := append(([]func(*Bootstrap))(nil), r.subs...)
+	r.mu.Unlock()
+
+	// 可热更新的字段两者已相同，剩下的差异只能重启后生效
+	for name, pair := range map[string][2]proto.Message{
+		"server.http":       {next.GetServer().GetHttp(), merged.Server.Http},
+		"server.grpc":       {next.GetServer().GetGrpc(), merged.Server.Grpc},
+		"server.rate_limit": {next.GetServer().GetRateLimit(), merged.Server.RateLimit},
+		"server.shutdown":   {next.GetServer().GetShutdown(), merged.Server.Shutdown},
+		"data":              {next.GetData(), merged.Data},
+		"auth":              {next.GetAuth(), merged.Auth},
+		"trace":             {next.GetTrace(), merged.Trace},
+		"log":               {next.GetLog(), merged.Log},
+		"jwt":               {next.GetJwt(), merged.Jwt},
+	} {
+		if !equal(pair[0], pair[1]) {
+			restart = append(restart, name)
+		}
+	}
+	sort.Strings(restart)
+
+	for _, fn := range subs {
+		fn(merged)
+	}
+	return restart, nil
+}
+
+// equal is proto.Equal that treats a missing section like an empty one.
+func equal(a, b proto.Message) bool {
+	return proto.Equal(a, b) || proto.Size(a) == 0 && proto.Size(b) == 0
+}
@@ -0,0 +1,47 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReloaderApply(t *testing.T) {
+	a := assert.New(t)
+	bc, err := load(t, "REALWORLD_JWT_SECRET=0123456789abcdef0123456789abcdef")
+	a.NoError(err)
+	r := NewReloader(bc)
+	var notified *Bootstrap
+	r.Subscribe(func(bc *Bootstrap) { notified = bc })
+
+	next := proto.Clone(bc).(*Bootstrap)
+	next.Log.Level = "debug"
+	next.Server.Http.Cors = &Server_HTTP_CORS{AllowedOrigins: []string{"https://app.example.com"}}
+	next.Runtime = &Runtime{Features: map[string]bool{"registration": false}}
+	next.Data.Database.Dsn = "other:pw@tcp(db:3306)/realworld"
+	next.Server.Http.Addr = "0.0.0.0:9000"
+
+	restart, err := r.Apply(next)
+	a.NoError(err)
+	a.Equal([]string{"data", "server.http"}, restart)
+	cur := r.Current()
+	a.Same(cur, notified)
+	a.Equal("debug", cur.Log.Level)
+	a.Equal([]string{"https://app.example.com"}, cur.Server.Http.Cors.AllowedOrigins)
+	a.False(cur.Runtime.Features["registration"])
+	// 需要重启的配置保持原值
+	a.Equal(bc.Data.Database.Dsn, cur.Data.Database.Dsn)
+	a.Equal(bc.Server.Http.Addr, cur.Server.Http.Addr)
+
+	// 校验失败时整体不生效
+	bad := proto.Clone(next).(*Bootstrap)
+	bad.Log.Level = "verbose"
+	_, err = r.Apply(bad)
+	a.ErrorContains(err, "log.level")
+	a.Same(cur, r.Current())
+
+	var nilReloader *Reloader
+	a.Nil(nilReloader.Current())
+	nilReloader.Subscribe(func(*Bootstrap) {})
+}
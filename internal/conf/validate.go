@@ -0,0 +1,256 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MinJWTSecretLength is the shortest accepted HS256 key.
+const MinJWTSecretLength = 32
+
+// Validate checks the loaded configuration before anything is started and
+// reports every problem at once, each with the path of the offending field.
+func (x *Bootstrap) Validate() error {
+	var v validation
+
+	for _, s := range []struct {
+		path string
+		addr string
+	}{
+		{"server.http.addr", x.GetServer().GetHttp().GetAddr()},
+		{"server.grpc.addr", x.GetServer().GetGrpc().GetAddr()},
+	} {
+		v.required(s.path, s.addr)
+	}
+	// 管理端口不做认证，不能与公开端口共用
+	if addr := x.GetServer().GetAdmin().GetAddr(); addr != "" &&
+		(addr == x.GetServer().GetHttp().GetAddr() || addr == x.GetServer().GetGrpc().GetAddr()) {
+		v.add("server.admin.addr", "must differ from server.http.addr and server.grpc.addr")
+	}
+	cors := x.GetServer().GetHttp().GetCors()
+	if cors.GetAllowCredentials() {
+		for _, o := range cors.GetAllowedOrigins() {
+			if o == "*" {
+				v.add("server.http.cors.allowed_origins", `must list origins instead of "*" when allow_credentials is set`)
+			}
+		}
+		if len(cors.GetAllowedOrigins()) == 0 {
+			v.add("server.http.cors.allowed_origins", "is required when allow_credentials is set")
+		}
+	}
+	sd := x.GetServer().GetShutdown()
+	if sd.GetDrainDelay().AsDuration() < 0 {
+		v.add("server.shutdown.drain_delay", "must not be negative")
+	}
+	if sd.GetTimeout().AsDuration() < 0 {
+		v.add("server.shutdown.timeout", "must not be negative")
+	}
+	rl := x.GetServer().GetRateLimit()
+	v.oneOf("server.rate_limit.store", rl.GetStore(), "", "memory", "redis")
+	if rl.GetStore() == "redis" {
+		v.required("data.redis.addr", x.GetData().GetRedis().GetAddr())
+	}
+	for i, r := range rl.GetRules() {
+		path := fmt.Sprintf("server.rate_limit.rules[%d]", i)
+		v.required(path+".operation", r.GetOperation())
+		v.oneOf(path+".key", r.GetKey(), "ip", "account")
+		if r.GetRate() <= 0 {
+			v.add(path+".rate", "must be positive")
+		}
+		if r.GetBurst() <= 0 {
+			v.add(path+".burst", "must be positive")
+		}
+	}
+
+	db := x.GetData().GetDatabase()
+	v.oneOf("data.database.driver", db.GetDriver(), "", "mysql")
+	v.required("data.database.dsn", db.GetDsn())
+	for _, d := range []struct {
+		path  string
+		value time.Duration
+	}{
+		{"data.database.connect_timeout", db.GetConnectTimeout().AsDuration()},
+		{"data.database.conn_max_lifetime", db.GetConnMaxLifetime().AsDuration()},
+		{"data.database.conn_max_idle_time", db.GetConnMaxIdleTime().AsDuration()},
+		{"data.database.read_timeout", db.GetReadTimeout().AsDuration()},
+		{"data.database.write_timeout", db.GetWriteTimeout().AsDuration()},
+		{"data.database.slow_threshold", db.GetSlowThreshold().AsDuration()},
+		{"data.database.replica_check_interval", db.GetReplicaCheckInterval().AsDuration()},
+	} {
+		if d.value < 0 {
+			v.add(d.path, "must not be negative")
+		}
+	}
+	for i, dsn := range db.GetReplicaDsns() {
+		v.required(fmt.Sprintf("data.database.replica_dsns[%d]", i), dsn)
+	}
+	if db.GetMaxOpenConns() < 0 || db.GetMaxIdleConns() < 0 {
+		v.add("data.database", "connection pool sizes must not be negative")
+	} else if db.GetMaxOpenConns() != 0 && db.GetMaxIdleConns() > db.GetMaxOpenConns() {
+		v.add("data.database.max_idle_conns", "must not exceed max_open_conns")
+	}
+	st := x.GetData().GetStorage()
+	v.oneOf("data.storage.driver", st.GetDriver(), "", "local", "s3")
+	if st.GetDriver() == "s3" {
+		v.required("data.storage.s3.endpoint", st.GetS3().GetEndpoint())
+		v.required("data.storage.s3.bucket", st.GetS3().GetBucket())
+	}
+	mail := x.GetData().GetMail()
+	v.oneOf("data.mail.driver", mail.GetDriver(), "", "smtp", "file", "log")
+	switch mail.GetDriver() {
+	case "smtp":
+		v.required("data.mail.smtp.addr", mail.GetSmtp().GetAddr())
+	case "file":
+		v.required("data.mail.file", mail.GetFile())
+	}
+	ps := x.GetData().GetPubsub()
+	v.oneOf("data.pubsub.driver", ps.GetDriver(), "", "memory", "redis")
+	if ps.GetDriver() == "redis" {
+		v.required("data.redis.addr", x.GetData().GetRedis().GetAddr())
+	}
+	if ps.GetBuffer() < 0 {
+		v.add("data.pubsub.buffer", "must not be negative")
+	}
+	ev := x.GetData().GetEvents()
+	if ev.GetPollInterval().AsDuration() < 0 {
+		v.add("data.events.poll_interval", "must not be negative")
+	}
+	if ev.GetBatchSize() < 0 {
+		v.add("data.events.batch_size", "must not be negative")
+	}
+	if ev.GetMaxAttempts() < 0 {
+		v.add("data.events.max_attempts", "must not be negative")
+	}
+	if ev.GetWebhook().GetTimeout().AsDuration() < 0 {
+		v.add("data.events.webhook.timeout", "must not be negative")
+	}
+	wh := x.GetData().GetWebhooks()
+	for _, d := range []struct {
+		path string
+		d    time.Duration
+	}{
+		{"data.webhooks.timeout", wh.GetTimeout().AsDuration()},
+		{"data.webhooks.retry_backoff", wh.GetRetryBackoff().AsDuration()},
+		{"data.webhooks.poll_interval", wh.GetPollInterval().AsDuration()},
+	} {
+		if d.d < 0 {
+			v.add(d.path, "must not be negative")
+		}
+	}
+	if wh.GetMaxAttempts() < 0 {
+		v.add("data.webhooks.max_attempts", "must not be negative")
+	}
+	if wh.GetMaxPerUser() < 0 {
+		v.add("data.webhooks.max_per_user", "must not be negative")
+	}
+	fc := x.GetData().GetFeeds()
+	if fc.GetLimit() < 0 {
+		v.add("data.feeds.limit", "must not be negative")
+	}
+	if fc.GetMaxAge().AsDuration() < 0 {
+		v.add("data.feeds.max_age", "must not be negative")
+	}
+	if u := fc.GetLinkBaseUrl(); u != "" {
+		if pu, err := url.Parse(u); err != nil || (pu.Scheme != "http" && pu.Scheme != "https") || pu.Host == "" {
+			v.add("data.feeds.link_base_url", "must be an http or https URL")
+		}
+	}
+	if u := fc.GetApiBaseUrl(); u != "" {
+		if pu, err := url.Parse(u); err != nil || (pu.Scheme != "http" && pu.Scheme != "https") || pu.Host == "" {
+			v.add("data.feeds.api_base_url", "must be an http or https URL")
+		}
+	}
+	for i, u := range ev.GetWebhook().GetUrls() {
+		if pu, err := url.Parse(u); err != nil || (pu.Scheme != "http" && pu.Scheme != "https") || pu.Host == "" {
+			v.add(fmt.Sprintf("data.events.webhook.urls[%d]", i), "must be an http or https URL")
+		}
+	}
+	if len(ev.GetWebhook().GetUrls()) > 0 && ev.GetWebhook().GetSecret() == "" {
+		v.add("data.events.webhook.secret", "is required")
+	}
+
+	hasher := x.GetAuth().GetHasher()
+	v.oneOf("auth.hasher.algorithm", hasher.GetAlgorithm(), "", "bcrypt", "argon2id")
+	if c := hasher.GetBcryptCost(); c != 0 && (c < 4 || c > 31) {
+		v.add("auth.hasher.bcrypt_cost", "must be between 4 and 31")
+	}
+	policy := x.GetAuth().GetPasswordPolicy()
+	if policy.GetMinLength() < 0 || policy.GetMaxLength() < 0 {
+		v.add("auth.password_policy", "lengths must not be negative")
+	} else if policy.GetMaxLength() != 0 && policy.GetMaxLength() < policy.GetMinLength() {
+		v.add("auth.password_policy.max_length", "must not be less than min_length")
+	}
+
+	secret := x.GetJwt().GetSecret()
+	if secret == "" {
+		v.add("jwt.secret", "is required")
+	} else if len(secret) < MinJWTSecretLength {
+		v.add("jwt.secret", fmt.Sprintf("must be at least %d bytes", MinJWTSecretLength))
+	}
+	if x.GetJwt().GetExpiresIn().AsDuration() < 0 {
+		v.add("jwt.expires_in", "must not be negative")
+	}
+
+	tr := x.GetTrace()
+	v.oneOf("trace.exporter", tr.GetExporter(), "", "none", "stdout", "otlp")
+	if tr.GetExporter() == "otlp" {
+		v.required("trace.endpoint", tr.GetEndpoint())
+		v.oneOf("trace.protocol", tr.GetProtocol(), "", "grpc", "http")
+	}
+	if r := tr.GetSampleRatio(); r < 0 || r > 1 {
+		v.add("trace.sample_ratio", "must be between 0 and 1")
+	}
+
+	rt := x.GetRuntime()
+	if rt.GetMarkdownCacheSize() < 0 {
+		v.add("runtime.markdown_cache_size", "must not be negative")
+	}
+	if rt.GetVerifyEmailTtl().AsDuration() < 0 {
+		v.add("runtime.verify_email_ttl", "must not be negative")
+	}
+	if rt.GetResetPasswordTtl().AsDuration() < 0 {
+		v.add("runtime.reset_password_ttl", "must not be negative")
+	}
+
+	v.oneOf("log.level", strings.ToLower(x.GetLog().GetLevel()), "", "debug", "info", "warn", "error")
+	v.oneOf("log.format", x.GetLog().GetFormat(), "", "json", "text")
+
+	return v.err()
+}
+
+type validation struct {
+	errs []string
+}
+
+func (v *validation) add(path, msg string) {
+	v.errs = append(v.errs, path+": "+msg)
+}
+
+func (v *validation) required(path, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(path, "is required")
+	}
+}
+
+func (v *validation) oneOf(path, value string, allowed ...string) {
+	var names []string
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+		if a != "" {
+			names = append(names, a)
+		}
+	}
+	v.add(path, fmt.Sprintf("must be one of %s, got %q", strings.Join(names, ", "), value))
+}
+
+func (v *validation) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return errors.New("invalid config:\n  " + strings.Join(v.errs, "\n  "))
+}
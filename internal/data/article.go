@@ -20,6 +20,8 @@ type Article struct {
 	AuthorID       uint
 	Author         User
 	FavoritesCount uint32
+	// 乐观锁，每次修改加一
+	Version uint32 `gorm:"not null;default:1"`
 }
 
 type Tag struct {
@@ -57,6 +59,8 @@ func convertArticle(x Article) *biz.Article {
 		CreatedAt:      x.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:      x.UpdatedAt.Format(time.RFC3339),
 		FavoritesCount: x.FavoritesCount,
+		TagList:        tagNames(x.Tags),
+		Version:        x.Version,
 		Author: &biz.Profile{
 			ID:       x.Author.ID,
 			Username: x.Author.Username,
@@ -66,6 +70,14 @@ func convertArticle(x Article) *biz.Article {
 	}
 }
 
+func tagNames(tags []Tag) []string {
+	rv := make([]string, len(tags))
+	for i, x := range tags {
+		rv[i] = x.Name
+	}
+	return rv
+}
+
 func NewArticleRepo(data *Data, logger log.Logger) biz.ArticleRepo {
 	return &articleRepo{
 		data: data,
@@ -74,8 +86,27 @@ func NewArticleRepo(data *Data, logger log.Logger) biz.ArticleRepo {
 }
 
 func (r *articleRepo) List(ctx context.Context, opts ...biz.ListOption) (rv []*biz.Article, err error) {
+	o := &biz.ListOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	db := r.data.ReadDB(ctx)
+	q := db.Preload("Author").Preload("Tags")
+	if author := o.Filters["author"]; author != "" {
+		q = q.Where("author_id = (?)", db.Model(&User{}).Select("id").Where("username = ?", author))
+	}
+	if o.Tag != "" {
+		q = q.Where("id IN (?)", db.Table("article_tags").Select("article_tags.article_id").
+			Joins("JOIN tags ON tags.id = article_tags.tag_id").Where("tags.name = ?", o.Tag))
+	}
+	if o.Limit > 0 {
+		q = q.Limit(int(o.Limit))
+	}
+	if o.Offset > 0 {
+		q = q.Offset(int(o.Offset))
+	}
 	var articles []Article
-	result := r.data.db.Preload("Author").Find(&articles)
+	result := q.Order("created_at DESC, id DESC").Find(&articles)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -88,13 +119,13 @@ func (r *articleRepo) List(ctx context.Context, opts ...biz.ListOption) (rv []*b
 
 func (r *articleRepo) Get(ctx context.Context, slug string) (rv *biz.Article, err error) {
 	x := Article{}
-	err = r.data.db.Where("slug = ?", slug).Preload("Author").First(&x).Error
+	err = r.data.ReadDB(ctx).Where("slug = ?", slug).Preload("Author").First(&x).Error
 	if err != nil {
 		return nil, err
 	}
 	var fc int64
 	rv = convertArticle(x)
-	err = r.data.db.Model(&ArticleFavorite{}).Where("article_id = ?", x.ID).Count(&fc).Error
+	err = r.data.ReadDB(ctx).Model(&ArticleFavorite{}).Where("article_id = ?", x.ID).Count(&fc).Error
 	rv.FavoritesCount = uint32(fc)
 	return rv, nil
 }
@@ -107,7 +138,7 @@ func (r *articleRepo) Create(ctx context.Context, a *biz.Article) (*biz.Article,
 		})
 	}
 	if len(tags) > 0 {
-		err := r.data.db.Clauses(clause.OnConflict{DoNothing: true}).Create(tags).Error
+		err := r.data.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(tags).Error
 		if err != nil {
 			return nil, err
 		}
@@ -120,8 +151,9 @@ func (r *articleRepo) Create(ctx context.Context, a *biz.Article) (*biz.Article,
 		Body:        a.Body,
 		Author:      User{Model: gorm.Model{ID: a.AuthorUserID}},
 		Tags:        tags,
+		Version:     1,
 	}
-	result := r.data.db.Create(&po)
+	result := r.data.DB(ctx).Create(&po)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -130,45 +162,63 @@ func (r *articleRepo) Create(ctx context.Context, a *biz.Article) (*biz.Article,
 }
 
 func (r *articleRepo) Update(ctx context.Context, a *biz.Article) (*biz.Article, error) {
-	var po Article
-	if result := r.data.db.First(&po); result.Error != nil {
+	values := map[string]interface{}{"version": gorm.Expr("version + 1")}
+	if a.Title != "" {
+		values["title"] = a.Title
+	}
+	if a.Description != "" {
+		values["description"] = a.Description
+	}
+	if a.Body != "" {
+		values["body"] = a.Body
+	}
+	q := r.data.DB(ctx).Model(&Article{}).Where("id = ?", a.ID)
+	if a.Version != 0 {
+		q = q.Where("version = ?", a.Version)
+	}
+	result := q.Updates(values)
+	if result.Error != nil {
 		return nil, result.Error
 	}
-	err := r.data.db.Model(&po).Updates(a).Error
-	return convertArticle(po), err
+	// 条件更新没有命中，说明版本已经变了
+	if result.RowsAffected == 0 {
+		return nil, biz.ErrArticleVersionConflict
+	}
+	return r.GetArticle(ctx, a.ID)
 }
 
 func (r *articleRepo) Delete(ctx context.Context, a *biz.Article) error {
-	rv := r.data.db.Delete(&Article{}, a.ID)
+	rv := r.data.DB(ctx).Delete(&Article{}, a.ID)
 	return rv.Error
 }
 
-func (r *articleRepo) Favorite(ctx context.Context, currentUserID uint, aid uint) error {
+func (r *articleRepo) Favorite(ctx context.Context, currentUserID uint, aid uint) (favorited bool, err error) {
 	af := ArticleFavorite{
 		UserID:    currentUserID,
 		ArticleID: aid,
 	}
 
 	var a Article
-	if err := r.data.db.Where("id = ?", aid).First(&a).Error; err != nil {
-		return err
+	if err := r.data.DB(ctx).Where("id = ?", aid).First(&a).Error; err != nil {
+		return false, err
 	}
 
-	if result := r.data.db.Where(&ArticleFavorite{UserID: currentUserID, ArticleID: aid}).First(&ArticleFavorite{}); result.RowsAffected == 0 {
-		err := r.data.db.Create(&af).Error
+	if result := r.data.DB(ctx).Where(&ArticleFavorite{UserID: currentUserID, ArticleID: aid}).First(&ArticleFavorite{}); result.RowsAffected == 0 {
+		err := r.data.DB(ctx).Create(&af).Error
 		if err != nil {
-			return err
+			return false, err
 		}
 		a.FavoritesCount += 1
+		favorited = true
 	} else {
-		if err := r.data.db.Where(&ArticleFavorite{UserID: currentUserID, ArticleID: aid}).Delete(&ArticleFavorite{}).Error; err != nil {
-			return err
+		if err := r.data.DB(ctx).Where(&ArticleFavorite{UserID: currentUserID, ArticleID: aid}).Delete(&ArticleFavorite{}).Error; err != nil {
+			return false, err
 		}
 		a.FavoritesCount -= 1
 	}
 
-	err := r.data.db.Model(&a).UpdateColumn("favorites_count", a.FavoritesCount).Error
-	return err
+	err = r.data.DB(ctx).Model(&a).UpdateColumn("favorites_count", a.FavoritesCount).Error
+	return favorited, err
 }
 
 func (r *articleRepo) Unfavorite(ctx context.Context, currentUserID uint, aid uint) error {
@@ -176,22 +226,22 @@ func (r *articleRepo) Unfavorite(ctx context.Context, currentUserID uint, aid ui
 		UserID:    currentUserID,
 		ArticleID: aid,
 	}
-	err := r.data.db.Delete(&po).Error
+	err := r.data.DB(ctx).Delete(&po).Error
 	if err != nil {
 		return err
 	}
 	var a Article
-	if err := r.data.db.First(&a).Error; err != nil {
+	if err := r.data.DB(ctx).First(&a).Error; err != nil {
 		return err
 	}
 
-	err = r.data.db.Model(&a).UpdateColumn("favorites_count", a.FavoritesCount-1).Error
+	err = r.data.DB(ctx).Model(&a).UpdateColumn("favorites_count", a.FavoritesCount-1).Error
 	return err
 }
 
 func (r *articleRepo) GetFavoritesStatus(ctx context.Context, currentUserID uint, aa []*biz.Article) (favorited []bool, err error) {
 	var po ArticleFavorite
-	if result := r.data.db.First(&po); result.Error != nil {
+	if result := r.data.DB(ctx).First(&po); result.Error != nil {
 		return nil, nil
 	}
 	return nil, nil
@@ -199,7 +249,7 @@ func (r *articleRepo) GetFavoritesStatus(ctx context.Context, currentUserID uint
 
 func (r *articleRepo) ListTags(ctx context.Context) (rv []biz.Tag, err error) {
 	var tags []Tag
-	err = r.data.db.Find(&tags).Error
+	err = r.data.ReadDB(ctx).Find(&tags).Error
 	if err != nil {
 		return nil, err
 	}
@@ -212,13 +262,13 @@ func (r *articleRepo) ListTags(ctx context.Context) (rv []biz.Tag, err error) {
 
 func (r *articleRepo) GetArticle(ctx context.Context, aid uint) (rv *biz.Article, err error) {
 	x := Article{}
-	err = r.data.db.Where("id = ?", aid).Preload("Author").First(&x).Error
+	err = r.data.DB(ctx).Where("id = ?", aid).Preload("Author").First(&x).Error
 	if err != nil {
 		return nil, err
 	}
 	var fc int64
 	rv = convertArticle(x)
-	err = r.data.db.Model(&ArticleFavorite{}).Where("article_id = ?", x.ID).Count(&fc).Error
+	err = r.data.DB(ctx).Model(&ArticleFavorite{}).Where("article_id = ?", x.ID).Count(&fc).Error
 	rv.FavoritesCount = uint32(fc)
 	return rv, nil
 }
@@ -0,0 +1,39 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"realworld_demo/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListArticles(t *testing.T) {
+	a := assert.New(t)
+	db, stmts := newDryRunDB(t, "query")
+
+	r := NewArticleRepo(&Data{db: db}, log.DefaultLogger)
+	_, err := r.List(context.Background())
+	a.NoError(err)
+	a.Equal("SELECT * FROM `articles` WHERE `articles`.`deleted_at` IS NULL ORDER BY created_at DESC, id DESC", (*stmts)[len(*stmts)-1])
+
+	_, err = r.List(context.Background(), biz.ListAuthor("alice"), biz.ListTag("go"), biz.ListLimit(20), biz.ListOffset(40))
+	a.NoError(err)
+	a.Equal("SELECT * FROM `articles` WHERE author_id = (SELECT `id` FROM `users` WHERE username = ? AND `users`.`deleted_at` IS NULL) AND "+
+		"id IN (SELECT article_tags.article_id FROM `article_tags` JOIN tags ON tags.id = article_tags.tag_id WHERE tags.name = ?) AND "+
+		"`articles`.`deleted_at` IS NULL ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?", (*stmts)[len(*stmts)-1])
+}
+
+func TestUpdateArticleVersion(t *testing.T) {
+	a := assert.New(t)
+	db, stmts := newDryRunDB(t, "update")
+
+	r := NewArticleRepo(&Data{db: db}, log.DefaultLogger)
+	// DryRun 不会更新任何行，视为版本冲突
+	_, err := r.Update(context.Background(), &biz.Article{ID: 7, Title: "New", Body: "text", Version: 3})
+	a.ErrorIs(err, biz.ErrArticleVersionConflict)
+	a.Equal([]string{"UPDATE `articles` SET `body`=?,`title`=?,`version`=version + 1,`updated_at`=? " +
+		"WHERE id = ? AND version = ? AND `articles`.`deleted_at` IS NULL"}, *stmts)
+}
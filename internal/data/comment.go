@@ -35,14 +35,17 @@ func (r *commentRepo) Create(ctx context.Context, in *biz.Comment) (rv *biz.Comm
 		Body:        in.Body,
 		AuthorID:    in.AuthorID,
 	}
-	result := r.data.db.Create(&c)
+	result := r.data.DB(ctx).Create(&c)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &biz.Comment{
-		ID:      c.ID,
-		Article: &biz.Article{},
-		Body:    c.Body,
+		ID:        c.ID,
+		Article:   &biz.Article{},
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		AuthorID:  c.AuthorID,
 		Author: &biz.Profile{
 			Username: c.Author.Username,
 			Bio:      c.Author.Bio,
@@ -53,7 +56,7 @@ func (r *commentRepo) Create(ctx context.Context, in *biz.Comment) (rv *biz.Comm
 
 func (r *commentRepo) List(ctx context.Context, slug string) (rv []*biz.Comment, err error) {
 	var comments []Comment
-	result := r.data.db.Where("article_slug = ?", slug).Preload("Author").Find(&comments)
+	result := r.data.ReadDB(ctx).Where("article_slug = ?", slug).Preload("Author").Find(&comments)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -75,7 +78,7 @@ func (r *commentRepo) List(ctx context.Context, slug string) (rv []*biz.Comment,
 
 func (r *commentRepo) Get(ctx context.Context, id uint) (*biz.Comment, error) {
 	var c Comment
-	result := r.data.db.First(&c, id)
+	result := r.data.DB(ctx).First(&c, id)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -93,5 +96,5 @@ func (r *commentRepo) Get(ctx context.Context, id uint) (*biz.Comment, error) {
 }
 
 func (r *commentRepo) Delete(ctx context.Context, id uint) (err error) {
-	return r.data.db.Delete(&Comment{}, id).Error
+	return r.data.DB(ctx).Delete(&Comment{}, id).Error
 }
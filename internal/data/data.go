@@ -1,11 +1,22 @@
 package data
 
 import (
+	"context"
 	"fmt"
+	"sync/atomic"
+	"time"
+
+	"realworld_demo/internal/biz"
 	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/consistency"
+	"realworld_demo/internal/pkg/health"
+	"realworld_demo/internal/pkg/logging"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/wire"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -19,54 +30,235 @@ var ProviderSet = wire.NewSet(
 	NewProfileRepo,
 	NewArticleRepo,
 	NewCommentRepo,
+	NewObjectStorage,
+	NewUserTokenRepo,
+	NewMailer,
+	NewNotificationRepo,
+	NewTransaction,
+	NewBroker,
+	NewOutboxRepo,
+	NewEventSinks,
+	NewWebhookRepo,
 )
 
 // Data .
 type Data struct {
-	db *gorm.DB
+	db       *gorm.DB
+	replicas *replicaSet
+	// migrated 在表全部存在之后置位，之后的就绪检查不再查询 information_schema
+	migrated atomic.Bool
+}
+
+type txKey struct{}
+
+// DB returns a session on the primary bound to ctx, so queries are traced
+// and cancelled with the request. Inside InTx it returns the transaction.
+func (d *Data) DB(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return d.db.WithContext(ctx)
+}
+
+// InTx implements biz.Transaction.
+func (d *Data) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// NewTransaction .
+func NewTransaction(d *Data) biz.Transaction {
+	return d
+}
+
+// ReadDB is DB for read-only queries that may be served by a replica. Inside
+// a transaction, once the request has written to the primary, or when no
+// replica is healthy, it returns DB.
+func (d *Data) ReadDB(ctx context.Context) *gorm.DB {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok || d.replicas == nil || consistency.Wrote(ctx) {
+		return d.DB(ctx)
+	}
+	if db := d.replicas.pick(); db != nil {
+		return db.WithContext(ctx)
+	}
+	return d.DB(ctx)
 }
 
 // NewData .
-func NewData(c *conf.Data, logger log.Logger, db *gorm.DB) (*Data, func(), error) {
+func NewData(c *conf.Data, logger log.Logger, db *gorm.DB, hc *health.Checker) (*Data, func(), error) {
+	helper := log.NewHelper(logger)
+	d := &Data{db: db}
+	if err := db.Use(writeTracker{}); err != nil {
+		return nil, nil, fmt.Errorf("register write tracker: %w", err)
+	}
+	if len(c.GetDatabase().GetReplicaDsns()) > 0 {
+		rs, err := newReplicaSet(c.GetDatabase(), helper)
+		if err != nil {
+			return nil, nil, err
+		}
+		rs.start()
+		d.replicas = rs
+	}
+	// 从库不可用时读请求走主库，所以不参与 readiness
+	hc.Register("database", d.ping)
+	hc.Register("migrations", d.checkMigrations)
 	cleanup := func() {
-		log.NewHelper(logger).Info("closing the data resources")
+		helper.Info("closing the data resources")
+		if d.replicas != nil {
+			d.replicas.close()
+		}
+		// 在 HTTP/gRPC 服务停止之后调用，此时已没有请求在使用连接
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil {
+			helper.Errorf("close database: %v", err)
+		}
 	}
-	return &Data{db: db}, cleanup, nil
+	return d, cleanup, nil
 }
 
-func NewDB(c *conf.Data) *gorm.DB {
-	log.Info("Connecting to database...")
-	log.Infof("DSN: %s", c.Database.Dsn)
+func (d *Data) ping(ctx context.Context) error {
+	return ping(ctx, d.db)
+}
 
-	// 尝试连接数据库
-	db, err := gorm.Open(mysql.Open(c.Database.Dsn), &gorm.Config{
-		DisableForeignKeyConstraintWhenMigrating: true,
-	})
+// checkMigrations 确认所有模型的表都已创建。表只会在启动时迁移，
+// 确认一次之后结果被缓存，/readyz 只剩数据库的 ping
+func (d *Data) checkMigrations(ctx context.Context) error {
+	if d.migrated.Load() {
+		return nil
+	}
+	m := d.DB(ctx).Migrator()
+	for _, model := range models {
+		if !m.HasTable(model) {
+			return fmt.Errorf("table for %T is missing", model)
+		}
+	}
+	d.migrated.Store(true)
+	return nil
+}
+
+// models 是需要自动迁移的模型
+var models = []interface{}{
+	&User{},
+	&Article{},
+	&Comment{},
+	&ArticleFavorite{},
+	&FollowUser{},
+	&UserToken{},
+	&Notification{},
+	&NotificationPreference{},
+	&OutboxEvent{},
+	&Webhook{},
+	&WebhookDelivery{},
+}
+
+const (
+	defaultConnectTimeout  = 30 * time.Second
+	defaultMaxOpenConns    = 50
+	defaultMaxIdleConns    = 10
+	defaultConnMaxLifetime = 30 * time.Minute
+	defaultConnMaxIdleTime = 5 * time.Minute
+)
+
+// durationOr returns d, or def when d is not set.
+func durationOr(d *durationpb.Duration, def time.Duration) time.Duration {
+	if d == nil {
+		return def
+	}
+	return d.AsDuration()
+}
+
+func NewDB(c *conf.Data, logger log.Logger) (*gorm.DB, error) {
+	helper := log.NewHelper(logger)
+	helper.Info("Connecting to database...")
+	helper.Infof("DSN: %s", logging.Redact(c.Database.Dsn))
+
+	dc := c.GetDatabase()
+	db, err := connect(dc.GetDsn(), durationOr(dc.GetConnectTimeout(), defaultConnectTimeout), helper)
 	if err != nil {
-		log.Errorf("数据库连接失败: %v", err)
-		panic(fmt.Sprintf("failed to connect database: %v", err))
+		return nil, err
 	}
+	helper.Info("数据库连接成功")
 
-	// 测试数据库连接
 	sqlDB, err := db.DB()
 	if err != nil {
-		log.Errorf("获取数据库连接失败: %v", err)
-		panic(fmt.Sprintf("failed to get database connection: %v", err))
+		return nil, fmt.Errorf("get database connection: %w", err)
 	}
-
-	if err := sqlDB.Ping(); err != nil {
-		log.Errorf("数据库Ping失败: %v", err)
-		panic(fmt.Sprintf("database ping failed: %v", err))
+	if err := setupPool(db, dc); err != nil {
+		return nil, err
+	}
+	// 连接池指标：go_sql_* {db_name="realworld"}
+	if err := prometheus.Register(collectors.NewDBStatsCollector(sqlDB, "realworld")); err != nil {
+		helper.Errorf("注册数据库连接池指标失败: %v", err)
 	}
 
-	log.Info("数据库连接成功")
+	if err := db.Use(newTracingPlugin()); err != nil {
+		return nil, fmt.Errorf("register tracing plugin: %w", err)
+	}
 
 	// 初始化数据库表
-	InitDB(db)
-	return db
+	if err := InitDB(db); err != nil {
+		return nil, err
+	}
+
+	// 迁移可能较慢，语句超时在迁移完成后才启用
+	if err := db.Use(newStatementPlugin(dc, helper)); err != nil {
+		return nil, fmt.Errorf("register statement plugin: %w", err)
+	}
+	return db, nil
 }
 
-func InitDB(db *gorm.DB) {
+// setupPool 按配置设置连接池，主库和从库各自一个连接池
+func setupPool(db *gorm.DB, dc *conf.Data_Database) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("get database connection: %w", err)
+	}
+	maxOpen, maxIdle := int(dc.GetMaxOpenConns()), int(dc.GetMaxIdleConns())
+	if maxOpen <= 0 {
+		maxOpen = defaultMaxOpenConns
+	}
+	if maxIdle <= 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+	sqlDB.SetMaxOpenConns(maxOpen)
+	sqlDB.SetMaxIdleConns(maxIdle)
+	sqlDB.SetConnMaxLifetime(durationOr(dc.GetConnMaxLifetime(), defaultConnMaxLifetime))
+	sqlDB.SetConnMaxIdleTime(durationOr(dc.GetConnMaxIdleTime(), defaultConnMaxIdleTime))
+	return nil
+}
+
+// connect 打开数据库并 Ping，失败时按指数退避重试，直到 timeout 用完。
+// 数据库往往与服务同时启动，不应因为它晚几秒就绪而退出
+func connect(dsn string, timeout time.Duration, helper *log.Helper) (*gorm.DB, error) {
+	deadline := time.Now().Add(timeout)
+	backoff := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+			DisableForeignKeyConstraintWhenMigrating: true,
+			Logger:                                   gormLogger{log: helper},
+		})
+		if err == nil {
+			return db, nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return nil, fmt.Errorf("connect database after %d attempts: %w", attempt, err)
+		}
+		helper.Warnf("数据库连接失败（第 %d 次），%s 后重试: %v", attempt, backoff, err)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > 8*time.Second {
+			backoff = 8 * time.Second
+		}
+	}
+}
+
+func InitDB(db *gorm.DB) error {
 	log.Info("开始初始化数据库表...")
 
 	// 检查数据库表是否存在
@@ -79,17 +271,32 @@ func InitDB(db *gorm.DB) {
 		}
 	}
 
+	// 邮箱验证上线前注册的老用户视为已验证
+	grandfather := db.Migrator().HasTable(&User{}) && !db.Migrator().HasColumn(&User{}, "EmailVerified")
+
+	// idx_follow_pair 上线前可能已有重复的关注记录，建唯一索引前只保留最早的一条，
+	// 软删除的记录也会占用索引，一并清除
+	if db.Migrator().HasTable(&FollowUser{}) && !db.Migrator().HasIndex(&FollowUser{}, "idx_follow_pair") {
+		if err := db.Exec("DELETE FROM follow_users WHERE deleted_at IS NOT NULL").Error; err != nil {
+			return fmt.Errorf("remove deleted follows: %w", err)
+		}
+		if err := db.Exec("DELETE f1 FROM follow_users f1 JOIN follow_users f2 " +
+			"ON f1.user_id = f2.user_id AND f1.following_id = f2.following_id AND f1.id > f2.id").Error; err != nil {
+			return fmt.Errorf("remove duplicate follows: %w", err)
+		}
+	}
+
 	// 自动迁移表结构
-	if err := db.AutoMigrate(
-		&User{},
-		&Article{},
-		&Comment{},
-		&ArticleFavorite{},
-		&FollowUser{},
-	); err != nil {
-		log.Errorf("数据库表自动迁移失败: %v", err)
-		panic(fmt.Sprintf("database migration failed: %v", err))
+	if err := db.AutoMigrate(models...); err != nil {
+		return fmt.Errorf("database migration failed: %w", err)
+	}
+
+	if grandfather {
+		if err := db.Model(&User{}).Where("1 = 1").Update("email_verified", true).Error; err != nil {
+			log.Errorf("标记老用户邮箱已验证失败: %v", err)
+		}
 	}
 
 	log.Info("数据库表初始化完成")
+	return nil
 }
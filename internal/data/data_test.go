@@ -0,0 +1,41 @@
+package data
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// newDryRunDB returns a DryRun session, which builds SQL without connecting
+// to the database, and the SQL of the statements of the given kinds
+// (query, create, update or delete) in the order they ran.
+func newDryRunDB(t *testing.T, kinds ...string) (*gorm.DB, *[]string) {
+	t.Helper()
+	conn, err := sql.Open("mysql", "user:pass@tcp(127.0.0.1:1)/db")
+	assert.NoError(t, err)
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	assert.NoError(t, err)
+	stmts := new([]string)
+	record := func(db *gorm.DB) { *stmts = append(*stmts, db.Statement.SQL.String()) }
+	for _, kind := range kinds {
+		name := "test:" + kind
+		switch kind {
+		case "query":
+			err = db.Callback().Query().After("gorm:query").Register(name, record)
+		case "create":
+			err = db.Callback().Create().After("gorm:create").Register(name, record)
+		case "update":
+			err = db.Callback().Update().After("gorm:update").Register(name, record)
+		case "delete":
+			err = db.Callback().Delete().After("gorm:delete").Register(name, record)
+		default:
+			t.Fatalf("unknown statement kind %q", kind)
+		}
+		assert.NoError(t, err)
+	}
+	return db, stmts
+}
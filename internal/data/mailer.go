@@ -0,0 +1,99 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"realworld_demo/internal/biz"
+	"realworld_demo/internal/conf"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// NewMailer picks the mail driver configured in c.Mail.
+func NewMailer(c *conf.Data, logger log.Logger) (biz.Mailer, error) {
+	mc := c.GetMail()
+	switch mc.GetDriver() {
+	case "smtp":
+		return NewSMTPMailer(mc), nil
+	case "file":
+		if mc.GetFile() == "" {
+			return nil, fmt.Errorf("mail.file is required by the file driver")
+		}
+		return NewFileMailer(mc, logger), nil
+	case "", "log":
+		return NewFileMailer(mc, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", mc.GetDriver())
+	}
+}
+
+// headerValue 防止邮件头注入
+var headerValue = strings.NewReplacer("\r", "", "\n", "").Replace
+
+func formatMail(from string, m *biz.Mail) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(m.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+type smtpMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(mc *conf.Data_Mail) biz.Mailer {
+	m := &smtpMailer{addr: mc.GetSmtp().GetAddr(), from: mc.GetFrom()}
+	if u := mc.GetSmtp().GetUsername(); u != "" {
+		host, _, _ := net.SplitHostPort(m.addr)
+		m.auth = smtp.PlainAuth("", u, mc.GetSmtp().GetPassword(), host)
+	}
+	return m
+}
+
+func (m *smtpMailer) Send(ctx context.Context, mail *biz.Mail) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{mail.To}, formatMail(m.from, mail))
+}
+
+// fileMailer is meant for local testing: mails are appended to a file,
+// or written to the log when no file is configured.
+type fileMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+	log  *log.Helper
+}
+
+func NewFileMailer(mc *conf.Data_Mail, logger log.Logger) biz.Mailer {
+	return &fileMailer{path: mc.GetFile(), from: mc.GetFrom(), log: log.NewHelper(logger)}
+}
+
+func (m *fileMailer) Send(ctx context.Context, mail *biz.Mail) error {
+	msg := formatMail(m.from, mail)
+	if m.path == "" {
+		m.log.WithContext(ctx).Infof("mail:\n%s", msg)
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\r\n\r\n", msg)
+	return err
+}
@@ -0,0 +1,135 @@
+package data
+
+import (
+	"context"
+
+	"realworld_demo/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Notification struct {
+	gorm.Model
+	// 按用户列出和统计未读
+	UserID    uint `gorm:"index:idx_notifications_user_read,priority:1"`
+	Read      bool `gorm:"index:idx_notifications_user_read,priority:2"`
+	ActorID   uint
+	Actor     User
+	Type      string `gorm:"size:32"`
+	ArticleID uint
+	Article   Article
+	CommentID uint
+}
+
+// NotificationPreference 没有记录的用户接收所有通知
+type NotificationPreference struct {
+	UserID   uint `gorm:"primaryKey;autoIncrement:false"`
+	Follow   bool
+	Favorite bool
+	Comment  bool
+}
+
+type notificationRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+func NewNotificationRepo(data *Data, logger log.Logger) biz.NotificationRepo {
+	return &notificationRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *notificationRepo) Create(ctx context.Context, n *biz.Notification) error {
+	po := Notification{
+		UserID:    n.UserID,
+		ActorID:   n.ActorID,
+		Type:      n.Type,
+		ArticleID: n.ArticleID,
+		CommentID: n.CommentID,
+	}
+	if err := r.data.DB(ctx).Create(&po).Error; err != nil {
+		return err
+	}
+	n.ID, n.CreatedAt = po.ID, po.CreatedAt
+	return nil
+}
+
+func (r *notificationRepo) List(ctx context.Context, userID uint, unreadOnly bool, limit, offset int64) ([]*biz.Notification, error) {
+	q := r.data.ReadDB(ctx).Where("user_id = ?", userID)
+	if unreadOnly {
+		q = q.Where("`read` = ?", false)
+	}
+	var pos []Notification
+	err := q.Preload("Actor").Preload("Article").
+		Order("id DESC").Limit(int(limit)).Offset(int(offset)).
+		Find(&pos).Error
+	if err != nil {
+		return nil, err
+	}
+	rv := make([]*biz.Notification, 0, len(pos))
+	for _, x := range pos {
+		rv = append(rv, &biz.Notification{
+			ID:      x.ID,
+			UserID:  x.UserID,
+			ActorID: x.ActorID,
+			Actor: &biz.Profile{
+				ID:       x.Actor.ID,
+				Username: x.Actor.Username,
+				Bio:      x.Actor.Bio,
+				Image:    x.Actor.Image,
+			},
+			Type:         x.Type,
+			ArticleID:    x.ArticleID,
+			ArticleSlug:  x.Article.Slug,
+			ArticleTitle: x.Article.Title,
+			CommentID:    x.CommentID,
+			Read:         x.Read,
+			CreatedAt:    x.CreatedAt,
+		})
+	}
+	return rv, nil
+}
+
+func (r *notificationRepo) UnreadCount(ctx context.Context, userID uint) (n int64, err error) {
+	err = r.data.ReadDB(ctx).Model(&Notification{}).
+		Where("user_id = ? AND `read` = ?", userID, false).
+		Count(&n).Error
+	return n, err
+}
+
+func (r *notificationRepo) MarkRead(ctx context.Context, userID uint, ids []uint) error {
+	return r.data.DB(ctx).Model(&Notification{}).
+		Where("user_id = ? AND id IN ? AND `read` = ?", userID, ids, false).
+		Update("read", true).Error
+}
+
+func (r *notificationRepo) MarkAllRead(ctx context.Context, userID uint) error {
+	return r.data.DB(ctx).Model(&Notification{}).
+		Where("user_id = ? AND `read` = ?", userID, false).
+		Update("read", true).Error
+}
+
+func (r *notificationRepo) GetPreferences(ctx context.Context, userID uint) (*biz.NotificationPreferences, error) {
+	var po NotificationPreference
+	result := r.data.DB(ctx).Where("user_id = ?", userID).Limit(1).Find(&po)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return &biz.NotificationPreferences{Follow: true, Favorite: true, Comment: true}, nil
+	}
+	return &biz.NotificationPreferences{Follow: po.Follow, Favorite: po.Favorite, Comment: po.Comment}, nil
+}
+
+func (r *notificationRepo) SavePreferences(ctx context.Context, userID uint, p *biz.NotificationPreferences) error {
+	return r.data.DB(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&NotificationPreference{
+		UserID:   userID,
+		Follow:   p.Follow,
+		Favorite: p.Favorite,
+		Comment:  p.Comment,
+	}).Error
+}
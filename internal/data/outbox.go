@@ -0,0 +1,142 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"realworld_demo/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	outboxPending   = "pending"
+	outboxDelivered = "delivered"
+	outboxDead      = "dead"
+)
+
+// OutboxEvent 与产生它的修改在同一个事务中写入，由 relay 投递。
+// 投递过的事件保留下来便于排查
+type OutboxEvent struct {
+	ID         uint   `gorm:"primaryKey"`
+	Type       string `gorm:"size:64"`
+	Data       string `gorm:"type:text"`
+	OccurredAt time.Time
+	// relay 按 status 和 next_attempt_at 取到期的事件
+	Status        string    `gorm:"size:16;index:idx_outbox_due,priority:1"`
+	NextAttemptAt time.Time `gorm:"index:idx_outbox_due,priority:2"`
+	Attempts      int
+	// 已经成功投递的 sink，每行一个，重试时跳过
+	Delivered    string `gorm:"type:text"`
+	LastError    string `gorm:"size:1024"`
+	ClaimedBy    string `gorm:"size:128;index"`
+	ClaimedUntil *time.Time
+	DeliveredAt  *time.Time
+	CreatedAt    time.Time
+}
+
+type outboxRepo struct {
+	data *Data
+	log  *log.Helper
+	seq  atomic.Uint64
+}
+
+func NewOutboxRepo(data *Data, logger log.Logger) biz.OutboxRepo {
+	return &outboxRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *outboxRepo) Add(ctx context.Context, events ...*biz.DomainEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	pos := make([]OutboxEvent, 0, len(events))
+	for _, e := range events {
+		pos = append(pos, OutboxEvent{
+			Type:          e.Type,
+			Data:          string(e.Data),
+			OccurredAt:    e.OccurredAt,
+			Status:        outboxPending,
+			NextAttemptAt: e.OccurredAt,
+		})
+	}
+	if err := r.data.DB(ctx).Create(&pos).Error; err != nil {
+		return err
+	}
+	for i, po := range pos {
+		events[i].ID = po.ID
+	}
+	return nil
+}
+
+// Claim 先用一条 UPDATE 给到期且未被领取的事件打上本次领取的标记，再按标记查出，
+// 多个实例同时领取时不会拿到同一个事件
+func (r *outboxRepo) Claim(ctx context.Context, owner string, lease time.Duration, limit int) ([]*biz.OutboxEntry, error) {
+	now := time.Now()
+	until := now.Add(lease)
+	token := fmt.Sprintf("%s-%d", owner, r.seq.Add(1))
+	res := r.data.DB(ctx).Model(&OutboxEvent{}).
+		Where("status = ? AND next_attempt_at <= ? AND (claimed_until IS NULL OR claimed_until < ?)", outboxPending, now, now).
+		Order("id").Limit(limit).
+		Updates(map[string]interface{}{"claimed_by": token, "claimed_until": until})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, res.Error
+	}
+	var pos []OutboxEvent
+	err := r.data.DB(ctx).Where("claimed_by = ? AND status = ?", token, outboxPending).Order("id").Find(&pos).Error
+	if err != nil {
+		return nil, err
+	}
+	rv := make([]*biz.OutboxEntry, 0, len(pos))
+	for _, po := range pos {
+		rv = append(rv, convertOutboxEvent(po))
+	}
+	return rv, nil
+}
+
+func (r *outboxRepo) Done(ctx context.Context, id uint) error {
+	return r.data.DB(ctx).Model(&OutboxEvent{ID: id}).Updates(map[string]interface{}{
+		"status":        outboxDelivered,
+		"delivered_at":  time.Now(),
+		"claimed_by":    "",
+		"claimed_until": nil,
+	}).Error
+}
+
+func (r *outboxRepo) Retry(ctx context.Context, e *biz.OutboxEntry, next time.Time, lastErr string, dead bool) error {
+	status := outboxPending
+	if dead {
+		status = outboxDead
+	}
+	return r.data.DB(ctx).Model(&OutboxEvent{ID: e.Event.ID}).Updates(map[string]interface{}{
+		"status":          status,
+		"attempts":        e.Attempts,
+		"delivered":       strings.Join(e.Delivered, "\n"),
+		"last_error":      lastErr,
+		"next_attempt_at": next,
+		"claimed_by":      "",
+		"claimed_until":   nil,
+	}).Error
+}
+
+func convertOutboxEvent(po OutboxEvent) *biz.OutboxEntry {
+	e := &biz.OutboxEntry{
+		Event: &biz.DomainEvent{
+			ID:         po.ID,
+			Type:       po.Type,
+			OccurredAt: po.OccurredAt,
+			Data:       json.RawMessage(po.Data),
+		},
+		Attempts: po.Attempts,
+	}
+	if po.Delivered != "" {
+		e.Delivered = strings.Split(po.Delivered, "\n")
+	}
+	return e
+}
@@ -0,0 +1,102 @@
+package data
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"realworld_demo/internal/biz"
+	"realworld_demo/internal/pkg/webhook"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxClaim(t *testing.T) {
+	a := assert.New(t)
+	db, stmts := newDryRunDB(t, "update")
+
+	r := NewOutboxRepo(&Data{db: db}, log.DefaultLogger)
+	_, err := r.Claim(context.Background(), "host-1", time.Minute, 10)
+	a.NoError(err)
+	// 一条 UPDATE 领取最早的一批事件
+	if a.Len(*stmts, 1) {
+		stmt := (*stmts)[0]
+		a.Contains(stmt, "UPDATE `outbox_events` SET `claimed_by`=?,`claimed_until`=?")
+		a.Contains(stmt, "WHERE status = ? AND next_attempt_at <= ? AND (claimed_until IS NULL OR claimed_until < ?)")
+		a.True(strings.HasSuffix(stmt, "ORDER BY id LIMIT ?"))
+	}
+}
+
+func TestConvertOutboxEvent(t *testing.T) {
+	a := assert.New(t)
+	e := convertOutboxEvent(OutboxEvent{ID: 3, Type: biz.EventUserFollowed, Data: `{"followerId":2}`, Attempts: 2, Delivered: "bus\nfile"})
+	a.Equal(uint(3), e.Event.ID)
+	a.JSONEq(`{"followerId":2}`, string(e.Event.Data))
+	a.Equal(2, e.Attempts)
+	a.Equal([]string{"bus", "file"}, e.Delivered)
+	a.Nil(convertOutboxEvent(OutboxEvent{}).Delivered)
+}
+
+func TestFileEventSink(t *testing.T) {
+	a := assert.New(t)
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	s := NewFileEventSink(path)
+	for i := uint(1); i <= 2; i++ {
+		a.NoError(s.Deliver(context.Background(), &biz.DomainEvent{ID: i, Type: biz.EventUserRegistered, Data: []byte(`{}`)}))
+	}
+
+	f, err := os.Open(path)
+	a.NoError(err)
+	defer f.Close()
+	var ids []uint
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e biz.DomainEvent
+		a.NoError(json.Unmarshal(sc.Bytes(), &e))
+		ids = append(ids, e.ID)
+	}
+	a.Equal([]uint{1, 2}, ids)
+}
+
+func TestWebhookSink(t *testing.T) {
+	a := assert.New(t)
+	status := http.StatusNoContent
+	var (
+		got  *http.Request
+		body []byte
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	s := NewWebhookSink(ts.URL, "whsec_test", []string{biz.EventCommentAdded}, webhook.NewClient(time.Second, true))
+	e := &biz.DomainEvent{ID: 7, Type: biz.EventCommentAdded, Data: []byte(`{}`)}
+	a.NoError(s.Deliver(context.Background(), e))
+	a.Equal("7", got.Header.Get(webhook.HeaderDelivery))
+	a.Equal(biz.EventCommentAdded, got.Header.Get(webhook.HeaderEvent))
+	a.NoError(webhook.Verify("whsec_test", got.Header, body, time.Minute))
+
+	// 未选择的事件不发送
+	got = nil
+	a.NoError(s.Deliver(context.Background(), &biz.DomainEvent{ID: 8, Type: biz.EventUserRegistered, Data: []byte(`{}`)}))
+	a.Nil(got)
+
+	status = http.StatusBadGateway
+	a.Error(s.Deliver(context.Background(), e))
+
+	// 默认不允许私有地址
+	s = NewWebhookSink(ts.URL, "whsec_test", nil, webhook.NewClient(time.Second, false))
+	a.ErrorIs(s.Deliver(context.Background(), e), webhook.ErrPrivateAddress)
+}
@@ -0,0 +1,46 @@
+package data
+
+import (
+	"context"
+	"fmt"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	"realworld_demo/internal/pkg/pubsub"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultChannelPrefix = "realworld:"
+
+// NewBroker returns the broker for real-time events. The memory broker only
+// reaches streams on the same instance.
+func NewBroker(c *conf.Data, hc *health.Checker, logger log.Logger) (pubsub.Broker, func(), error) {
+	pc := c.GetPubsub()
+	switch pc.GetDriver() {
+	case "", "memory":
+		return pubsub.NewMemoryBroker(int(pc.GetBuffer())), func() {}, nil
+	case "redis":
+		rdb := redis.NewClient(&redis.Options{
+			Network:      c.GetRedis().GetNetwork(),
+			Addr:         c.GetRedis().GetAddr(),
+			ReadTimeout:  c.GetRedis().GetReadTimeout().AsDuration(),
+			WriteTimeout: c.GetRedis().GetWriteTimeout().AsDuration(),
+		})
+		prefix := pc.GetChannelPrefix()
+		if prefix == "" {
+			prefix = defaultChannelPrefix
+		}
+		hc.Register("pubsub", func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		})
+		cleanup := func() {
+			if err := rdb.Close(); err != nil {
+				log.NewHelper(logger).Errorf("close pubsub redis: %v", err)
+			}
+		}
+		return pubsub.NewRedisBroker(rdb, prefix, int(pc.GetBuffer())), cleanup, nil
+	}
+	return nil, nil, fmt.Errorf("unknown pubsub driver %q", pc.GetDriver())
+}
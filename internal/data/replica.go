@@ -0,0 +1,172 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/consistency"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultReplicaCheckInterval = 5 * time.Second
+	replicaPingTimeout          = 2 * time.Second
+)
+
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// replicaSet spreads reads over the healthy replicas. A background loop pings
+// every replica, ejecting those that fail and restoring them once they
+// answer again.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	interval time.Duration
+	log      *log.Helper
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newReplicaSet opens the replicas without connecting, so a replica that is
+// down at startup is only ejected instead of failing the service.
+func newReplicaSet(dc *conf.Data_Database, helper *log.Helper) (*replicaSet, error) {
+	rs := &replicaSet{
+		interval: durationOr(dc.GetReplicaCheckInterval(), defaultReplicaCheckInterval),
+		log:      helper,
+		stop:     make(chan struct{}),
+	}
+	for i, dsn := range dc.GetReplicaDsns() {
+		db, err := gorm.Open(mysql.New(mysql.Config{DSN: dsn, SkipInitializeWithVersion: true}), &gorm.Config{
+			DisableAutomaticPing: true,
+			Logger:               gormLogger{log: helper},
+		})
+		if err != nil {
+			rs.close()
+			return nil, fmt.Errorf("open replica %d: %w", i, err)
+		}
+		r := &replica{name: fmt.Sprintf("replica-%d", i), db: db}
+		rs.replicas = append(rs.replicas, r)
+		if err := setupPool(db, dc); err != nil {
+			rs.close()
+			return nil, err
+		}
+		if err := db.Use(newTracingPlugin()); err != nil {
+			rs.close()
+			return nil, fmt.Errorf("register tracing plugin: %w", err)
+		}
+		if err := db.Use(newStatementPlugin(dc, helper)); err != nil {
+			rs.close()
+			return nil, fmt.Errorf("register statement plugin: %w", err)
+		}
+		sqlDB, _ := db.DB()
+		if err := prometheus.Register(collectors.NewDBStatsCollector(sqlDB, "realworld_"+r.name)); err != nil {
+			helper.Errorf("注册从库连接池指标失败: %v", err)
+		}
+	}
+	rs.check()
+	return rs, nil
+}
+
+// pick returns the next healthy replica, or nil when there is none.
+func (rs *replicaSet) pick() *gorm.DB {
+	n := uint64(len(rs.replicas))
+	start := rs.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if r := rs.replicas[(start+i)%n]; r.healthy.Load() {
+			return r.db
+		}
+	}
+	return nil
+}
+
+func (rs *replicaSet) check() {
+	for _, r := range rs.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaPingTimeout)
+		err := ping(ctx, r.db)
+		cancel()
+		switch was := r.healthy.Swap(err == nil); {
+		case was && err != nil:
+			rs.log.Warnf("从库 %s 不可用，读请求改走其他从库或主库: %v", r.name, err)
+		case !was && err == nil:
+			rs.log.Infof("从库 %s 恢复可用", r.name)
+		}
+	}
+}
+
+func (rs *replicaSet) start() {
+	rs.wg.Add(1)
+	go func() {
+		defer rs.wg.Done()
+		ticker := time.NewTicker(rs.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-rs.stop:
+				return
+			case <-ticker.C:
+				rs.check()
+			}
+		}
+	}()
+}
+
+func (rs *replicaSet) close() {
+	select {
+	case <-rs.stop:
+		return
+	default:
+		close(rs.stop)
+	}
+	rs.wg.Wait()
+	for _, r := range rs.replicas {
+		if sqlDB, err := r.db.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				rs.log.Errorf("close %s: %v", r.name, err)
+			}
+		}
+	}
+}
+
+func ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// writeTracker marks the request context after every write on the primary,
+// see consistency.MarkWrite.
+type writeTracker struct{}
+
+func (writeTracker) Name() string { return "write-tracker" }
+
+func (writeTracker) Initialize(db *gorm.DB) error {
+	mark := func(db *gorm.DB) { consistency.MarkWrite(db.Statement.Context) }
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("consistency:create", mark),
+		cb.Update().Before("gorm:update").Register("consistency:update", mark),
+		cb.Delete().Before("gorm:delete").Register("consistency:delete", mark),
+		cb.Raw().Before("gorm:raw").Register("consistency:raw", mark),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/consistency"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// sameDB reports whether two sessions use the same connection pool.
+func sameDB(a, b *gorm.DB) bool {
+	return a.Statement.ConnPool == b.Statement.ConnPool
+}
+
+func TestReadDB(t *testing.T) {
+	a := assert.New(t)
+	primary, _ := newDryRunDB(t)
+	a.NoError(primary.Use(writeTracker{}))
+	db1, _ := newDryRunDB(t)
+	db2, _ := newDryRunDB(t)
+	r1, r2 := &replica{name: "replica-0", db: db1}, &replica{name: "replica-1", db: db2}
+	r1.healthy.Store(true)
+	r2.healthy.Store(true)
+	d := &Data{db: primary, replicas: &replicaSet{replicas: []*replica{r1, r2}, log: log.NewHelper(log.DefaultLogger)}}
+
+	// 读请求在健康的从库间轮询
+	ctx := consistency.NewContext(context.Background())
+	first, second := d.ReadDB(ctx), d.ReadDB(ctx)
+	a.False(sameDB(first, second))
+	for _, db := range []*gorm.DB{first, second} {
+		a.True(sameDB(db, r1.db) || sameDB(db, r2.db))
+	}
+
+	// 摘除不健康的从库
+	r1.healthy.Store(false)
+	a.True(sameDB(r2.db, d.ReadDB(ctx)))
+	a.True(sameDB(r2.db, d.ReadDB(ctx)))
+	r2.healthy.Store(false)
+	a.True(sameDB(primary, d.ReadDB(ctx)))
+	r1.healthy.Store(true)
+
+	// 写入主库之后，同一请求内的读取都走主库
+	d.DB(ctx).Create(&User{Username: "alice"})
+	a.True(consistency.Wrote(ctx))
+	a.True(sameDB(primary, d.ReadDB(ctx)))
+	// 其他请求不受影响
+	a.True(sameDB(r1.db, d.ReadDB(consistency.NewContext(context.Background()))))
+
+	// 没有配置从库时都走主库
+	a.True(sameDB(primary, (&Data{db: primary}).ReadDB(context.Background())))
+}
+
+func TestReplicaEjection(t *testing.T) {
+	a := assert.New(t)
+	// 启动时连不上的从库不会导致启动失败，只是被摘除
+	rs, err := newReplicaSet(&conf.Data_Database{
+		ReplicaDsns: []string{"user:pass@tcp(127.0.0.1:1)/db"},
+	}, log.NewHelper(log.DefaultLogger))
+	a.NoError(err)
+	defer rs.close()
+	a.False(rs.replicas[0].healthy.Load())
+	a.Nil(rs.pick())
+}
@@ -0,0 +1,128 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"realworld_demo/internal/conf"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	defaultReadTimeout   = 5 * time.Second
+	defaultWriteTimeout  = 10 * time.Second
+	defaultSlowThreshold = 200 * time.Millisecond
+
+	statementKey = "statement:state"
+)
+
+// statementPlugin bounds every statement with a timeout on top of the
+// request deadline and logs slow statements. Row and Rows are left alone:
+// their results are read after the callbacks return.
+type statementPlugin struct {
+	readTimeout   time.Duration
+	writeTimeout  time.Duration
+	slowThreshold time.Duration
+	log           *log.Helper
+}
+
+func newStatementPlugin(dc *conf.Data_Database, helper *log.Helper) *statementPlugin {
+	return &statementPlugin{
+		readTimeout:   durationOr(dc.GetReadTimeout(), defaultReadTimeout),
+		writeTimeout:  durationOr(dc.GetWriteTimeout(), defaultWriteTimeout),
+		slowThreshold: durationOr(dc.GetSlowThreshold(), defaultSlowThreshold),
+		log:           helper,
+	}
+}
+
+type statementState struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	start  time.Time
+}
+
+func (p *statementPlugin) Name() string { return "statement" }
+
+func (p *statementPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	// "*" 保证超时覆盖其他插件（如 tracing）和预加载的嵌套查询
+	for _, err := range []error{
+		cb.Create().Before("*").Register("statement:before_create", p.before(p.writeTimeout)),
+		cb.Create().After("*").Register("statement:after_create", p.after),
+		cb.Query().Before("*").Register("statement:before_query", p.before(p.readTimeout)),
+		cb.Query().After("*").Register("statement:after_query", p.after),
+		cb.Update().Before("*").Register("statement:before_update", p.before(p.writeTimeout)),
+		cb.Update().After("*").Register("statement:after_update", p.after),
+		cb.Delete().Before("*").Register("statement:before_delete", p.before(p.writeTimeout)),
+		cb.Delete().After("*").Register("statement:after_delete", p.after),
+		cb.Raw().Before("*").Register("statement:before_raw", p.before(p.writeTimeout)),
+		cb.Raw().After("*").Register("statement:after_raw", p.after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *statementPlugin) before(timeout time.Duration) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		st := &statementState{ctx: db.Statement.Context, start: time.Now()}
+		if timeout > 0 {
+			db.Statement.Context, st.cancel = context.WithTimeout(st.ctx, timeout)
+		}
+		db.InstanceSet(statementKey, st)
+	}
+}
+
+func (p *statementPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(statementKey)
+	if !ok {
+		return
+	}
+	st := v.(*statementState)
+	if st.cancel != nil {
+		st.cancel()
+		// 同一个 *gorm.DB 可能继续执行下一条语句，不能留下已取消的 ctx
+		db.Statement.Context = st.ctx
+	}
+	if p.slowThreshold <= 0 {
+		return
+	}
+	if elapsed := time.Since(st.start); elapsed >= p.slowThreshold {
+		// 只记录带占位符的 SQL，不记录参数
+		p.log.WithContext(st.ctx).Warnf("slow sql (%s, %d rows): %s",
+			elapsed.Round(time.Millisecond), db.RowsAffected, db.Statement.SQL.String())
+	}
+}
+
+// gormLogger sends GORM's own messages through the service logger instead
+// of stdout. Slow statements are reported by statementPlugin.
+type gormLogger struct {
+	log *log.Helper
+}
+
+func (l gormLogger) LogMode(logger.LogLevel) logger.Interface { return l }
+
+func (l gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.log.WithContext(ctx).Infof(msg, args...)
+}
+
+func (l gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.log.WithContext(ctx).Warnf(msg, args...)
+}
+
+func (l gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.log.WithContext(ctx).Errorf(msg, args...)
+}
+
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+	l.log.WithContext(ctx).Errorf("sql error after %s: %v", time.Since(begin).Round(time.Millisecond), err)
+}
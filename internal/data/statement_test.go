@@ -0,0 +1,51 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestStatementPlugin(t *testing.T) {
+	a := assert.New(t)
+	db, _ := newDryRunDB(t)
+	var buf bytes.Buffer
+	a.NoError(db.Use(&statementPlugin{
+		readTimeout:   time.Second,
+		writeTimeout:  time.Minute,
+		slowThreshold: time.Nanosecond,
+		log:           log.NewHelper(log.NewStdLogger(&buf)),
+	}))
+
+	// 记录语句执行时的 deadline
+	var deadlines []time.Duration
+	record := func(db *gorm.DB) {
+		if d, ok := db.Statement.Context.Deadline(); ok {
+			deadlines = append(deadlines, time.Until(d))
+		}
+	}
+	a.NoError(db.Callback().Query().Before("gorm:query").Register("test:query", record))
+	a.NoError(db.Callback().Create().Before("gorm:create").Register("test:create", record))
+
+	d := &Data{db: db}
+	tx := d.DB(context.Background()).Where("username = ?", "alice")
+	var users []User
+	tx.Find(&users)
+	tx.Create(&User{Username: "bob"})
+	a.Len(deadlines, 2)
+	a.InDelta(time.Second, deadlines[0], float64(100*time.Millisecond))
+	a.InDelta(time.Minute, deadlines[1], float64(100*time.Millisecond))
+	// 语句结束后恢复原来的 ctx
+	_, ok := tx.Statement.Context.Deadline()
+	a.False(ok)
+	a.NoError(tx.Statement.Context.Err())
+
+	a.Contains(buf.String(), "slow sql")
+	a.Contains(buf.String(), "SELECT * FROM `users` WHERE username = ?")
+	a.NotContains(buf.String(), "alice")
+}
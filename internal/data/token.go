@@ -0,0 +1,74 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"realworld_demo/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+)
+
+type UserToken struct {
+	gorm.Model
+	UserID    uint   `gorm:"index"`
+	Purpose   string `gorm:"size:32"`
+	TokenHash string `gorm:"size:64;uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type userTokenRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+func NewUserTokenRepo(data *Data, logger log.Logger) biz.UserTokenRepo {
+	return &userTokenRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *userTokenRepo) CreateToken(ctx context.Context, t *biz.UserToken) error {
+	return r.data.DB(ctx).Create(&UserToken{
+		UserID:    t.UserID,
+		Purpose:   t.Purpose,
+		TokenHash: t.TokenHash,
+		ExpiresAt: t.ExpiresAt,
+	}).Error
+}
+
+func (r *userTokenRepo) ConsumeToken(ctx context.Context, purpose, tokenHash string) (userID uint, err error) {
+	now := time.Now()
+	err = r.data.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var t UserToken
+		if err := tx.Where("token_hash = ? AND purpose = ?", tokenHash, purpose).First(&t).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return biz.ErrInvalidToken
+			}
+			return err
+		}
+		// 条件更新保证并发下只有一个请求能使用该 token
+		rv := tx.Model(&UserToken{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", t.ID, now).
+			Update("used_at", now)
+		if rv.Error != nil {
+			return rv.Error
+		}
+		if rv.RowsAffected == 0 {
+			return biz.ErrInvalidToken
+		}
+		userID = t.UserID
+		return nil
+	})
+	return userID, err
+}
+
+func (r *userTokenRepo) RevokeTokens(ctx context.Context, userID uint, purpose string) error {
+	return r.data.DB(ctx).Model(&UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
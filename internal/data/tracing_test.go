@@ -0,0 +1,39 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingPlugin(t *testing.T) {
+	a := assert.New(t)
+	rec := tracetest.NewSpanRecorder()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(rec))
+
+	db, _ := newDryRunDB(t)
+	a.NoError(db.Use(&tracingPlugin{tracer: tp.Tracer("test")}))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "ListArticles")
+	d := &Data{db: db}
+	var users []User
+	d.DB(ctx).Where("username = ?", "alice").Find(&users)
+	parent.End()
+
+	spans := rec.Ended()
+	a.Len(spans, 2)
+	q := spans[0]
+	a.Equal("gorm.Query", q.Name())
+	a.Equal(parent.SpanContext().SpanID(), q.Parent().SpanID())
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range q.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	a.Contains(attrs["db.statement"].AsString(), "SELECT * FROM `users` WHERE username = ?")
+	a.NotContains(attrs["db.statement"].AsString(), "alice")
+	a.Equal("users", attrs["db.sql.table"].AsString())
+}
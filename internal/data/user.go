@@ -14,8 +14,8 @@ import (
 
 type FollowUser struct {
 	gorm.Model
-	UserID      uint
-	FollowingID uint
+	UserID      uint `gorm:"uniqueIndex:idx_follow_pair"`
+	FollowingID uint `gorm:"uniqueIndex:idx_follow_pair"`
 }
 
 type userRepo struct {
@@ -25,12 +25,13 @@ type userRepo struct {
 
 type User struct {
 	gorm.Model
-	Email        string `gorm:"size:500"`
-	Username     string `gorm:"size:500"`
-	Bio          string `gorm:"size:1000"`
-	Image        string `gorm:"size:1000"`
-	PasswordHash string `gorm:"size:500"`
-	Following    uint32
+	Email         string `gorm:"size:500"`
+	Username      string `gorm:"size:500"`
+	Bio           string `gorm:"size:1000"`
+	Image         string `gorm:"size:1000"`
+	PasswordHash  string `gorm:"size:500"`
+	Following     uint32
+	EmailVerified bool
 }
 
 func NewUserRepo(data *Data, logger log.Logger) biz.UserRepo {
@@ -55,20 +56,20 @@ func NewProfileRepo(data *Data, logger log.Logger) biz.ProfileRepo {
 func (r *userRepo) CreateUser(ctx context.Context, u *biz.User) error {
 	// 检查邮箱是否已存在
 	var existingUser User
-	if err := r.data.db.Where("email = ?", u.Email).First(&existingUser).Error; err == nil {
-		r.log.Errorf("创建用户失败: 邮箱 %s 已存在", u.Email)
+	if err := r.data.DB(ctx).Where("email = ?", u.Email).First(&existingUser).Error; err == nil {
+		r.log.WithContext(ctx).Errorf("创建用户失败: 邮箱 %s 已存在", u.Email)
 		return fmt.Errorf("邮箱已被注册")
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-		r.log.Errorf("查询用户邮箱时发生错误: %v", err)
+		r.log.WithContext(ctx).Errorf("查询用户邮箱时发生错误: %v", err)
 		return err
 	}
 
 	// 检查用户名是否已存在
-	if err := r.data.db.Where("username = ?", u.Username).First(&existingUser).Error; err == nil {
-		r.log.Errorf("创建用户失败: 用户名 %s 已存在", u.Username)
+	if err := r.data.DB(ctx).Where("username = ?", u.Username).First(&existingUser).Error; err == nil {
+		r.log.WithContext(ctx).Errorf("创建用户失败: 用户名 %s 已存在", u.Username)
 		return fmt.Errorf("用户名已被使用")
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-		r.log.Errorf("查询用户名时发生错误: %v", err)
+		r.log.WithContext(ctx).Errorf("查询用户名时发生错误: %v", err)
 		return err
 	}
 
@@ -81,114 +82,134 @@ func (r *userRepo) CreateUser(ctx context.Context, u *biz.User) error {
 		PasswordHash: u.PasswordHash,
 	}
 
-	r.log.Infof("开始创建用户: email=%s, username=%s", u.Email, u.Username)
+	r.log.WithContext(ctx).Infof("开始创建用户: email=%s, username=%s", u.Email, u.Username)
 
 	// 尝试连接数据库
 	sqlDB, err := r.data.db.DB()
 	if err != nil {
-		r.log.Errorf("获取数据库连接失败: %v", err)
+		r.log.WithContext(ctx).Errorf("获取数据库连接失败: %v", err)
 		return fmt.Errorf("数据库连接错误: %w", err)
 	}
 
 	// 测试数据库连接
-	if err := sqlDB.Ping(); err != nil {
-		r.log.Errorf("数据库连接测试失败: %v", err)
+	if err := sqlDB.PingContext(ctx); err != nil {
+		r.log.WithContext(ctx).Errorf("数据库连接测试失败: %v", err)
 		return fmt.Errorf("数据库连接测试失败: %w", err)
 	}
 
-	rv := r.data.db.Create(&user)
+	rv := r.data.DB(ctx).Create(&user)
 	if rv.Error != nil {
-		r.log.Errorf("创建用户失败: %v", rv.Error)
+		r.log.WithContext(ctx).Errorf("创建用户失败: %v", rv.Error)
 		return rv.Error
 	}
 
 	if user.ID == 0 {
-		r.log.Error("创建用户后ID为0")
+		r.log.WithContext(ctx).Error("创建用户后ID为0")
 		return fmt.Errorf("创建用户后ID为0")
 	}
 
 	// 更新用户ID
 	u.ID = user.ID
-	r.log.Infof("创建用户成功: id=%d, email=%s, username=%s", user.ID, u.Email, u.Username)
+	r.log.WithContext(ctx).Infof("创建用户成功: id=%d, email=%s, username=%s", user.ID, u.Email, u.Username)
 	return nil
 }
 
 func (r *userRepo) UpdateUser(ctx context.Context, in *biz.User) (rv *biz.User, err error) {
 	u := new(User)
-	err = r.data.db.Where("username = ?", in.Username).First(u).Error
+	err = r.data.DB(ctx).Where("id = ?", in.ID).First(u).Error
 	if err != nil {
 		return nil, err
 	}
-	err = r.data.db.Model(&u).Updates(&User{
-		Email:        in.Email,
-		Bio:          in.Bio,
-		PasswordHash: in.PasswordHash,
-		Image:        in.Image,
+	// 使用 map 更新，允许把 bio、image 清空
+	err = r.data.DB(ctx).Model(u).Updates(map[string]interface{}{
+		"email":          in.Email,
+		"username":       in.Username,
+		"bio":            in.Bio,
+		"image":          in.Image,
+		"password_hash":  in.PasswordHash,
+		"email_verified": in.EmailVerified,
 	}).Error
+	if err != nil {
+		return nil, err
+	}
 	return &biz.User{
-		ID:           u.ID,
-		Email:        u.Email,
-		Username:     u.Username,
-		Bio:          u.Bio,
-		Image:        u.Image,
-		PasswordHash: u.PasswordHash,
+		ID:            u.ID,
+		Email:         u.Email,
+		Username:      u.Username,
+		Bio:           u.Bio,
+		Image:         u.Image,
+		PasswordHash:  u.PasswordHash,
+		EmailVerified: u.EmailVerified,
 	}, nil
 }
 
+func (r *userRepo) SetEmailVerified(ctx context.Context, id uint, verified bool) error {
+	return r.data.DB(ctx).Model(&User{}).Where("id = ?", id).Update("email_verified", verified).Error
+}
+
 func (r *userRepo) GetUserByEmail(ctx context.Context, email string) (rv *biz.User, err error) {
 	u := new(User)
-	result := r.data.db.Where("email = ?", email).First(u)
+	result := r.data.DB(ctx).Where("email = ?", email).First(u)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, errors.NotFound("user", "not found by email")
 	}
 	if result.Error != nil {
-		return nil, err
+		return nil, result.Error
 	}
 	return &biz.User{
-		ID:           u.ID,
-		Email:        u.Email,
-		Username:     u.Username,
-		Bio:          u.Bio,
-		Image:        u.Image,
-		PasswordHash: u.PasswordHash,
+		ID:            u.ID,
+		Email:         u.Email,
+		Username:      u.Username,
+		Bio:           u.Bio,
+		Image:         u.Image,
+		PasswordHash:  u.PasswordHash,
+		EmailVerified: u.EmailVerified,
 	}, nil
 }
 
 func (r *userRepo) GetUserByID(ctx context.Context, id uint) (rv *biz.User, err error) {
 	u := new(User)
-	err = r.data.db.Where("id = ?", id).First(u).Error
+	err = r.data.DB(ctx).Where("id = ?", id).First(u).Error
 	if err != nil {
 		return nil, err
 	}
 	return &biz.User{
-		ID:           u.ID,
-		Email:        u.Email,
-		Username:     u.Username,
-		Bio:          u.Bio,
-		Image:        u.Image,
-		PasswordHash: u.PasswordHash,
+		ID:            u.ID,
+		Email:         u.Email,
+		Username:      u.Username,
+		Bio:           u.Bio,
+		Image:         u.Image,
+		PasswordHash:  u.PasswordHash,
+		EmailVerified: u.EmailVerified,
 	}, nil
 }
 
 func (r *userRepo) GetUserByUsername(ctx context.Context, username string) (rv *biz.User, err error) {
 	u := new(User)
-	err = r.data.db.Where("username = ?", username).First(u).Error
-	if err != nil {
-		return nil, err
+	result := r.data.DB(ctx).Where("username = ?", username).First(u)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, errors.NotFound("user", "not found by username")
+	}
+	if result.Error != nil {
+		return nil, result.Error
 	}
 	return &biz.User{
-		ID:           u.ID,
-		Email:        u.Email,
-		Username:     u.Username,
-		Bio:          u.Bio,
-		Image:        u.Image,
-		PasswordHash: u.PasswordHash,
+		ID:            u.ID,
+		Email:         u.Email,
+		Username:      u.Username,
+		Bio:           u.Bio,
+		Image:         u.Image,
+		PasswordHash:  u.PasswordHash,
+		EmailVerified: u.EmailVerified,
 	}, nil
 }
 
 func (r *profileRepo) GetProfile(ctx context.Context, username string) (rv *biz.Profile, err error) {
 	u := new(User)
-	err = r.data.db.Where("username = ?", username).First(u).Error
+	err = r.data.ReadDB(ctx).Where("username = ?", username).First(u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.NotFound("user", "not found by username")
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -201,26 +222,32 @@ func (r *profileRepo) GetProfile(ctx context.Context, username string) (rv *biz.
 	}, nil
 }
 
-func (r *profileRepo) FollowUser(ctx context.Context, currentUserID uint, followingID uint) (err error) {
+func (r *profileRepo) FollowUser(ctx context.Context, currentUserID uint, followingID uint) (followed bool, err error) {
 	po := FollowUser{
 		UserID:      currentUserID,
 		FollowingID: followingID,
 	}
-	return r.data.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&po).Error
+	// idx_follow_pair 冲突时不插入，RowsAffected 为 0
+	result := r.data.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&po)
+	return result.RowsAffected > 0, result.Error
 }
 
 func (r *profileRepo) UnfollowUser(ctx context.Context, currentUserID uint, followingID uint) (err error) {
-	po := FollowUser{
-		UserID:      currentUserID,
-		FollowingID: followingID,
-	}
-	return r.data.db.Delete(&po).Error
+	// 物理删除，否则软删除的记录仍占着 idx_follow_pair，无法再次关注
+	return r.data.DB(ctx).Unscoped().
+		Where("user_id = ? AND following_id = ?", currentUserID, followingID).
+		Delete(&FollowUser{}).Error
 }
 
 func (r *profileRepo) GetUserFollowingStatus(ctx context.Context, currentUserID uint, userIDs []uint) (following []bool, err error) {
 	var po FollowUser
-	if result := r.data.db.First(&po); result.Error != nil {
+	if result := r.data.DB(ctx).First(&po); result.Error != nil {
 		return nil, nil
 	}
 	return nil, nil
 }
+
+func (r *profileRepo) ListFollowerIDs(ctx context.Context, userID uint) (ids []uint, err error) {
+	err = r.data.ReadDB(ctx).Model(&FollowUser{}).Where("following_id = ?", userID).Pluck("user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,25 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowUser(t *testing.T) {
+	a := assert.New(t)
+	db, stmts := newDryRunDB(t, "create", "delete")
+
+	r := NewProfileRepo(&Data{db: db}, log.DefaultLogger)
+	// DryRun 不会插入任何行，视为已经关注
+	followed, err := r.FollowUser(context.Background(), 2, 1)
+	a.NoError(err)
+	a.False(followed)
+	a.NoError(r.UnfollowUser(context.Background(), 2, 1))
+	a.Equal([]string{
+		"INSERT INTO `follow_users` (`created_at`,`updated_at`,`deleted_at`,`user_id`,`following_id`) VALUES (?,?,?,?,?) ON DUPLICATE KEY UPDATE `id`=`id`",
+		"DELETE FROM `follow_users` WHERE user_id = ? AND following_id = ?",
+	}, *stmts)
+}
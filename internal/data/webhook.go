@@ -0,0 +1,254 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"realworld_demo/internal/biz"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Webhook struct {
+	ID     uint   `gorm:"primaryKey"`
+	UserID uint   `gorm:"index"`
+	URL    string `gorm:"size:2048"`
+	Secret string `gorm:"size:64"`
+	// 订阅的事件，逗号分隔
+	Events    string `gorm:"size:255"`
+	Global    bool
+	CreatedAt time.Time
+}
+
+// WebhookDelivery 即投递日志。测试事件的 EventID 为 NULL，不受唯一索引约束
+type WebhookDelivery struct {
+	ID             uint  `gorm:"primaryKey"`
+	WebhookID      uint  `gorm:"uniqueIndex:idx_webhook_deliveries_event,priority:1"`
+	EventID        *uint `gorm:"uniqueIndex:idx_webhook_deliveries_event,priority:2"`
+	Webhook        *Webhook
+	EventType      string    `gorm:"size:64"`
+	Payload        string    `gorm:"type:text"`
+	Status         string    `gorm:"size:16;index:idx_webhook_deliveries_due,priority:1"`
+	NextAttemptAt  time.Time `gorm:"index:idx_webhook_deliveries_due,priority:2"`
+	Attempts       int
+	ResponseStatus int
+	ResponseBody   string `gorm:"size:1024"`
+	Error          string `gorm:"size:1024"`
+	DurationMs     int64
+	ClaimedBy      string `gorm:"size:128;index"`
+	ClaimedUntil   *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type webhookRepo struct {
+	data *Data
+	log  *log.Helper
+	seq  atomic.Uint64
+}
+
+func NewWebhookRepo(data *Data, logger log.Logger) biz.WebhookRepo {
+	return &webhookRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func convertWebhook(po *Webhook) *biz.Webhook {
+	return &biz.Webhook{
+		ID:        po.ID,
+		UserID:    po.UserID,
+		URL:       po.URL,
+		Secret:    po.Secret,
+		Events:    strings.Split(po.Events, ","),
+		Global:    po.Global,
+		CreatedAt: po.CreatedAt,
+	}
+}
+
+func (r *webhookRepo) Create(ctx context.Context, w *biz.Webhook) error {
+	po := Webhook{
+		UserID: w.UserID,
+		URL:    w.URL,
+		Secret: w.Secret,
+		Events: strings.Join(w.Events, ","),
+		Global: w.Global,
+	}
+	if err := r.data.DB(ctx).Create(&po).Error; err != nil {
+		return err
+	}
+	w.ID, w.CreatedAt = po.ID, po.CreatedAt
+	return nil
+}
+
+func (r *webhookRepo) Get(ctx context.Context, id uint) (*biz.Webhook, error) {
+	var po Webhook
+	if err := r.data.DB(ctx).First(&po, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, kerrors.NotFound("webhook", "not found")
+		}
+		return nil, err
+	}
+	return convertWebhook(&po), nil
+}
+
+func (r *webhookRepo) List(ctx context.Context, userID uint) ([]*biz.Webhook, error) {
+	var pos []Webhook
+	if err := r.data.ReadDB(ctx).Where("user_id = ?", userID).Order("id").Find(&pos).Error; err != nil {
+		return nil, err
+	}
+	rv := make([]*biz.Webhook, 0, len(pos))
+	for i := range pos {
+		rv = append(rv, convertWebhook(&pos[i]))
+	}
+	return rv, nil
+}
+
+func (r *webhookRepo) Count(ctx context.Context, userID uint) (n int64, err error) {
+	err = r.data.DB(ctx).Model(&Webhook{}).Where("user_id = ?", userID).Count(&n).Error
+	return n, err
+}
+
+func (r *webhookRepo) Delete(ctx context.Context, id uint) error {
+	return r.data.InTx(ctx, func(ctx context.Context) error {
+		if err := r.data.DB(ctx).Where("webhook_id = ?", id).Delete(&WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return r.data.DB(ctx).Delete(&Webhook{}, id).Error
+	})
+}
+
+func (r *webhookRepo) ListSubscribed(ctx context.Context, typ string, aud biz.WebhookAudience) ([]*biz.Webhook, error) {
+	db := r.data.DB(ctx)
+	owners := db.Where("`global` = ?", true)
+	if len(aud.UserIDs) > 0 {
+		owners = owners.Or("user_id IN ?", aud.UserIDs)
+	}
+	if aud.FollowersOf != 0 {
+		owners = owners.Or("user_id IN (?)", db.Model(&FollowUser{}).Select("user_id").Where("following_id = ?", aud.FollowersOf))
+	}
+	var pos []Webhook
+	if err := db.Where("FIND_IN_SET(?, events) > 0", typ).Where(owners).Find(&pos).Error; err != nil {
+		return nil, err
+	}
+	rv := make([]*biz.Webhook, 0, len(pos))
+	for i := range pos {
+		rv = append(rv, convertWebhook(&pos[i]))
+	}
+	return rv, nil
+}
+
+func (r *webhookRepo) CreateDeliveries(ctx context.Context, ds ...*biz.WebhookDelivery) error {
+	if len(ds) == 0 {
+		return nil
+	}
+	pos := make([]WebhookDelivery, 0, len(ds))
+	for _, d := range ds {
+		po := WebhookDelivery{
+			WebhookID:     d.WebhookID,
+			EventType:     d.EventType,
+			Payload:       string(d.Payload),
+			Status:        d.Status,
+			NextAttemptAt: d.NextAttemptAt,
+		}
+		if d.EventID != 0 {
+			id := d.EventID
+			po.EventID = &id
+		}
+		pos = append(pos, po)
+	}
+	// relay 可能重复投递同一个事件
+	if err := r.data.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&pos).Error; err != nil {
+		return err
+	}
+	for i, po := range pos {
+		ds[i].ID, ds[i].CreatedAt, ds[i].UpdatedAt = po.ID, po.CreatedAt, po.UpdatedAt
+	}
+	return nil
+}
+
+// ClaimDeliveries 与 outboxRepo.Claim 相同，先标记再按标记查出
+func (r *webhookRepo) ClaimDeliveries(ctx context.Context, owner string, lease time.Duration, limit int) ([]*biz.WebhookDelivery, error) {
+	now := time.Now()
+	token := fmt.Sprintf("%s-%d", owner, r.seq.Add(1))
+	res := r.data.DB(ctx).Model(&WebhookDelivery{}).
+		Where("status = ? AND next_attempt_at <= ? AND (claimed_until IS NULL OR claimed_until < ?)", biz.WebhookDeliveryPending, now, now).
+		Order("next_attempt_at").Limit(limit).
+		Updates(map[string]interface{}{"claimed_by": token, "claimed_until": now.Add(lease)})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, res.Error
+	}
+	var pos []WebhookDelivery
+	err := r.data.DB(ctx).Preload("Webhook").
+		Where("claimed_by = ? AND status = ?", token, biz.WebhookDeliveryPending).
+		Order("next_attempt_at").Find(&pos).Error
+	if err != nil {
+		return nil, err
+	}
+	rv := make([]*biz.WebhookDelivery, 0, len(pos))
+	for i := range pos {
+		rv = append(rv, convertWebhookDelivery(&pos[i]))
+	}
+	return rv, nil
+}
+
+func (r *webhookRepo) UpdateDelivery(ctx context.Context, d *biz.WebhookDelivery) error {
+	return r.data.DB(ctx).Model(&WebhookDelivery{ID: d.ID}).Updates(map[string]interface{}{
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"response_status": d.ResponseStatus,
+		"response_body":   d.ResponseBody,
+		"error":           d.Error,
+		"duration_ms":     d.Duration.Milliseconds(),
+		"next_attempt_at": d.NextAttemptAt,
+		"claimed_by":      "",
+		"claimed_until":   nil,
+	}).Error
+}
+
+func (r *webhookRepo) ListDeliveries(ctx context.Context, webhookID uint, limit, offset int64) ([]*biz.WebhookDelivery, error) {
+	var pos []WebhookDelivery
+	err := r.data.ReadDB(ctx).Where("webhook_id = ?", webhookID).
+		Order("id DESC").Limit(int(limit)).Offset(int(offset)).
+		Find(&pos).Error
+	if err != nil {
+		return nil, err
+	}
+	rv := make([]*biz.WebhookDelivery, 0, len(pos))
+	for i := range pos {
+		rv = append(rv, convertWebhookDelivery(&pos[i]))
+	}
+	return rv, nil
+}
+
+func convertWebhookDelivery(po *WebhookDelivery) *biz.WebhookDelivery {
+	d := &biz.WebhookDelivery{
+		ID:             po.ID,
+		WebhookID:      po.WebhookID,
+		EventType:      po.EventType,
+		Payload:        []byte(po.Payload),
+		Status:         po.Status,
+		Attempts:       po.Attempts,
+		ResponseStatus: po.ResponseStatus,
+		ResponseBody:   po.ResponseBody,
+		Error:          po.Error,
+		Duration:       time.Duration(po.DurationMs) * time.Millisecond,
+		NextAttemptAt:  po.NextAttemptAt,
+		CreatedAt:      po.CreatedAt,
+		UpdatedAt:      po.UpdatedAt,
+	}
+	if po.EventID != nil {
+		d.EventID = *po.EventID
+	}
+	if po.Webhook != nil {
+		d.Webhook = convertWebhook(po.Webhook)
+	}
+	return d
+}
@@ -0,0 +1,25 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"realworld_demo/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListSubscribedWebhooks(t *testing.T) {
+	a := assert.New(t)
+	db, stmts := newDryRunDB(t, "query")
+
+	r := NewWebhookRepo(&Data{db: db}, log.DefaultLogger)
+	_, err := r.ListSubscribed(context.Background(), biz.EventArticlePublished, biz.WebhookAudience{UserIDs: []uint{1, 2}, FollowersOf: 3})
+	a.NoError(err)
+	// 子查询在构建 SQL 时也会经过 query 回调，最后一条才是实际执行的语句
+	if a.NotEmpty(*stmts) {
+		a.Equal("SELECT * FROM `webhooks` WHERE FIND_IN_SET(?, events) > 0 AND (`global` = ? OR user_id IN (?,?) OR "+
+			"user_id IN (SELECT `user_id` FROM `follow_users` WHERE following_id = ? AND `follow_users`.`deleted_at` IS NULL))", (*stmts)[len(*stmts)-1])
+	}
+}
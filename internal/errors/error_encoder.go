@@ -17,6 +17,10 @@ func NewHTTPError(code int, field string, detail string) *HTTPError {
 
 type HTTPError struct {
 	Errors map[string][]string `json:"errors"`
+	// RequestID 与响应头 X-Request-ID 相同，方便用户反馈问题时对照日志
+	RequestID string `json:"requestId,omitempty"`
+	// Metadata 是 kratos 错误的附加信息，如版本冲突时的当前版本
+	Metadata map[string]string `json:"metadata,omitempty"`
 
 	Code int `json:"-"`
 }
@@ -33,7 +37,9 @@ func FromError(err error) *HTTPError {
 		return se
 	}
 	if se := new(errors.Error); errors.As(err, &se) {
-		return NewHTTPError(int(se.Code), se.Reason, se.Message)
+		he := NewHTTPError(int(se.Code), se.Reason, se.Message)
+		he.Metadata = se.Metadata
+		return he
 	}
 	return NewHTTPError(500, "internal", "error")
 }
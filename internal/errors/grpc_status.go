@@ -0,0 +1,63 @@
+package errors
+
+import (
+	"net/http"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcCodes 补充 kratos 默认映射中没有的状态码，其余沿用 kratos 的映射
+var grpcCodes = map[int]codes.Code{
+	http.StatusUnprocessableEntity:   codes.InvalidArgument,
+	http.StatusPreconditionFailed:    codes.FailedPrecondition,
+	http.StatusRequestEntityTooLarge: codes.InvalidArgument,
+}
+
+func grpcCode(code int) codes.Code {
+	if c, ok := grpcCodes[code]; ok {
+		return c
+	}
+	return errors.New(code, "", "").GRPCStatus().Code()
+}
+
+// GRPCStatus converts a domain error to a gRPC status error. The reason
+// (the field name for validation errors) is carried in ErrorInfo, so kratos
+// clients decode it back into an *errors.Error with the same reason.
+// Unknown errors become Internal without leaking their message.
+func GRPCStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	var (
+		code     int
+		reason   string
+		message  string
+		metadata map[string]string
+	)
+	if ke := new(errors.Error); errors.As(err, &ke) {
+		code, reason, message, metadata = int(ke.Code), ke.Reason, ke.Message, ke.Metadata
+	} else if he := new(HTTPError); errors.As(err, &he) {
+		code = he.Code
+		for field, details := range he.Errors {
+			reason = field
+			if len(details) > 0 {
+				message = details[0]
+			}
+		}
+	} else if _, ok := status.FromError(err); ok {
+		return err
+	} else {
+		return status.Error(codes.Internal, "internal error")
+	}
+	st, detailErr := status.New(grpcCode(code), message).WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Metadata: metadata,
+	})
+	if detailErr != nil {
+		return status.Error(grpcCode(code), message)
+	}
+	return st.Err()
+}
@@ -0,0 +1,49 @@
+// Package consistency tracks whether a request has written to the primary
+// database, so later reads in the same request skip the replicas and see
+// their own writes despite replication lag.
+package consistency
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+)
+
+type trackerKey struct{}
+
+type tracker struct {
+	wrote atomic.Bool
+}
+
+// NewContext returns a context that records writes made with it. Contexts
+// derived from it share the record.
+func NewContext(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(trackerKey{}).(*tracker); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, trackerKey{}, &tracker{})
+}
+
+// MarkWrite records that a write was sent to the primary. It does nothing
+// outside a context from NewContext.
+func MarkWrite(ctx context.Context) {
+	if t, ok := ctx.Value(trackerKey{}).(*tracker); ok {
+		t.wrote.Store(true)
+	}
+}
+
+// Wrote reports whether MarkWrite has been called in this request.
+func Wrote(ctx context.Context) bool {
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	return ok && t.wrote.Load()
+}
+
+// Server gives every request its own write record.
+func Server() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			return handler(NewContext(ctx), req)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package consistency
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrote(t *testing.T) {
+	a := assert.New(t)
+	// 没有 NewContext 时不记录
+	MarkWrite(context.Background())
+	a.False(Wrote(context.Background()))
+
+	ctx := NewContext(context.Background())
+	a.False(Wrote(ctx))
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+	MarkWrite(child)
+	a.True(Wrote(ctx))
+	// 已有记录时不会重新开始
+	a.True(Wrote(NewContext(child)))
+
+	var seen bool
+	_, _ = Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		MarkWrite(ctx)
+		seen = Wrote(ctx)
+		return nil, nil
+	})(context.Background(), nil)
+	a.True(seen)
+}
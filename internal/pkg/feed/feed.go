@@ -0,0 +1,322 @@
+// Package feed encodes syndication feeds as Atom 1.0, RSS 2.0 and JSON Feed
+// 1.1.
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+type Format string
+
+const (
+	Atom Format = "atom"
+	RSS  Format = "rss"
+	JSON Format = "json"
+)
+
+// ParseFormat accepts the file extensions used in feed URLs.
+func ParseFormat(ext string) (Format, bool) {
+	switch f := Format(ext); f {
+	case Atom, RSS, JSON:
+		return f, true
+	}
+	return "", false
+}
+
+// mediaType is the content type without parameters, as used in links.
+func (f Format) mediaType() string {
+	switch f {
+	case Atom:
+		return "application/atom+xml"
+	case RSS:
+		return "application/rss+xml"
+	case JSON:
+		return "application/feed+json"
+	}
+	return ""
+}
+
+func (f Format) ContentType() string {
+	return f.mediaType() + "; charset=utf-8"
+}
+
+type Feed struct {
+	// ID is a permanent, unique URI; the feed URL when empty
+	ID          string
+	Title       string
+	Description string
+	// Link is the HTML page of the feed, FeedURL the feed itself
+	Link    string
+	FeedURL string
+	Updated time.Time
+	Items   []*Item
+}
+
+type Item struct {
+	// ID is a permanent, unique URI; the link when empty
+	ID          string
+	Title       string
+	Link        string
+	Summary     string
+	ContentHTML string
+	Author      Author
+	Categories  []string
+	Published   time.Time
+	Updated     time.Time
+}
+
+type Author struct {
+	Name string
+	URL  string
+}
+
+func (f *Feed) Encode(format Format) ([]byte, error) {
+	switch format {
+	case Atom:
+		return encodeXML(f.atom())
+	case RSS:
+		return encodeXML(f.rss())
+	case JSON:
+		return json.MarshalIndent(f.jsonFeed(), "", "  ")
+	}
+	return nil, fmt.Errorf("feed: unknown format %q", format)
+}
+
+func encodeXML(v interface{}) ([]byte, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+type atomFeed struct {
+	XMLName  xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	Updated  string       `xml:"updated"`
+	Links    []atomLink   `xml:"link"`
+	Entries  []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func (f *Feed) atom() *atomFeed {
+	af := &atomFeed{
+		ID:       orDefault(f.ID, f.FeedURL),
+		Title:    f.Title,
+		Subtitle: f.Description,
+		// updated 是必填项
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: Atom.mediaType(), Href: f.FeedURL},
+			{Rel: "alternate", Type: "text/html", Href: f.Link},
+		},
+	}
+	for _, x := range f.Items {
+		e := &atomEntry{
+			ID:        orDefault(x.ID, x.Link),
+			Title:     x.Title,
+			Updated:   atomTime(x.Updated),
+			Published: atomTime(x.Published),
+			Links:     []atomLink{{Rel: "alternate", Type: "text/html", Href: x.Link}},
+		}
+		if e.Updated == "" {
+			e.Updated = e.Published
+		}
+		if x.Author.Name != "" {
+			e.Author = &atomPerson{Name: x.Author.Name, URI: x.Author.URL}
+		}
+		for _, c := range x.Categories {
+			e.Categories = append(e.Categories, atomCategory{Term: c})
+		}
+		if x.Summary != "" {
+			e.Summary = &atomText{Type: "text", Body: x.Summary}
+		}
+		if x.ContentHTML != "" {
+			e.Content = &atomText{Type: "html", Body: x.ContentHTML}
+		}
+		af.Entries = append(af.Entries, e)
+	}
+	return af
+}
+
+type rssFeed struct {
+	XMLName      xml.Name    `xml:"rss"`
+	Version      string      `xml:"version,attr"`
+	AtomNS       string      `xml:"xmlns:atom,attr"`
+	ContentNS    string      `xml:"xmlns:content,attr"`
+	DublinCoreNS string      `xml:"xmlns:dc,attr"`
+	Channel      *rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	AtomLink      atomLink   `xml:"atom:link"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description,omitempty"`
+	Content     *cdata   `xml:"content:encoded,omitempty"`
+}
+
+type cdata struct {
+	Body string `xml:",cdata"`
+}
+
+func rssTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+func (f *Feed) rss() *rssFeed {
+	ch := &rssChannel{
+		Title: f.Title,
+		Link:  f.Link,
+		// RSS 2.0 要求 description，没有时用标题
+		Description:   orDefault(f.Description, f.Title),
+		LastBuildDate: rssTime(f.Updated),
+		AtomLink:      atomLink{Rel: "self", Type: RSS.mediaType(), Href: f.FeedURL},
+	}
+	for _, x := range f.Items {
+		it := &rssItem{
+			Title:       x.Title,
+			Link:        x.Link,
+			GUID:        rssGUID{IsPermaLink: x.ID == "" || x.ID == x.Link, Value: orDefault(x.ID, x.Link)},
+			PubDate:     rssTime(x.Published),
+			Creator:     x.Author.Name,
+			Categories:  x.Categories,
+			Description: x.Summary,
+		}
+		if x.ContentHTML != "" {
+			it.Content = &cdata{Body: x.ContentHTML}
+		}
+		ch.Items = append(ch.Items, it)
+	}
+	return &rssFeed{
+		Version:      "2.0",
+		AtomNS:       "http://www.w3.org/2005/Atom",
+		ContentNS:    "http://purl.org/rss/1.0/modules/content/",
+		DublinCoreNS: "http://purl.org/dc/elements/1.1/",
+		Channel:      ch,
+	}
+}
+
+type jsonFeed struct {
+	Version     string      `json:"version"`
+	Title       string      `json:"title"`
+	HomePageURL string      `json:"home_page_url,omitempty"`
+	FeedURL     string      `json:"feed_url,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Items       []*jsonItem `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonItem struct {
+	ID            string        `json:"id"`
+	URL           string        `json:"url,omitempty"`
+	Title         string        `json:"title,omitempty"`
+	ContentHTML   string        `json:"content_html"`
+	Summary       string        `json:"summary,omitempty"`
+	DatePublished string        `json:"date_published,omitempty"`
+	DateModified  string        `json:"date_modified,omitempty"`
+	Authors       []*jsonAuthor `json:"authors,omitempty"`
+	Tags          []string      `json:"tags,omitempty"`
+}
+
+func (f *Feed) jsonFeed() *jsonFeed {
+	jf := &jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Items:       make([]*jsonItem, 0, len(f.Items)),
+	}
+	for _, x := range f.Items {
+		it := &jsonItem{
+			ID:            orDefault(x.ID, x.Link),
+			URL:           x.Link,
+			Title:         x.Title,
+			ContentHTML:   x.ContentHTML,
+			Summary:       x.Summary,
+			DatePublished: atomTime(x.Published),
+			DateModified:  atomTime(x.Updated),
+			Tags:          x.Categories,
+		}
+		if x.Author.Name != "" {
+			it.Authors = []*jsonAuthor{{Name: x.Author.Name, URL: x.Author.URL}}
+		}
+		jf.Items = append(jf.Items, it)
+	}
+	return jf
+}
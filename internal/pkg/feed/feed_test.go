@@ -0,0 +1,138 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	return &Feed{
+		Title:   "Conduit",
+		Link:    "https://conduit.example/",
+		FeedURL: "https://api.example/feeds/articles.atom",
+		Updated: published.Add(time.Hour),
+		Items: []*Item{{
+			Title:       "Hello <world>",
+			Link:        "https://conduit.example/article/hello",
+			Summary:     "greeting",
+			ContentHTML: "<p>Hi &amp; bye</p>",
+			Author:      Author{Name: "alice", URL: "https://conduit.example/profile/alice"},
+			Categories:  []string{"go", "web"},
+			Published:   published,
+			Updated:     published.Add(time.Hour),
+		}},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	a := assert.New(t)
+	f, ok := ParseFormat("rss")
+	a.True(ok)
+	a.Equal(RSS, f)
+	a.Equal("application/rss+xml; charset=utf-8", f.ContentType())
+	_, ok = ParseFormat("xml")
+	a.False(ok)
+	_, err := testFeed().Encode("xml")
+	a.Error(err)
+}
+
+func TestEncodeAtom(t *testing.T) {
+	a := assert.New(t)
+	b, err := testFeed().Encode(Atom)
+	a.NoError(err)
+	var got struct {
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Links   []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+			Author    string `xml:"author>name"`
+			Content   struct {
+				Type string `xml:"type,attr"`
+				Body string `xml:",chardata"`
+			} `xml:"content"`
+			Categories []struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+		} `xml:"entry"`
+	}
+	a.NoError(xml.Unmarshal(b, &got))
+	a.Equal("https://api.example/feeds/articles.atom", got.ID)
+	a.Equal("2024-05-01T09:00:00Z", got.Updated)
+	a.Equal("self", got.Links[0].Rel)
+	if a.Len(got.Entries, 1) {
+		e := got.Entries[0]
+		a.Equal("https://conduit.example/article/hello", e.ID)
+		a.Equal("Hello <world>", e.Title)
+		a.Equal("2024-05-01T08:00:00Z", e.Published)
+		a.Equal("alice", e.Author)
+		a.Equal("html", e.Content.Type)
+		a.Equal("<p>Hi &amp; bye</p>", e.Content.Body)
+		a.Len(e.Categories, 2)
+	}
+}
+
+func TestEncodeRSS(t *testing.T) {
+	a := assert.New(t)
+	b, err := testFeed().Encode(RSS)
+	a.NoError(err)
+	var got struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Description   string `xml:"description"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				GUID    string   `xml:"guid"`
+				PubDate string   `xml:"pubDate"`
+				Creator string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Content string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+				Tags    []string `xml:"category"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	a.NoError(xml.Unmarshal(b, &got))
+	a.Equal("2.0", got.Version)
+	a.Equal("Conduit", got.Channel.Description)
+	a.Equal("Wed, 01 May 2024 09:00:00 +0000", got.Channel.LastBuildDate)
+	if a.Len(got.Channel.Items, 1) {
+		it := got.Channel.Items[0]
+		a.Equal("https://conduit.example/article/hello", it.GUID)
+		a.Equal("Wed, 01 May 2024 08:00:00 +0000", it.PubDate)
+		a.Equal("alice", it.Creator)
+		a.Equal("<p>Hi &amp; bye</p>", it.Content)
+		a.Equal([]string{"go", "web"}, it.Tags)
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	a := assert.New(t)
+	b, err := testFeed().Encode(JSON)
+	a.NoError(err)
+	var got map[string]interface{}
+	a.NoError(json.Unmarshal(b, &got))
+	a.Equal("https://jsonfeed.org/version/1.1", got["version"])
+	a.Equal("https://api.example/feeds/articles.atom", got["feed_url"])
+	items := got["items"].([]interface{})
+	if a.Len(items, 1) {
+		it := items[0].(map[string]interface{})
+		a.Equal("https://conduit.example/article/hello", it["id"])
+		a.Equal("<p>Hi &amp; bye</p>", it["content_html"])
+		a.Equal("2024-05-01T09:00:00Z", it["date_modified"])
+		a.Equal([]interface{}{map[string]interface{}{"name": "alice", "url": "https://conduit.example/profile/alice"}}, it["authors"])
+	}
+
+	// 空的订阅源也要输出 items 数组
+	b, err = (&Feed{Title: "empty"}).Encode(JSON)
+	a.NoError(err)
+	a.Contains(string(b), `"items": []`)
+}
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// watchInterval is how often Watch re-runs the checks.
+var watchInterval = 5 * time.Second
+
+// GRPCServer implements grpc.health.v1.Health on top of the readiness checks.
+// The empty service name and every name in services report the overall state.
+type GRPCServer struct {
+	healthpb.UnimplementedHealthServer
+
+	checker  *Checker
+	services map[string]struct{}
+}
+
+func NewGRPCServer(c *Checker, services ...string) *GRPCServer {
+	s := &GRPCServer{checker: c, services: map[string]struct{}{"": {}}}
+	for _, name := range services {
+		s.services[name] = struct{}{}
+	}
+	return s
+}
+
+func (s *GRPCServer) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	if _, ok := s.services[service]; !ok {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, status.Error(codes.NotFound, "unknown service")
+	}
+	if s.checker.Ready(ctx).OK() {
+		return healthpb.HealthCheckResponse_SERVING, nil
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING, nil
+}
+
+func (s *GRPCServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, err := s.status(ctx, req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch sends the current status and then every change until the client
+// goes away. Unknown services get SERVICE_UNKNOWN instead of an error, as
+// the protocol asks.
+func (s *GRPCServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		st, _ := s.status(ctx, req.GetService())
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package health runs dependency checks for liveness and readiness probes
+// over HTTP (/healthz, /readyz) and the standard grpc.health.v1 service.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultTimeout = 2 * time.Second
+
+// CheckFunc returns nil when the dependency is usable.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Result of one check.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the readiness response body.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+const (
+	StatusOK           = "ok"
+	StatusError        = "error"
+	StatusUnavailable  = "unavailable"
+	StatusShuttingDown = "shutting_down"
+)
+
+type Checker struct {
+	mu       sync.RWMutex
+	checks   []check
+	timeout  time.Duration
+	stopping atomic.Bool
+	once     sync.Once
+	done     chan struct{}
+}
+
+func NewChecker() *Checker {
+	return &Checker{timeout: defaultTimeout, done: make(chan struct{})}
+}
+
+// Register adds a readiness check. Checks run concurrently, each with its
+// own timeout.
+func (c *Checker) Register(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Shutdown makes readiness fail from now on, so load balancers stop sending
+// new requests while in-flight ones drain.
+func (c *Checker) Shutdown() {
+	c.stopping.Store(true)
+	c.once.Do(func() { close(c.done) })
+}
+
+// Done is closed by Shutdown. Long-lived streams watch it to end before the
+// servers stop, since those would otherwise wait for them.
+func (c *Checker) Done() <-chan struct{} {
+	return c.done
+}
+
+// ShuttingDown reports whether Shutdown has been called.
+func (c *Checker) ShuttingDown() bool {
+	return c.stopping.Load()
+}
+
+// Ready runs every check and reports whether the service can take traffic.
+func (c *Checker) Ready(ctx context.Context) *Report {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	rep := &Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, ch := range checks {
+		wg.Add(1)
+		go func(ch check) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			start := time.Now()
+			err := ch.fn(cctx)
+			res := Result{Status: StatusOK, Duration: time.Since(start).String()}
+			if err != nil {
+				res.Status, res.Error = StatusError, err.Error()
+			}
+			mu.Lock()
+			rep.Checks[ch.name] = res
+			if err != nil {
+				rep.Status = StatusUnavailable
+			}
+			mu.Unlock()
+		}(ch)
+	}
+	wg.Wait()
+	if c.stopping.Load() {
+		rep.Status = StatusShuttingDown
+	}
+	return rep
+}
+
+// OK reports whether rep allows traffic.
+func (rep *Report) OK() bool {
+	return rep.Status == StatusOK
+}
+
+// LivenessHandler answers 200 while the process can serve HTTP at all. It
+// never checks dependencies, so an unavailable database does not get the
+// pod restarted.
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	})
+}
+
+// ReadinessHandler answers 200 when every check passes, 503 otherwise, with
+// per-check details.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rep := c.Ready(r.Context())
+		code := http.StatusOK
+		if !rep.OK() {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, rep)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
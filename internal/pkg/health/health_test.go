@@ -0,0 +1,87 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestReadiness(t *testing.T) {
+	a := assert.New(t)
+	c := NewChecker()
+	var dbErr error
+	c.Register("database", func(ctx context.Context) error { return dbErr })
+	c.Register("redis", func(ctx context.Context) error { return nil })
+
+	get := func(h nethttp.Handler) (int, Report) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/", nil))
+		var rep Report
+		a.NoError(json.Unmarshal(w.Body.Bytes(), &rep))
+		return w.Code, rep
+	}
+
+	code, rep := get(c.ReadinessHandler())
+	a.Equal(200, code)
+	a.Equal(StatusOK, rep.Status)
+	a.Equal(StatusOK, rep.Checks["database"].Status)
+	a.Equal(StatusOK, rep.Checks["redis"].Status)
+
+	dbErr = errors.New("connection refused")
+	code, rep = get(c.ReadinessHandler())
+	a.Equal(503, code)
+	a.Equal(StatusUnavailable, rep.Status)
+	a.Equal(StatusError, rep.Checks["database"].Status)
+	a.Equal("connection refused", rep.Checks["database"].Error)
+	a.Equal(StatusOK, rep.Checks["redis"].Status)
+
+	// 存活探针不检查依赖
+	code, rep = get(c.LivenessHandler())
+	a.Equal(200, code)
+	a.Equal(StatusOK, rep.Status)
+
+	dbErr = nil
+	c.Shutdown()
+	c.Shutdown()
+	a.True(c.ShuttingDown())
+	select {
+	case <-c.Done():
+	default:
+		t.Fatal("Done should be closed after Shutdown")
+	}
+	code, rep = get(c.ReadinessHandler())
+	a.Equal(503, code)
+	a.Equal(StatusShuttingDown, rep.Status)
+	code, _ = get(c.LivenessHandler())
+	a.Equal(200, code)
+}
+
+func TestGRPCHealth(t *testing.T) {
+	a := assert.New(t)
+	c := NewChecker()
+	var dbErr error
+	c.Register("database", func(ctx context.Context) error { return dbErr })
+	s := NewGRPCServer(c, "realworld.v1.RealWorld")
+
+	for _, name := range []string{"", "realworld.v1.RealWorld"} {
+		resp, err := s.Check(context.Background(), &healthpb.HealthCheckRequest{Service: name})
+		a.NoError(err)
+		a.Equal(healthpb.HealthCheckResponse_SERVING, resp.Status)
+	}
+
+	dbErr = errors.New("down")
+	resp, err := s.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	a.NoError(err)
+	a.Equal(healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	_, err = s.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "nope"})
+	a.Equal(codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,75 @@
+// Package httpcache implements the validators of HTTP conditional requests:
+// ETag with If-None-Match and Last-Modified with If-Modified-Since
+// (RFC 9110 section 13).
+package httpcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// ETag returns a weak entity tag of body. It is weak because the same
+// resource may be encoded differently, for example by protojson across
+// builds.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// NotModified reports whether a GET request for a representation with etag
+// and lastModified can be answered with 304 Not Modified. If-None-Match
+// takes precedence over If-Modified-Since; a zero lastModified never
+// matches.
+func NotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Values("If-None-Match"); len(inm) > 0 {
+		return matchETag(strings.Join(inm, ","), etag)
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// Last-Modified 只精确到秒
+	return !lastModified.Truncate(time.Second).After(ims)
+}
+
+// matchETag uses the weak comparison, which If-None-Match requires.
+func matchETag(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// SetETag sets the ETag header of the HTTP reply in ctx, used instead of a
+// hash of the body; it does nothing for other transports.
+func SetETag(ctx context.Context, etag string) {
+	if tr, ok := transport.FromServerContext(ctx); ok && tr.Kind() == transport.KindHTTP {
+		tr.ReplyHeader().Set("ETag", etag)
+	}
+}
+
+// SetLastModified sets the Last-Modified header of the HTTP reply in ctx;
+// it does nothing for other transports.
+func SetLastModified(ctx context.Context, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if tr, ok := transport.FromServerContext(ctx); ok && tr.Kind() == transport.KindHTTP {
+		tr.ReplyHeader().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+	}
+}
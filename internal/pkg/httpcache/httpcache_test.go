@@ -0,0 +1,49 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETag(t *testing.T) {
+	a := assert.New(t)
+	e := ETag([]byte(`{"tags":["go"]}`))
+	a.Regexp(`^W/"[0-9a-f]{32}"$`, e)
+	a.Equal(e, ETag([]byte(`{"tags":["go"]}`)))
+	a.NotEqual(e, ETag([]byte(`{"tags":[]}`)))
+}
+
+func TestNotModified(t *testing.T) {
+	a := assert.New(t)
+	etag := `W/"abc"`
+	modified := time.Date(2024, 5, 1, 9, 0, 0, 500, time.UTC)
+	for _, c := range []struct {
+		header       map[string]string
+		lastModified time.Time
+		want         bool
+	}{
+		{nil, modified, false},
+		{map[string]string{"If-None-Match": `W/"abc"`}, modified, true},
+		// If-None-Match 用弱比较
+		{map[string]string{"If-None-Match": `"abc"`}, modified, true},
+		{map[string]string{"If-None-Match": `"x", W/"abc"`}, modified, true},
+		{map[string]string{"If-None-Match": `*`}, time.Time{}, true},
+		{map[string]string{"If-None-Match": `"x"`}, modified, false},
+		// 有 If-None-Match 时忽略 If-Modified-Since
+		{map[string]string{"If-None-Match": `"x"`, "If-Modified-Since": "Wed, 01 May 2024 10:00:00 GMT"}, modified, false},
+		{map[string]string{"If-Modified-Since": "Wed, 01 May 2024 09:00:00 GMT"}, modified, true},
+		{map[string]string{"If-Modified-Since": "Wed, 01 May 2024 08:59:59 GMT"}, modified, false},
+		{map[string]string{"If-Modified-Since": "Wed, 01 May 2024 09:00:00 GMT"}, time.Time{}, false},
+		{map[string]string{"If-Modified-Since": "yesterday"}, modified, false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k, v := range c.header {
+			r.Header.Set(k, v)
+		}
+		a.Equal(c.want, NotModified(r, etag, c.lastModified), c.header)
+	}
+}
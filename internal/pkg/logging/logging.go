@@ -0,0 +1,105 @@
+// Package logging is the kratos log.Logger used by the whole service: one
+// line per entry in JSON or logfmt-like text, a level that can be changed at
+// runtime, and redaction of passwords, tokens and DSN credentials.
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+var _ log.Logger = (*Logger)(nil)
+
+type Logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	json  bool
+	level atomic.Int32
+}
+
+// NewLogger writes entries at or above level to w. format is FormatJSON
+// (the default) or FormatText.
+func NewLogger(w io.Writer, format string, level log.Level) (*Logger, error) {
+	l := &Logger{w: w}
+	switch format {
+	case "", FormatJSON:
+		l.json = true
+	case FormatText:
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+	l.SetLevel(level)
+	return l, nil
+}
+
+// SetLevel changes the minimum level; safe to call while logging.
+func (l *Logger) SetLevel(level log.Level) {
+	l.level.Store(int32(level))
+}
+
+func (l *Logger) Level() log.Level {
+	return log.Level(l.level.Load())
+}
+
+func (l *Logger) Log(level log.Level, keyvals ...interface{}) error {
+	if level < l.Level() || len(keyvals) == 0 {
+		return nil
+	}
+	if len(keyvals)%2 == 1 {
+		keyvals = append(keyvals, "KEYVALS UNPAIRED")
+	}
+
+	var buf bytes.Buffer
+	if l.json {
+		buf.WriteString(`{"level":`)
+		writeJSON(&buf, level.String())
+		for i := 0; i < len(keyvals); i += 2 {
+			key := fmt.Sprint(keyvals[i])
+			buf.WriteByte(',')
+			writeJSON(&buf, key)
+			buf.WriteByte(':')
+			writeJSON(&buf, redactValue(key, keyvals[i+1]))
+		}
+		buf.WriteByte('}')
+	} else {
+		buf.WriteString(level.String())
+		for i := 0; i < len(keyvals); i += 2 {
+			key := fmt.Sprint(keyvals[i])
+			fmt.Fprintf(&buf, " %s=%v", key, redactValue(key, keyvals[i+1]))
+		}
+	}
+	buf.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.w.Write(buf.Bytes())
+	return err
+}
+
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	switch x := v.(type) {
+	case error:
+		v = x.Error()
+	case time.Duration:
+		v = x.String()
+	case fmt.Stringer:
+		v = x.String()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(b)
+}
@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"context"
+
+	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/requestid"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Per-request fields. They are only filled in when the logger carries the
+// request context, i.e. log.WithContext or (*log.Helper).WithContext.
+
+func RequestID() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		return requestid.FromContext(ctx)
+	}
+}
+
+func UserID() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if id, ok := auth.UserIDFromContext(ctx); ok {
+			return id
+		}
+		return ""
+	}
+}
+
+func Operation() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			return tr.Operation()
+		}
+		return ""
+	}
+}
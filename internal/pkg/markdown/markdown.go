@@ -0,0 +1,227 @@
+package markdown
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	defaultCacheSize = 1024
+
+	// 英文按每分钟 200 词估算，中文按每分钟 400 字估算
+	wordsPerMinute = 200
+	hanPerMinute   = 400
+)
+
+// Heading is a table of contents entry.
+type Heading struct {
+	Level int
+	Text  string
+	ID    string
+}
+
+// Result is the rendered form of a markdown document.
+type Result struct {
+	HTML        string
+	TOC         []Heading
+	ReadingTime uint32
+}
+
+type Option func(*Renderer)
+
+// WithCacheSize sets how many rendered documents are kept in memory.
+func WithCacheSize(n int) Option {
+	return func(r *Renderer) {
+		r.cacheSize = n
+	}
+}
+
+// Renderer converts markdown to sanitized HTML and caches the result
+// by content digest, so every revision of a body is rendered once.
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+
+	mu        sync.Mutex
+	cacheSize int
+	ll        *list.List
+	items     map[string]*list.Element
+	hits      uint64
+	misses    uint64
+}
+
+// CacheStats returns the number of cache hits and misses so far.
+func (r *Renderer) CacheStats() (hits, misses uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.hits, r.misses
+}
+
+type entry struct {
+	key string
+	res *Result
+}
+
+// NewRenderer .
+func NewRenderer(opts ...Option) *Renderer {
+	r := &Renderer{
+		md: goldmark.New(
+			goldmark.WithExtensions(extension.GFM),
+			goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		),
+		policy:    NewPolicy(),
+		cacheSize: defaultCacheSize,
+		ll:        list.New(),
+		items:     make(map[string]*list.Element),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// NewPolicy returns the allowlist applied to rendered HTML: the usual
+// user-generated-content tags plus heading anchors and code language classes.
+func NewPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).
+		OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).
+		OnElements("code")
+	return p
+}
+
+// Render renders body, returning a cached result when the same content
+// has been rendered before.
+func (r *Renderer) Render(body string) (*Result, error) {
+	sum := sha256.Sum256([]byte(body))
+	key := hex.EncodeToString(sum[:])
+	if res, ok := r.get(key); ok {
+		return res, nil
+	}
+
+	src := []byte(body)
+	doc := r.md.Parser().Parse(text.NewReader(src))
+	var buf bytes.Buffer
+	if err := r.md.Renderer().Render(&buf, src, doc); err != nil {
+		return nil, err
+	}
+	res := &Result{
+		HTML:        r.policy.Sanitize(buf.String()),
+		TOC:         tableOfContents(doc, src),
+		ReadingTime: ReadingTime(body),
+	}
+	r.put(key, res)
+	return res, nil
+}
+
+func (r *Renderer) get(key string) (*Result, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.items[key]; ok {
+		r.hits++
+		r.ll.MoveToFront(e)
+		return e.Value.(*entry).res, true
+	}
+	r.misses++
+	return nil, false
+}
+
+// SetCacheSize changes the cache capacity, dropping the least recently used
+// documents when it shrinks. Zero disables the cache.
+func (r *Renderer) SetCacheSize(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cacheSize = n
+	r.evict()
+}
+
+// Flush drops every cached result; the cache size is kept.
+func (r *Renderer) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ll.Init()
+	clear(r.items)
+}
+
+func (r *Renderer) put(key string, res *Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cacheSize <= 0 {
+		return
+	}
+	if e, ok := r.items[key]; ok {
+		r.ll.MoveToFront(e)
+		return
+	}
+	r.items[key] = r.ll.PushFront(&entry{key: key, res: res})
+	r.evict()
+}
+
+func (r *Renderer) evict() {
+	for r.ll.Len() > r.cacheSize && r.ll.Len() > 0 {
+		last := r.ll.Back()
+		r.ll.Remove(last)
+		delete(r.items, last.Value.(*entry).key)
+	}
+}
+
+func tableOfContents(doc ast.Node, src []byte) []Heading {
+	var toc []Heading
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		var id string
+		if v, ok := h.AttributeString("id"); ok {
+			if b, ok := v.([]byte); ok {
+				id = string(b)
+			}
+		}
+		toc = append(toc, Heading{
+			Level: h.Level,
+			Text:  string(h.Text(src)),
+			ID:    id,
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return toc
+}
+
+// ReadingTime estimates the minutes needed to read body.
+func ReadingTime(body string) uint32 {
+	var han, words int
+	for _, f := range strings.Fields(body) {
+		other := false
+		for _, c := range f {
+			if unicode.Is(unicode.Han, c) {
+				han++
+			} else if unicode.IsLetter(c) || unicode.IsDigit(c) {
+				other = true
+			}
+		}
+		if other {
+			words++
+		}
+	}
+	if han == 0 && words == 0 {
+		return 0
+	}
+	m := float64(words)/wordsPerMinute + float64(han)/hanPerMinute
+	return uint32(math.Max(1, math.Ceil(m)))
+}
@@ -0,0 +1,69 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	a := assert.New(t)
+	r := NewRenderer()
+
+	res, err := r.Render("# Hello World\n\n<script>alert(1)</script>\n\n```go\nfmt.Println(1)\n```\n\n[x](javascript:alert(1))\n")
+	a.NoError(err)
+	a.Contains(res.HTML, `<h1 id="hello-world">Hello World</h1>`)
+	a.Contains(res.HTML, `<code class="language-go">`)
+	a.NotContains(res.HTML, "<script>")
+	a.NotContains(res.HTML, "javascript:")
+	a.Equal([]Heading{{Level: 1, Text: "Hello World", ID: "hello-world"}}, res.TOC)
+	a.Equal(uint32(1), res.ReadingTime)
+
+	// 相同内容命中缓存
+	again, err := r.Render("# Hello World\n\n<script>alert(1)</script>\n\n```go\nfmt.Println(1)\n```\n\n[x](javascript:alert(1))\n")
+	a.NoError(err)
+	a.Same(res, again)
+}
+
+func TestRenderCacheEviction(t *testing.T) {
+	a := assert.New(t)
+	r := NewRenderer(WithCacheSize(1))
+
+	first, _ := r.Render("a")
+	_, _ = r.Render("b")
+	again, _ := r.Render("a")
+	a.NotSame(first, again)
+	cached, _ := r.Render("a")
+	a.Same(again, cached)
+
+	hits, misses := r.CacheStats()
+	a.EqualValues(1, hits)
+	a.EqualValues(3, misses)
+
+	r.Flush()
+	flushed, _ := r.Render("a")
+	a.NotSame(cached, flushed)
+}
+
+func TestReadingTime(t *testing.T) {
+	a := assert.New(t)
+	a.Equal(uint32(0), ReadingTime(""))
+	a.Equal(uint32(1), ReadingTime("just a few words"))
+	a.Equal(uint32(2), ReadingTime(strings.Repeat("word ", 300)))
+}
+
+func TestSetCacheSize(t *testing.T) {
+	a := assert.New(t)
+	r := NewRenderer(WithCacheSize(2))
+	_, _ = r.Render("a")
+	b, _ := r.Render("b")
+
+	r.SetCacheSize(1)
+	cached, _ := r.Render("b")
+	a.Same(b, cached)
+	_, _ = r.Render("a")
+	hits, misses := r.CacheStats()
+	a.EqualValues(1, hits)
+	a.EqualValues(3, misses)
+}
@@ -2,11 +2,11 @@ package auth
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
 	"github.com/golang-jwt/jwt/v4"
@@ -14,15 +14,30 @@ import (
 
 var currentUserKey struct{}
 
+var (
+	ErrTokenMissing = errors.Unauthorized("token", "is missing")
+	ErrTokenInvalid = errors.Unauthorized("token", "is invalid")
+)
+
 type CurrentUser struct {
 	UserID uint
 }
 
 func GenerateToken(secret string, userid uint) string {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	return NewToken(secret, userid, 0)
+}
+
+// NewToken is GenerateToken with an exp claim when ttl > 0; JWTAuth rejects
+// expired tokens.
+func NewToken(secret string, userid uint, ttl time.Duration) string {
+	claims := jwt.MapClaims{
 		"userid": userid,
 		"nbf":    time.Date(2015, 10, 10, 12, 0, 0, 0, time.UTC).Unix(),
-	})
+	}
+	if ttl > 0 {
+		claims["exp"] = time.Now().Add(ttl).Unix()
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Sign and get the complete encoded token as a string using the secret
 	tokenString, err := token.SignedString([]byte(secret))
@@ -39,7 +54,7 @@ func JWTAuth(secret string) middleware.Middleware {
 				tokenString := tr.RequestHeader().Get("Authorization")
 				auths := strings.SplitN(tokenString, " ", 2)
 				if len(auths) != 2 || !strings.EqualFold(auths[0], "Token") {
-					return nil, errors.New("jwt token missing")
+					return nil, ErrTokenMissing
 				}
 
 				token, err := jwt.Parse(auths[1], func(token *jwt.Token) (interface{}, error) {
@@ -51,17 +66,19 @@ func JWTAuth(secret string) middleware.Middleware {
 				})
 
 				if err != nil {
-					return nil, err
+					return nil, ErrTokenInvalid.WithCause(err)
 				}
 
-				if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-					// put CurrentUser into ctx
-					if u, ok := claims["userid"]; ok {
-						ctx = WithContext(ctx, &CurrentUser{UserID: uint(u.(float64))})
-					}
-				} else {
-					return nil, errors.New("Token Invalid")
+				claims, ok := token.Claims.(jwt.MapClaims)
+				if !ok || !token.Valid {
+					return nil, ErrTokenInvalid
+				}
+				// put CurrentUser into ctx
+				u, ok := claims["userid"].(float64)
+				if !ok {
+					return nil, ErrTokenInvalid
 				}
+				ctx = WithContext(ctx, &CurrentUser{UserID: uint(u)})
 			}
 			return handler(ctx, req)
 		}
@@ -72,6 +89,16 @@ func FromContext(ctx context.Context) *CurrentUser {
 	return ctx.Value(currentUserKey).(*CurrentUser)
 }
 
+// UserIDFromContext is like FromContext but does not panic on
+// unauthenticated requests.
+func UserIDFromContext(ctx context.Context) (uint, bool) {
+	u, ok := ctx.Value(currentUserKey).(*CurrentUser)
+	if !ok || u == nil {
+		return 0, false
+	}
+	return u.UserID, true
+}
+
 func WithContext(ctx context.Context, user *CurrentUser) context.Context {
 	return context.WithValue(ctx, currentUserKey, user)
 }
@@ -0,0 +1,156 @@
+// Package password hashes passwords with bcrypt or argon2id and enforces a
+// password policy.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+)
+
+// ErrTooLong is returned by bcrypt for passwords over 72 bytes.
+var ErrTooLong = bcrypt.ErrPasswordTooLong
+
+type Argon2Params struct {
+	Time       uint32
+	Memory     uint32 // KiB
+	Threads    uint8
+	KeyLength  uint32
+	SaltLength uint32
+}
+
+// DefaultArgon2Params follows the second recommendation of RFC 9106.
+var DefaultArgon2Params = Argon2Params{
+	Time:       3,
+	Memory:     64 * 1024,
+	Threads:    4,
+	KeyLength:  32,
+	SaltLength: 16,
+}
+
+type Hasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     Argon2Params
+}
+
+// NewHasher returns a hasher for algorithm. Zero values of cost and params
+// select the defaults.
+func NewHasher(algorithm string, bcryptCost int, params Argon2Params) (*Hasher, error) {
+	h := &Hasher{algorithm: algorithm, bcryptCost: bcryptCost, argon2: params}
+	switch algorithm {
+	case "", Bcrypt:
+		h.algorithm = Bcrypt
+		if h.bcryptCost == 0 {
+			h.bcryptCost = bcrypt.DefaultCost
+		}
+		if h.bcryptCost < bcrypt.MinCost || h.bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost %d out of range [%d, %d]", h.bcryptCost, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case Argon2id:
+		d := DefaultArgon2Params
+		if h.argon2.Time == 0 {
+			h.argon2.Time = d.Time
+		}
+		if h.argon2.Memory == 0 {
+			h.argon2.Memory = d.Memory
+		}
+		if h.argon2.Threads == 0 {
+			h.argon2.Threads = d.Threads
+		}
+		if h.argon2.KeyLength == 0 {
+			h.argon2.KeyLength = d.KeyLength
+		}
+		if h.argon2.SaltLength == 0 {
+			h.argon2.SaltLength = d.SaltLength
+		}
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", algorithm)
+	}
+	return h, nil
+}
+
+// Hash hashes pwd with the configured algorithm. argon2id hashes use the PHC
+// string format: $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>.
+func (h *Hasher) Hash(pwd string) (string, error) {
+	if h.algorithm == Bcrypt {
+		b, err := bcrypt.GenerateFromPassword([]byte(pwd), h.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	p := h.argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(pwd), salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// NeedsRehash reports whether hash was made with other settings than h.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	switch h.algorithm {
+	case Bcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.bcryptCost
+	case Argon2id:
+		p, _, _, err := decodeArgon2(hash)
+		if err != nil {
+			return true
+		}
+		return p.Time != h.argon2.Time || p.Memory != h.argon2.Memory || p.Threads != h.argon2.Threads ||
+			p.KeyLength != h.argon2.KeyLength || p.SaltLength != h.argon2.SaltLength
+	}
+	return false
+}
+
+// Verify reports whether pwd matches hash, whichever algorithm made it.
+func Verify(hash, pwd string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		p, salt, key, err := decodeArgon2(hash)
+		if err != nil {
+			return false
+		}
+		other := argon2.IDKey([]byte(pwd), salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+		return subtle.ConstantTimeCompare(key, other) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(pwd)) == nil
+}
+
+var errMalformed = errors.New("malformed argon2id hash")
+
+func decodeArgon2(hash string) (p Argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		return p, nil, nil, errMalformed
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errMalformed
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, errMalformed
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, errMalformed
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, errMalformed
+	}
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,83 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBcrypt(t *testing.T) {
+	a := assert.New(t)
+	h, err := NewHasher(Bcrypt, bcrypt.MinCost, Argon2Params{})
+	a.NoError(err)
+
+	hash, err := h.Hash("correct horse")
+	a.NoError(err)
+	a.True(Verify(hash, "correct horse"))
+	a.False(Verify(hash, "wrong horse"))
+	a.False(h.NeedsRehash(hash))
+
+	h2, _ := NewHasher(Bcrypt, bcrypt.MinCost+1, Argon2Params{})
+	a.True(h2.NeedsRehash(hash))
+
+	_, err = h.Hash(strings.Repeat("x", 73))
+	a.ErrorIs(err, ErrTooLong)
+
+	_, err = NewHasher(Bcrypt, 99, Argon2Params{})
+	a.Error(err)
+	_, err = NewHasher("md5", 0, Argon2Params{})
+	a.Error(err)
+}
+
+func TestArgon2id(t *testing.T) {
+	a := assert.New(t)
+	params := Argon2Params{Time: 1, Memory: 1024, Threads: 1}
+	h, err := NewHasher(Argon2id, 0, params)
+	a.NoError(err)
+
+	hash, err := h.Hash("correct horse")
+	a.NoError(err)
+	a.True(strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	a.True(Verify(hash, "correct horse"))
+	a.False(Verify(hash, "wrong horse"))
+	a.False(h.NeedsRehash(hash))
+
+	params.Time = 2
+	h2, _ := NewHasher(Argon2id, 0, params)
+	a.True(h2.NeedsRehash(hash))
+	// bcrypt 哈希在切换到 argon2id 后需要升级
+	b, _ := bcrypt.GenerateFromPassword([]byte("x"), bcrypt.MinCost)
+	a.True(h.NeedsRehash(string(b)))
+
+	a.False(Verify("$argon2id$v=19$m=1024,t=1,p=1$bad", "correct horse"))
+}
+
+func TestPolicy(t *testing.T) {
+	a := assert.New(t)
+	p, err := NewPolicy(0, 0, "", true)
+	a.NoError(err)
+
+	a.Error(p.Check(""))
+	a.Error(p.Check("short"))
+	a.Error(p.Check(strings.Repeat("x", 65)))
+	a.Error(p.Check("Password123"))
+	a.Error(p.Check("alice-in-wonderland", "alice", "alice@example.com"))
+	a.Error(p.Check("wonderland-smith", "alice", "smith@example.com"))
+	a.NoError(p.Check("correct horse", "alice", "alice@example.com"))
+	// 按字符而不是字节计算长度
+	a.NoError(p.Check("密码密码密码密码"))
+
+	list := filepath.Join(t.TempDir(), "breached.txt")
+	a.NoError(os.WriteFile(list, []byte("correct horse\n"), 0o600))
+	p, err = NewPolicy(8, 64, list, false)
+	a.NoError(err)
+	a.Error(p.Check("Correct Horse"))
+	a.NoError(p.Check("password123"))
+
+	_, err = NewPolicy(10, 8, "", false)
+	a.Error(err)
+}
@@ -0,0 +1,93 @@
+package password
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed breached.txt
+var builtinBreached []byte
+
+// PolicyError explains why a password was rejected.
+type PolicyError struct {
+	Reason string
+}
+
+func (e *PolicyError) Error() string { return e.Reason }
+
+type Policy struct {
+	minLength     int
+	maxLength     int
+	rejectSimilar bool
+	breached      map[string]struct{}
+}
+
+// NewPolicy loads the breached password list from breachedList, or uses the
+// built-in list when it is empty. Zero lengths select 8 and 64.
+func NewPolicy(minLength, maxLength int, breachedList string, rejectSimilar bool) (*Policy, error) {
+	p := &Policy{minLength: minLength, maxLength: maxLength, rejectSimilar: rejectSimilar}
+	if p.minLength <= 0 {
+		p.minLength = 8
+	}
+	if p.maxLength <= 0 {
+		p.maxLength = 64
+	}
+	if p.maxLength < p.minLength {
+		return nil, fmt.Errorf("password max_length %d is less than min_length %d", p.maxLength, p.minLength)
+	}
+	var r io.Reader = bytes.NewReader(builtinBreached)
+	if breachedList != "" {
+		f, err := os.Open(breachedList)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	p.breached = make(map[string]struct{})
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			p.breached[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Check returns a *PolicyError if pwd is rejected. identities are the
+// username and email of the account, used by the similarity check.
+func (p *Policy) Check(pwd string, identities ...string) error {
+	n := utf8.RuneCountInString(pwd)
+	if n < p.minLength {
+		return &PolicyError{fmt.Sprintf("is too short (minimum is %d characters)", p.minLength)}
+	}
+	if n > p.maxLength {
+		return &PolicyError{fmt.Sprintf("is too long (maximum is %d characters)", p.maxLength)}
+	}
+	lower := strings.ToLower(pwd)
+	if _, ok := p.breached[lower]; ok {
+		return &PolicyError{"has appeared in a data breach, choose another one"}
+	}
+	if p.rejectSimilar {
+		for _, id := range identities {
+			// 邮箱只比较 @ 前的部分
+			if i := strings.IndexByte(id, '@'); i >= 0 {
+				id = id[:i]
+			}
+			id = strings.ToLower(id)
+			if len(id) >= 3 && (strings.Contains(lower, id) || strings.Contains(id, lower)) {
+				return &PolicyError{"is too similar to your username or email"}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Package pubsub delivers messages published on a topic to every current
+// subscriber of that topic, in process or across instances through Redis.
+// Delivery is best effort: a subscriber that cannot keep up loses messages
+// instead of slowing down the publisher.
+package pubsub
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// DefaultBuffer is the number of messages queued per subscription.
+const DefaultBuffer = 64
+
+var droppedCounter, _ = otel.Meter("realworld_demo/internal/pkg/pubsub").Int64Counter("realworld_pubsub_dropped",
+	metric.WithDescription("Messages dropped because the subscriber's buffer was full."))
+
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+type Broker interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe starts receiving messages of the given topics. The ctx only
+	// bounds the subscription request; close the Subscription when done.
+	Subscribe(ctx context.Context, topics ...string) (Subscription, error)
+}
+
+type Subscription interface {
+	// C is closed after Close.
+	C() <-chan Message
+	Close() error
+}
+
+// MemoryBroker is a Broker for a single instance.
+type MemoryBroker struct {
+	buffer int
+
+	mu     sync.RWMutex
+	topics map[string]map[*memorySub]struct{}
+}
+
+func NewMemoryBroker(buffer int) *MemoryBroker {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &MemoryBroker{buffer: buffer, topics: map[string]map[*memorySub]struct{}{}}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, payload []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.topics[topic] {
+		s.deliver(Message{Topic: topic, Payload: payload})
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, topics ...string) (Subscription, error) {
+	s := &memorySub{b: b, topics: topics, c: make(chan Message, b.buffer)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, t := range topics {
+		subs, ok := b.topics[t]
+		if !ok {
+			subs = map[*memorySub]struct{}{}
+			b.topics[t] = subs
+		}
+		subs[s] = struct{}{}
+	}
+	return s, nil
+}
+
+type memorySub struct {
+	b      *MemoryBroker
+	topics []string
+	c      chan Message
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (s *memorySub) deliver(m Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.c <- m:
+	default:
+		droppedCounter.Add(context.Background(), 1)
+	}
+}
+
+func (s *memorySub) C() <-chan Message { return s.c }
+
+func (s *memorySub) Close() error {
+	s.b.mu.Lock()
+	for _, t := range s.topics {
+		delete(s.b.topics[t], s)
+		if len(s.b.topics[t]) == 0 {
+			delete(s.b.topics, t)
+		}
+	}
+	s.b.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.c)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func receive(t *testing.T, s Subscription) (Message, bool) {
+	t.Helper()
+	select {
+	case m, ok := <-s.C():
+		return m, ok
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return Message{}, false
+	}
+}
+
+func testBroker(t *testing.T, b Broker) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	s1, err := b.Subscribe(ctx, "a", "b")
+	a.NoError(err)
+	s2, err := b.Subscribe(ctx, "b")
+	a.NoError(err)
+
+	a.NoError(b.Publish(ctx, "a", []byte("1")))
+	a.NoError(b.Publish(ctx, "b", []byte("2")))
+	a.NoError(b.Publish(ctx, "c", []byte("3")))
+
+	m, _ := receive(t, s1)
+	a.Equal(Message{Topic: "a", Payload: []byte("1")}, m)
+	m, _ = receive(t, s1)
+	a.Equal(Message{Topic: "b", Payload: []byte("2")}, m)
+	m, _ = receive(t, s2)
+	a.Equal(Message{Topic: "b", Payload: []byte("2")}, m)
+
+	a.NoError(s1.Close())
+	_, ok := receive(t, s1)
+	a.False(ok)
+	a.NoError(b.Publish(ctx, "b", []byte("4")))
+	m, _ = receive(t, s2)
+	a.Equal("4", string(m.Payload))
+	a.NoError(s2.Close())
+}
+
+func TestMemoryBroker(t *testing.T) {
+	testBroker(t, NewMemoryBroker(0))
+}
+
+func TestMemoryBrokerSlowSubscriber(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	b := NewMemoryBroker(2)
+	s, _ := b.Subscribe(ctx, "a")
+
+	// 缓冲满了之后丢弃，不阻塞发布者
+	for i := 0; i < 5; i++ {
+		a.NoError(b.Publish(ctx, "a", []byte{byte('0' + i)}))
+	}
+	m, _ := receive(t, s)
+	a.Equal("0", string(m.Payload))
+	m, _ = receive(t, s)
+	a.Equal("1", string(m.Payload))
+
+	a.NoError(s.Close())
+	a.Empty(b.topics)
+	// 关闭后发布不会 panic
+	a.NoError(b.Publish(ctx, "a", []byte("x")))
+}
+
+func TestRedisBroker(t *testing.T) {
+	mr := miniredis.RunT(t)
+	testBroker(t, NewRedisBroker(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "test:", 0))
+}
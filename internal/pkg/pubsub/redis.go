@@ -0,0 +1,68 @@
+package pubsub
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBroker is a Broker shared by all instances through Redis pub/sub.
+// go-redis resubscribes after a reconnect; messages published in between
+// are lost.
+type RedisBroker struct {
+	rdb    redis.UniversalClient
+	prefix string
+	buffer int
+}
+
+func NewRedisBroker(rdb redis.UniversalClient, prefix string, buffer int) *RedisBroker {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &RedisBroker{rdb: rdb, prefix: prefix, buffer: buffer}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, topic string, payload []byte) error {
+	return b.rdb.Publish(ctx, b.prefix+topic, payload).Err()
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context, topics ...string) (Subscription, error) {
+	channels := make([]string, len(topics))
+	for i, t := range topics {
+		channels[i] = b.prefix + t
+	}
+	ps := b.rdb.Subscribe(ctx, channels...)
+	// 等待订阅确认，确保返回之后发布的消息都能收到
+	if len(channels) > 0 {
+		if _, err := ps.Receive(ctx); err != nil {
+			_ = ps.Close()
+			return nil, err
+		}
+	}
+	s := &redisSub{ps: ps, c: make(chan Message, b.buffer)}
+	go s.forward(b.prefix)
+	return s, nil
+}
+
+type redisSub struct {
+	ps *redis.PubSub
+	c  chan Message
+}
+
+// forward 不阻塞 go-redis 的接收循环，订阅者跟不上时丢弃消息
+func (s *redisSub) forward(prefix string) {
+	defer close(s.c)
+	for m := range s.ps.Channel() {
+		select {
+		case s.c <- Message{Topic: m.Channel[len(prefix):], Payload: []byte(m.Payload)}:
+		default:
+			droppedCounter.Add(context.Background(), 1)
+		}
+	}
+}
+
+func (s *redisSub) C() <-chan Message { return s.c }
+
+func (s *redisSub) Close() error {
+	return s.ps.Close()
+}
@@ -0,0 +1,279 @@
+// Package ratelimit throttles requests per client IP and per account with
+// token buckets, and temporarily locks an account for the client IP that
+// failed repeatedly.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	KeyIP      = "ip"
+	KeyAccount = "account"
+)
+
+var (
+	ErrLimitExceeded = errors.New(429, "rate_limit", "too many requests, retry later")
+	ErrAccountLocked = errors.New(429, "account", "temporarily locked after too many failed attempts")
+)
+
+// Rule limits one operation, keyed by client IP or by account.
+type Rule struct {
+	Operation string
+	Key       string
+	Limit     Limit
+}
+
+// Lockout locks an account for Duration once MaxFailures unauthorized
+// responses from the same client IP happen within Window. Other IPs can
+// still use the account, so failing on purpose cannot lock its owner out.
+type Lockout struct {
+	Operations  []string
+	MaxFailures int
+	Window      time.Duration
+	Duration    time.Duration
+}
+
+// AccountFunc extracts the account (e.g. email) a request acts on.
+type AccountFunc func(req interface{}) string
+
+type Option func(*Limiter)
+
+func WithRules(rules []Rule) Option {
+	return func(l *Limiter) { l.rules = rules }
+}
+
+func WithLockout(lo Lockout) Option {
+	return func(l *Limiter) { l.lockout = lo }
+}
+
+func WithAccount(f AccountFunc) Option {
+	return func(l *Limiter) { l.account = f }
+}
+
+// WithTrustProxyHeaders takes the client IP from X-Real-IP or X-Forwarded-For.
+// Only enable it behind a proxy that sets these headers.
+func WithTrustProxyHeaders(trust bool) Option {
+	return func(l *Limiter) { l.trustProxy = trust }
+}
+
+func WithLogger(logger log.Logger) Option {
+	return func(l *Limiter) { l.log = log.NewHelper(logger) }
+}
+
+type Limiter struct {
+	store Store
+
+	mu      sync.RWMutex
+	rules   []Rule
+	lockout Lockout
+
+	account    AccountFunc
+	trustProxy bool
+	log        *log.Helper
+}
+
+func New(store Store, opts ...Option) *Limiter {
+	l := &Limiter{
+		store:   store,
+		account: func(interface{}) string { return "" },
+		log:     log.NewHelper(log.DefaultLogger),
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// Update replaces the rules and lockout policy, e.g. after a config reload.
+// Buckets and failure counters already in the store are kept.
+func (l *Limiter) Update(rules []Rule, lockout Lockout) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rules, l.lockout = rules, lockout
+}
+
+func (l *Limiter) policy() ([]Rule, Lockout) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.rules, l.lockout
+}
+
+// Server returns the middleware. Store failures are logged and the request
+// is let through, so an unavailable Redis does not take the API down.
+func (l *Limiter) Server() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			op := tr.Operation()
+			account := strings.ToLower(strings.TrimSpace(l.account(req)))
+			rules, lockout := l.policy()
+
+			lockable := account != "" && lockout.MaxFailures > 0 && contains(lockout.Operations, op)
+			// 按账号和来源 IP 计数和锁定，否则知道邮箱的人就能从任意 IP 锁住账号
+			source := account + "@" + clientIP(ctx, tr, l.trustProxy)
+			lockKey, failKey := "lock:"+source, "fail:"+source
+			if lockable {
+				ttl, err := l.store.LockTTL(ctx, lockKey)
+				if err != nil {
+					l.log.WithContext(ctx).Errorf("ratelimit: %v", err)
+				} else if ttl > 0 {
+					setRetryAfter(tr, ttl)
+					return nil, ErrAccountLocked
+				}
+			}
+
+			if err := l.allow(ctx, tr, rules, op, account); err != nil {
+				return nil, err
+			}
+
+			reply, err := handler(ctx, req)
+			if lockable {
+				l.record(ctx, lockout, lockKey, failKey, err)
+			}
+			return reply, err
+		}
+	}
+}
+
+// allow takes a token from every bucket matching op and reports the
+// tightest one in the RateLimit headers.
+func (l *Limiter) allow(ctx context.Context, tr transport.Transporter, rules []Rule, op, account string) error {
+	var (
+		tightest *Result
+		limit    Limit
+	)
+	for _, r := range rules {
+		if r.Operation != op {
+			continue
+		}
+		var id string
+		switch r.Key {
+		case KeyIP:
+			id = clientIP(ctx, tr, l.trustProxy)
+		case KeyAccount:
+			id = account
+		}
+		if id == "" {
+			continue
+		}
+		res, err := l.store.Allow(ctx, op+":"+r.Key+":"+id, r.Limit)
+		if err != nil {
+			l.log.WithContext(ctx).Errorf("ratelimit: %v", err)
+			continue
+		}
+		if !res.Allowed {
+			setHeaders(tr, r.Limit, res)
+			setRetryAfter(tr, res.RetryAfter)
+			return ErrLimitExceeded
+		}
+		if tightest == nil || res.Remaining < tightest.Remaining {
+			tightest, limit = &res, r.Limit
+		}
+	}
+	if tightest != nil {
+		setHeaders(tr, limit, *tightest)
+	}
+	return nil
+}
+
+// record counts unauthorized responses and locks the account at the limit.
+// A successful request clears the failures.
+func (l *Limiter) record(ctx context.Context, lockout Lockout, lockKey, failKey string, err error) {
+	switch {
+	case err == nil:
+		if err := l.store.Reset(ctx, failKey); err != nil {
+			l.log.WithContext(ctx).Errorf("ratelimit: %v", err)
+		}
+	case errors.IsUnauthorized(err):
+		n, err := l.store.Incr(ctx, failKey, lockout.Window)
+		if err != nil {
+			l.log.WithContext(ctx).Errorf("ratelimit: %v", err)
+			return
+		}
+		if n < int64(lockout.MaxFailures) {
+			return
+		}
+		l.log.WithContext(ctx).Warnf("ratelimit: account locked for %s after %d failures", lockout.Duration, n)
+		if err := l.store.Lock(ctx, lockKey, lockout.Duration); err != nil {
+			l.log.WithContext(ctx).Errorf("ratelimit: %v", err)
+			return
+		}
+		if err := l.store.Reset(ctx, failKey); err != nil {
+			l.log.WithContext(ctx).Errorf("ratelimit: %v", err)
+		}
+	}
+}
+
+// setHeaders writes the IETF draft RateLimit-* headers.
+func setHeaders(tr transport.Transporter, l Limit, res Result) {
+	h := tr.ReplyHeader()
+	h.Set("RateLimit-Limit", strconv.Itoa(l.Burst))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+}
+
+func setRetryAfter(tr transport.Transporter, d time.Duration) {
+	tr.ReplyHeader().Set("Retry-After", strconv.Itoa(ceilSeconds(d)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	s := int(math.Ceil(d.Seconds()))
+	if s < 1 {
+		return 1
+	}
+	return s
+}
+
+func clientIP(ctx context.Context, tr transport.Transporter, trustProxy bool) string {
+	if ht, ok := tr.(http.Transporter); ok {
+		r := ht.Request()
+		if trustProxy {
+			if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+				return ip
+			}
+			// 最右侧的地址由受信任的代理追加，左侧的可能被客户端伪造
+			if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+				parts := strings.Split(xff, ",")
+				return strings.TrimSpace(parts[len(parts)-1])
+			}
+		}
+		return hostOnly(r.RemoteAddr)
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return hostOnly(p.Addr.String())
+	}
+	return ""
+}
+
+func hostOnly(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
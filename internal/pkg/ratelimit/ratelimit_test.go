@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"realworld_demo/internal/pkg/transporttest"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/peer"
+)
+
+func call(l *Limiter, op, account string, err error) (*transporttest.Transport, error) {
+	return callFrom(l, "192.0.2.1", op, account, err)
+}
+
+// callFrom 模拟来自 ip 的 gRPC 请求
+func callFrom(l *Limiter, ip, op, account string, err error) (*transporttest.Transport, error) {
+	tr := transporttest.New(transport.KindGRPC, op)
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 1234}})
+	ctx = transport.NewServerContext(ctx, tr)
+	_, err = l.Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, err
+	})(ctx, account)
+	return tr, err
+}
+
+func TestMemoryStoreAllow(t *testing.T) {
+	a := assert.New(t)
+	s := NewMemoryStore()
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	l := Limit{Rate: 1, Burst: 2}
+	ctx := context.Background()
+
+	res, _ := s.Allow(ctx, "k", l)
+	a.True(res.Allowed)
+	a.Equal(1, res.Remaining)
+	res, _ = s.Allow(ctx, "k", l)
+	a.True(res.Allowed)
+	res, _ = s.Allow(ctx, "k", l)
+	a.False(res.Allowed)
+	a.Equal(time.Second, res.RetryAfter)
+
+	now = now.Add(time.Second)
+	res, _ = s.Allow(ctx, "k", l)
+	a.True(res.Allowed)
+	a.Equal(0, res.Remaining)
+}
+
+func TestMiddleware(t *testing.T) {
+	a := assert.New(t)
+	l := New(NewMemoryStore(),
+		WithRules([]Rule{{Operation: "/login", Key: KeyAccount, Limit: Limit{Rate: 0.001, Burst: 2}}}),
+		WithAccount(func(req interface{}) string { return req.(string) }),
+	)
+
+	tr, err := call(l, "/login", "a@example.com", nil)
+	a.NoError(err)
+	a.Equal("2", tr.Reply.Get("RateLimit-Limit"))
+	a.Equal("1", tr.Reply.Get("RateLimit-Remaining"))
+
+	_, err = call(l, "/login", "A@example.com ", nil)
+	a.NoError(err)
+	tr, err = call(l, "/login", "a@example.com", nil)
+	a.ErrorIs(err, ErrLimitExceeded)
+	a.NotEmpty(tr.Reply.Get("Retry-After"))
+
+	// 其他账号和不受限的接口不受影响
+	_, err = call(l, "/login", "b@example.com", nil)
+	a.NoError(err)
+	tr, err = call(l, "/other", "a@example.com", nil)
+	a.NoError(err)
+	a.Empty(tr.Reply.Get("RateLimit-Limit"))
+}
+
+func TestLockout(t *testing.T) {
+	a := assert.New(t)
+	l := New(NewMemoryStore(),
+		WithLockout(Lockout{Operations: []string{"/login"}, MaxFailures: 3, Window: time.Minute, Duration: time.Minute}),
+		WithAccount(func(req interface{}) string { return req.(string) }),
+	)
+	denied := errors.Unauthorized("user", "wrong password")
+
+	_, err := call(l, "/login", "a", denied)
+	a.Equal(denied, err)
+	_, err = call(l, "/login", "a", denied)
+	a.Equal(denied, err)
+	// 成功登录会清零失败次数
+	_, err = call(l, "/login", "a", nil)
+	a.NoError(err)
+
+	for i := 0; i < 3; i++ {
+		_, err = call(l, "/login", "a", denied)
+		a.Equal(denied, err)
+	}
+	tr, err := call(l, "/login", "a", nil)
+	a.ErrorIs(err, ErrAccountLocked)
+	a.Equal("60", tr.Reply.Get("Retry-After"))
+
+	_, err = call(l, "/login", "b", nil)
+	a.NoError(err)
+	// 只锁定失败的来源，账号的主人从其他 IP 仍然可以登录
+	_, err = callFrom(l, "198.51.100.7", "/login", "a", nil)
+	a.NoError(err)
+}
+
+func TestRedisStore(t *testing.T) {
+	a := assert.New(t)
+	mr := miniredis.RunT(t)
+	s := NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+	l := Limit{Rate: 0.5, Burst: 2}
+
+	res, err := s.Allow(ctx, "k", l)
+	a.NoError(err)
+	a.True(res.Allowed)
+	a.Equal(1, res.Remaining)
+	res, _ = s.Allow(ctx, "k", l)
+	a.True(res.Allowed)
+	res, _ = s.Allow(ctx, "k", l)
+	a.False(res.Allowed)
+	a.InDelta(2*time.Second, res.RetryAfter, float64(100*time.Millisecond))
+
+	n, err := s.Incr(ctx, "fail", time.Minute)
+	a.NoError(err)
+	a.EqualValues(1, n)
+	n, _ = s.Incr(ctx, "fail", time.Minute)
+	a.EqualValues(2, n)
+	a.Equal(time.Minute, mr.TTL("ratelimit:fail"))
+
+	ttl, err := s.LockTTL(ctx, "lock")
+	a.NoError(err)
+	a.Zero(ttl)
+	a.NoError(s.Lock(ctx, "lock", time.Minute))
+	ttl, _ = s.LockTTL(ctx, "lock")
+	a.Equal(time.Minute, ttl)
+	a.NoError(s.Reset(ctx, "lock", "fail"))
+	ttl, _ = s.LockTTL(ctx, "lock")
+	a.Zero(ttl)
+}
+
+func TestUpdate(t *testing.T) {
+	a := assert.New(t)
+	l := New(NewMemoryStore(),
+		WithRules([]Rule{{Operation: "/login", Key: KeyAccount, Limit: Limit{Rate: 0.001, Burst: 1}}}),
+		WithAccount(func(req interface{}) string { return req.(string) }),
+	)
+	_, err := call(l, "/login", "a", nil)
+	a.NoError(err)
+	_, err = call(l, "/login", "a", nil)
+	a.ErrorIs(err, ErrLimitExceeded)
+
+	l.Update([]Rule{{Operation: "/register", Key: KeyAccount, Limit: Limit{Rate: 0.001, Burst: 1}}},
+		Lockout{Operations: []string{"/login"}, MaxFailures: 1, Window: time.Minute, Duration: time.Minute})
+	_, err = call(l, "/login", "a", errors.Unauthorized("user", "wrong password"))
+	a.True(errors.IsUnauthorized(err))
+	_, err = call(l, "/login", "a", nil)
+	a.ErrorIs(err, ErrAccountLocked)
+	tr, err := call(l, "/register", "b", nil)
+	a.NoError(err)
+	a.Equal("1", tr.Reply.Get("RateLimit-Limit"))
+}
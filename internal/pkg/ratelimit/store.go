@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket: Burst tokens at most, refilled at Rate per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result of taking one token from a bucket.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long to wait for the next token when not allowed.
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again.
+	ResetAfter time.Duration
+}
+
+// Store keeps buckets, failure counters and locks. MemoryStore serves a
+// single instance; RedisStore shares state between instances.
+type Store interface {
+	// Allow takes one token from the bucket at key.
+	Allow(ctx context.Context, key string, l Limit) (Result, error)
+	// Incr increments the counter at key. The counter expires ttl after
+	// its first increment.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Lock sets key for ttl; LockTTL returns what is left of it, or 0.
+	Lock(ctx context.Context, key string, ttl time.Duration) error
+	LockTTL(ctx context.Context, key string) (time.Duration, error)
+	// Reset deletes counters and locks.
+	Reset(ctx context.Context, keys ...string) error
+}
+
+// take refills a bucket holding tokens since elapsed and takes one token.
+func take(tokens float64, elapsed time.Duration, l Limit) (float64, Result) {
+	burst := float64(l.Burst)
+	tokens = math.Min(burst, tokens+elapsed.Seconds()*l.Rate)
+	var res Result
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / l.Rate)
+	}
+	res.Remaining = int(tokens)
+	res.ResetAfter = seconds((burst - tokens) / l.Rate)
+	return tokens, res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full is when the bucket refills completely and can be forgotten.
+	full time.Time
+}
+
+type entry struct {
+	n       int64
+	expires time.Time
+}
+
+// MemoryStore is a Store for a single instance.
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	entries  map[string]*entry
+	lastScan time.Time
+	now      func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		entries: make(map[string]*entry),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, l Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.gc(now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		s.buckets[key] = b
+	}
+	var res Result
+	b.tokens, res = take(b.tokens, now.Sub(b.last), l)
+	b.last = now
+	b.full = now.Add(res.ResetAfter)
+	return res, nil
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	e, ok := s.entries[key]
+	if !ok || !now.Before(e.expires) {
+		e = &entry{expires: now.Add(ttl)}
+		s.entries[key] = e
+	}
+	e.n++
+	return e.n, nil
+}
+
+func (s *MemoryStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = &entry{n: 1, expires: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	if ttl := e.expires.Sub(s.now()); ttl > 0 {
+		return ttl, nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		delete(s.entries, k)
+		delete(s.buckets, k)
+	}
+	return nil
+}
+
+// gc 每分钟清理一次已回满的桶和过期的计数
+func (s *MemoryStore) gc(now time.Time) {
+	if now.Sub(s.lastScan) < time.Minute {
+		return
+	}
+	s.lastScan = now
+	for k, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, k)
+		}
+	}
+	for k, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Package requestid gives every request an id that ties client-visible
+// errors to server logs. The id is read from or generated for the
+// X-Request-ID header (x-request-id metadata on gRPC), stored in the
+// context, echoed in the reply and forwarded on outgoing calls.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Header carries the id over HTTP and, lower-cased, as gRPC metadata.
+const Header = "X-Request-ID"
+
+// maxLen bounds ids accepted from clients.
+const maxLen = 128
+
+type requestIDKey struct{}
+
+// New returns a random 32 character hex id.
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the request id, or "" outside a request.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Valid reports whether a client supplied id can be used as is. Anything
+// else is replaced, so ids are safe to put in logs and headers.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Server takes the id from the request header when it is valid, generates
+// one otherwise, and sets it on the reply header.
+func Server() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			id := FromContext(ctx)
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				if id == "" {
+					id = tr.RequestHeader().Get(Header)
+				}
+				if !Valid(id) {
+					id = New()
+				}
+				tr.ReplyHeader().Set(Header, id)
+			} else if id == "" {
+				id = New()
+			}
+			return handler(NewContext(ctx, id), req)
+		}
+	}
+}
+
+// Client forwards the id of the current request to downstream services.
+func Client() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if id := FromContext(ctx); id != "" {
+				if tr, ok := transport.FromClientContext(ctx); ok {
+					tr.RequestHeader().Set(Header, id)
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package requestid
+
+import (
+	"context"
+	"testing"
+
+	"realworld_demo/internal/pkg/transporttest"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(in string) (seen string, tr *transporttest.Transport) {
+	tr = transporttest.New(transport.KindHTTP, "/test")
+	if in != "" {
+		tr.Request.Set(Header, in)
+	}
+	ctx := transport.NewServerContext(context.Background(), tr)
+	_, _ = Server()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		seen = FromContext(ctx)
+		return nil, nil
+	})(ctx, nil)
+	return seen, tr
+}
+
+func TestServer(t *testing.T) {
+	a := assert.New(t)
+
+	id, tr := serve("abc-123")
+	a.Equal("abc-123", id)
+	a.Equal("abc-123", tr.Reply.Get(Header))
+
+	id, tr = serve("")
+	a.Len(id, 32)
+	a.Equal(id, tr.Reply.Get(Header))
+
+	// 不合法的 id 被替换，不会写入日志和响应头
+	id, _ = serve("bad id\n")
+	a.Len(id, 32)
+	id, _ = serve(string(make([]byte, maxLen+1)))
+	a.Len(id, 32)
+}
+
+func TestClient(t *testing.T) {
+	a := assert.New(t)
+	tr := transporttest.New(transport.KindHTTP, "/test")
+	ctx := transport.NewClientContext(NewContext(context.Background(), "abc"), tr)
+	_, err := Client()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})(ctx, nil)
+	a.NoError(err)
+	a.Equal("abc", tr.Request.Get(Header))
+}
@@ -0,0 +1,66 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// writeTimeout bounds every write, so a client that stopped reading is
+// dropped instead of holding the stream open.
+const writeTimeout = 10 * time.Second
+
+// SSE writes a text/event-stream response.
+type SSE struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// NewSSE sends the response headers and tells the client to reconnect
+// after retry when the stream ends.
+func NewSSE(w http.ResponseWriter, retry time.Duration) (*SSE, error) {
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-store")
+	// 关闭 nginx 等反向代理的缓冲
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	s := &SSE{w: w, rc: http.NewResponseController(w)}
+	if err := s.write([]byte("retry: " + strconv.FormatInt(retry.Milliseconds(), 10) + "\n\n")); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Event sends one event; data may span several lines.
+func (s *SSE) Event(event string, data []byte) error {
+	var b bytes.Buffer
+	if event != "" {
+		b.WriteString("event: " + event + "\n")
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		b.WriteString("data: ")
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return s.write(b.Bytes())
+}
+
+// Ping sends a comment, which keeps proxies from closing an idle stream and
+// detects clients that went away.
+func (s *SSE) Ping() error {
+	return s.write([]byte(": ping\n\n"))
+}
+
+func (s *SSE) write(b []byte) error {
+	if err := s.rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := s.w.Write(b); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
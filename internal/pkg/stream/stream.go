@@ -0,0 +1,52 @@
+// Package stream supports HTTP responses that stay open, such as
+// Server-Sent Events and WebSocket, on a server that bounds every request
+// with a timeout.
+package stream
+
+import (
+	"context"
+	"net/http"
+)
+
+type connKey struct{}
+
+type conn struct {
+	ctx  context.Context
+	done <-chan struct{}
+}
+
+// Filter remembers the request context from before the server applies its
+// request timeout. Streams opened with Detach end when done is closed, e.g.
+// when the server starts shutting down. Install it as an HTTP filter.
+func Filter(done <-chan struct{}) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, connKey{}, &conn{ctx: ctx, done: done})))
+		})
+	}
+}
+
+// Detach returns a context with the values of ctx that is not bound by the
+// request timeout. It is cancelled when the client goes away, when the done
+// channel given to Filter is closed, or by the returned cancel. Without
+// Filter it is just a child of ctx.
+func Detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	c, ok := ctx.Value(connKey{}).(*conn)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(c.ctx, cancel)
+	go func() {
+		select {
+		case <-c.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
@@ -0,0 +1,67 @@
+package stream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// serve 模拟 kratos：Filter 之后再给请求加上超时
+func serve(done chan struct{}, h func(ctx context.Context)) (cancel context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/api/stream", nil).WithContext(ctx)
+	Filter(done)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tctx, tcancel := context.WithTimeout(r.Context(), time.Millisecond)
+		defer tcancel()
+		h(tctx)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	return cancel
+}
+
+func TestDetach(t *testing.T) {
+	a := assert.New(t)
+	done := make(chan struct{})
+	var sctx context.Context
+	var scancel context.CancelFunc
+	disconnect := serve(done, func(ctx context.Context) {
+		sctx, scancel = Detach(ctx)
+		<-ctx.Done()
+	})
+	defer scancel()
+	// 请求超时之后仍然可用
+	a.NoError(sctx.Err())
+
+	disconnect()
+	select {
+	case <-sctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("detached context should end when the client goes away")
+	}
+
+	disconnect = serve(done, func(ctx context.Context) {
+		sctx, scancel = Detach(ctx)
+	})
+	defer disconnect()
+	close(done)
+	select {
+	case <-sctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("detached context should end on shutdown")
+	}
+}
+
+func TestSSE(t *testing.T) {
+	a := assert.New(t)
+	w := httptest.NewRecorder()
+	s, err := NewSSE(w, 3*time.Second)
+	a.NoError(err)
+	a.NoError(s.Event("comment", []byte("{\"a\":1}\nx")))
+	a.NoError(s.Ping())
+	a.Equal("text/event-stream", w.Header().Get("Content-Type"))
+	a.True(w.Flushed)
+	a.Equal("retry: 3000\n\nevent: comment\ndata: {\"a\":1}\ndata: x\n\n: ping\n\n", w.Body.String())
+}
@@ -0,0 +1,43 @@
+// Package transporttest provides a kratos transport for testing middleware
+// without a server.
+package transporttest
+
+import (
+	nethttp "net/http"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// Header implements transport.Header on top of http.Header.
+type Header nethttp.Header
+
+func (h Header) Get(key string) string      { return nethttp.Header(h).Get(key) }
+func (h Header) Set(key, value string)      { nethttp.Header(h).Set(key, value) }
+func (h Header) Add(key, value string)      { nethttp.Header(h).Add(key, value) }
+func (h Header) Values(key string) []string { return nethttp.Header(h).Values(key) }
+func (h Header) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Transport is a server transport with in-memory headers.
+type Transport struct {
+	TransportKind transport.Kind
+	Op            string
+	Request       Header
+	Reply         Header
+}
+
+// New returns a transport of kind for operation op with empty headers.
+func New(kind transport.Kind, op string) *Transport {
+	return &Transport{TransportKind: kind, Op: op, Request: Header{}, Reply: Header{}}
+}
+
+func (t *Transport) Kind() transport.Kind            { return t.TransportKind }
+func (t *Transport) Endpoint() string                { return "" }
+func (t *Transport) Operation() string               { return t.Op }
+func (t *Transport) RequestHeader() transport.Header { return t.Request }
+func (t *Transport) ReplyHeader() transport.Header   { return t.Reply }
@@ -0,0 +1,58 @@
+package server
+
+import (
+	nethttp "net/http"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const defaultAdminAddr = "127.0.0.1:8001"
+
+// AdminServer is the internal HTTP listener for operational endpoints. It
+// has no authentication and must only be reachable from the internal
+// network, e.g. by the Prometheus scraper and the readiness probe.
+type AdminServer struct {
+	*http.Server
+}
+
+// NewAdminServer new an internal HTTP server on server.admin.addr.
+func NewAdminServer(c *conf.Server, hc *health.Checker, rld *conf.Reloader) *AdminServer {
+	addr := c.GetAdmin().GetAddr()
+	if addr == "" {
+		addr = defaultAdminAddr
+	}
+	opts := []http.ServerOption{http.Address(addr)}
+	if n := c.GetAdmin().GetNetwork(); n != "" {
+		opts = append(opts, http.Network(n))
+	}
+	srv := http.NewServer(opts...)
+	srv.Handle("/metrics", promhttp.Handler())
+	// 就绪报告包含依赖检查的错误信息，不在公开端口上提供
+	srv.Handle("/readyz", hc.ReadinessHandler())
+	srv.Handle("/debug/config", configHandler(rld))
+	return &AdminServer{Server: srv}
+}
+
+// configHandler shows the effective configuration with secrets masked.
+func configHandler(rld *conf.Reloader) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		bc := rld.Current()
+		if bc == nil {
+			nethttp.NotFound(w, r)
+			return
+		}
+		b, err := protojson.MarshalOptions{Multiline: true, UseProtoNames: true}.Marshal(bc.Redacted())
+		if err != nil {
+			nethttp.Error(w, err.Error(), nethttp.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(b)
+	})
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	"realworld_demo/internal/service"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessOnlyOnAdminServer(t *testing.T) {
+	a := assert.New(t)
+	srv := newTestServer(t, service.NewRealWorldService(nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger))
+
+	// 公开端口只有不含细节的存活探针
+	a.Equal(200, get(srv, "/healthz").Code)
+	a.Equal(404, get(srv, "/readyz").Code)
+
+	hc := health.NewChecker()
+	hc.Register("db", func(context.Context) error { return errors.New("dial tcp 10.0.0.5:3306: connect: connection refused") })
+	w := get(NewAdminServer(&conf.Server{}, hc, nil), "/readyz")
+	a.Equal(503, w.Code)
+	a.Contains(w.Body.String(), "10.0.0.5")
+}
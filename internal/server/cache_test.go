@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"realworld_demo/internal/biz"
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/markdown"
+	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/service"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type cacheArticleRepo struct {
+	biz.ArticleRepo
+	tags    []biz.Tag
+	version uint32
+}
+
+func (r *cacheArticleRepo) Get(ctx context.Context, slug string) (*biz.Article, error) {
+	return &biz.Article{
+		ID: 1, Slug: slug, Title: "Hello", Body: "hi", Version: r.version,
+		UpdatedAt: "2024-05-01T09:00:00Z", Author: &biz.Profile{ID: 1},
+	}, nil
+}
+
+func (r *cacheArticleRepo) GetArticle(ctx context.Context, id uint) (*biz.Article, error) {
+	return r.Get(ctx, "hello")
+}
+
+func (r *cacheArticleRepo) Update(ctx context.Context, a *biz.Article) (*biz.Article, error) {
+	if a.Version != 0 && a.Version != r.version {
+		return nil, biz.ErrArticleVersionConflict
+	}
+	r.version++
+	return r.GetArticle(ctx, a.ID)
+}
+
+func (r *cacheArticleRepo) List(ctx context.Context, opts ...biz.ListOption) ([]*biz.Article, error) {
+	return nil, nil
+}
+
+func (r *cacheArticleRepo) ListTags(ctx context.Context) ([]biz.Tag, error) {
+	return r.tags, nil
+}
+
+type cacheCommentRepo struct {
+	biz.CommentRepo
+}
+
+func (cacheCommentRepo) List(ctx context.Context, slug string) ([]*biz.Comment, error) {
+	return []*biz.Comment{{ID: 1, Body: "nice", Author: &biz.Profile{Username: "bob"}}}, nil
+}
+
+type cacheNotificationRepo struct {
+	biz.NotificationRepo
+}
+
+func (cacheNotificationRepo) UnreadCount(ctx context.Context, userID uint) (int64, error) {
+	return 3, nil
+}
+
+func TestConditionalGet(t *testing.T) {
+	a := assert.New(t)
+	ar := &cacheArticleRepo{tags: []biz.Tag{"go"}, version: 1}
+	sc := biz.NewSocialUsecase(ar, nil, cacheCommentRepo{}, nil, markdown.NewRenderer(), nil, nil, nil, nil, nil, log.DefaultLogger)
+	nc := biz.NewNotificationUsecase(cacheNotificationRepo{}, log.DefaultLogger)
+	srv := newTestServer(t, service.NewRealWorldService(nil, sc, nil, nc, nil, nil, nil, log.DefaultLogger))
+
+	w := get(srv, "/api/tags")
+	a.Equal(200, w.Code)
+	a.Equal("public, max-age=300", w.Header().Get("Cache-Control"))
+	a.Equal("Accept, Authorization", w.Header().Get("Vary"))
+	etag := w.Header().Get("ETag")
+	a.NotEmpty(etag)
+
+	w = get(srv, "/api/tags", "If-None-Match", etag)
+	a.Equal(304, w.Code)
+	a.Empty(w.Body.Bytes())
+	a.Equal(etag, w.Header().Get("ETag"))
+	a.Empty(w.Header().Get("Content-Type"))
+
+	// 内容变化后 ETag 随之变化
+	ar.tags = append(ar.tags, "web")
+	w = get(srv, "/api/tags", "If-None-Match", etag)
+	a.Equal(200, w.Code)
+	a.NotEqual(etag, w.Header().Get("ETag"))
+	body, _ := io.ReadAll(w.Body)
+	a.JSONEq(`{"tags":["go","web"]}`, string(body))
+
+	// 单篇文章还有 Last-Modified
+	w = get(srv, "/api/article/hello")
+	a.Equal(200, w.Code)
+	a.Equal("public, max-age=60", w.Header().Get("Cache-Control"))
+	a.Equal("Wed, 01 May 2024 09:00:00 GMT", w.Header().Get("Last-Modified"))
+	a.Equal(304, get(srv, "/api/article/hello", "If-Modified-Since", "Wed, 01 May 2024 09:00:00 GMT").Code)
+	a.Equal(200, get(srv, "/api/article/hello", "If-Modified-Since", "Wed, 01 May 2024 08:00:00 GMT").Code)
+	// 单篇文章的 ETag 是版本号，也用于更新时的 If-Match
+	a.Equal(`"1"`, w.Header().Get("ETag"))
+	a.Equal(304, get(srv, "/api/article/hello", "If-None-Match", `"1"`).Code)
+
+	// 评论列表不需要登录
+	w = get(srv, "/api/articles/hello/comments")
+	a.Equal(200, w.Code)
+	a.Equal("public, max-age=30", w.Header().Get("Cache-Control"))
+	body, _ = io.ReadAll(w.Body)
+	a.Contains(string(body), `"body":"nice"`)
+
+	// 登录用户的结果因人而异，只能由浏览器缓存
+	token := "Token " + auth.GenerateToken(conf.NewJWT().Secret, 1)
+	w = get(srv, "/api/article/hello", "Authorization", token)
+	a.Equal(200, w.Code)
+	a.Equal("private, no-cache", w.Header().Get("Cache-Control"))
+
+	// 其他 GET 接口不缓存
+	w = get(srv, "/api/notifications/unread-count", "Authorization", token)
+	a.Equal(200, w.Code)
+	a.Equal("no-store", w.Header().Get("Cache-Control"))
+	a.Empty(w.Header().Get("ETag"))
+}
+
+func TestUpdateArticleConflict(t *testing.T) {
+	a := assert.New(t)
+	ar := &cacheArticleRepo{version: 1}
+	sc := biz.NewSocialUsecase(ar, nil, nil, nil, markdown.NewRenderer(), nil, nil, nil, nil, nil, log.DefaultLogger)
+	srv := newTestServer(t, service.NewRealWorldService(nil, sc, nil, nil, nil, nil, nil, log.DefaultLogger))
+	token := "Token " + auth.GenerateToken(conf.NewJWT().Secret, 1)
+
+	put := func(body string, ifMatch string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(nethttp.MethodPut, "/api/article/hello", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", token)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		var rv map[string]interface{}
+		a.NoError(json.Unmarshal(w.Body.Bytes(), &rv))
+		return w, rv
+	}
+
+	w, body := put(`{"article":{"body":"v2","version":1}}`, "")
+	a.Equal(200, w.Code)
+	a.Equal(`"2"`, w.Header().Get("ETag"))
+	a.Equal(float64(2), body["article"].(map[string]interface{})["version"])
+
+	// 请求体中过期的版本返回 409
+	w, body = put(`{"article":{"body":"v3","version":1}}`, "")
+	a.Equal(409, w.Code)
+	a.Equal(map[string]interface{}{"version": "2"}, body["metadata"])
+
+	// If-Match 优先，不匹配返回 412
+	w, body = put(`{"article":{"body":"v3","version":2}}`, `"1"`)
+	a.Equal(412, w.Code)
+	a.Equal(map[string]interface{}{"version": "2"}, body["metadata"])
+	w, _ = put(`{"article":{"body":"v3"}}`, `W/"2"`)
+	a.Equal(412, w.Code)
+
+	w, _ = put(`{"article":{"body":"v3"}}`, `"2"`)
+	a.Equal(200, w.Code)
+	a.Equal(`"3"`, w.Header().Get("ETag"))
+	w, _ = put(`{"article":{"body":"v4"}}`, "*")
+	a.Equal(200, w.Code)
+	a.Equal(uint32(4), ar.version)
+}
@@ -1,48 +1,136 @@
 package server
 
 import (
-	"log"
+	"fmt"
 	nethttp "net/http"
+	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
 	"github.com/go-kratos/kratos/v2/transport/http"
 
+	v1 "realworld_demo/api/realworld/v1"
 	"realworld_demo/internal/errors"
+	"realworld_demo/internal/pkg/httpcache"
+	"realworld_demo/internal/pkg/requestid"
 )
 
-func errorEncoder(w nethttp.ResponseWriter, r *nethttp.Request, err error) {
-	// 记录错误
-	log.Printf("API错误: %v", err)
+// cacheMaxAge 是可缓存的 GET 接口对匿名请求的 max-age。带 token 的请求结果因人而异
+// （following、favorited），只允许浏览器缓存且每次都要校验；其余 GET 接口不缓存
+var cacheMaxAge = map[string]time.Duration{
+	v1.OperationRealWorldGetArticle:   time.Minute,
+	v1.OperationRealWorldListArticles: 30 * time.Second,
+	v1.OperationRealWorldGetComment:   30 * time.Second, // 文章的评论列表
+	v1.OperationRealWorldGetProfile:   time.Minute,
+	v1.OperationRealWorldGetTags:      5 * time.Minute,
+}
+
+// newResponseEncoder 与 kratos 默认的编码器相同，另外为 GET 请求设置
+// Cache-Control、ETag，条件请求命中时返回 304
+func newResponseEncoder() http.EncodeResponseFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request, v interface{}) error {
+		if v == nil {
+			return nil
+		}
+		if rd, ok := v.(http.Redirector); ok {
+			url, code := rd.Redirect()
+			nethttp.Redirect(w, r, url, code)
+			return nil
+		}
+		codec, _ := http.CodecForRequest(r, "Accept")
+		data, err := codec.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if r.Method == nethttp.MethodGet && cacheHeaders(w, r, data) {
+			// kratos 的 ResponseWriter 在 Write 时才写出状态码，304 没有响应体，直接写到底层
+			if u, ok := w.(interface{ Unwrap() nethttp.ResponseWriter }); ok {
+				w = u.Unwrap()
+			}
+			w.WriteHeader(nethttp.StatusNotModified)
+			return nil
+		}
+		w.Header().Set("Content-Type", "application/"+codec.Name())
+		_, err = w.Write(data)
+		return err
+	}
+}
 
-	// 转换为HTTP错误
-	se := errors.FromError(err)
+// cacheHeaders sets the caching headers of a GET reply with body and
+// reports whether the request is answered with 304 Not Modified.
+func cacheHeaders(w nethttp.ResponseWriter, r *nethttp.Request, body []byte) bool {
+	var operation string
+	if tr, ok := transport.FromServerContext(r.Context()); ok {
+		operation = tr.Operation()
+	}
+	h := w.Header()
+	maxAge, ok := cacheMaxAge[operation]
+	if !ok {
+		h.Set("Cache-Control", "no-store")
+		return false
+	}
+	if r.Header.Get("Authorization") != "" {
+		h.Set("Cache-Control", "private, no-cache")
+	} else {
+		h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge/time.Second)))
+	}
+	h.Add("Vary", "Accept, Authorization")
+	// service 可以用 httpcache.SetETag 设置基于版本的 ETag，否则用内容的哈希；
+	// Last-Modified 由 service 通过 httpcache.SetLastModified 设置
+	etag := h.Get("ETag")
+	if etag == "" {
+		etag = httpcache.ETag(body)
+		h.Set("ETag", etag)
+	}
+	lastModified, _ := nethttp.ParseTime(h.Get("Last-Modified"))
+	return httpcache.NotModified(r, etag, lastModified)
+}
 
-	// 获取编解码器
-	codec, _ := http.CodecForRequest(r, "Accept")
+// newErrorEncoder 把错误写成 {"errors": {...}}。每个请求的错误已由 logging
+// 中间件记录，这里只记录服务端错误
+func newErrorEncoder(logger log.Logger) http.EncodeErrorFunc {
+	helper := log.NewHelper(logger)
+	return func(w nethttp.ResponseWriter, r *nethttp.Request, err error) {
+		// 中间件已设置 X-Request-ID；请求在进入中间件之前失败（如路由、解码错误）时在这里补上
+		id := w.Header().Get(requestid.Header)
+		if id == "" {
+			if id = r.Header.Get(requestid.Header); !requestid.Valid(id) {
+				id = requestid.New()
+			}
+			w.Header().Set(requestid.Header, id)
+		}
+		ctx := requestid.NewContext(r.Context(), id)
 
-	// 打印错误详情
-	log.Printf("HTTP错误: 状态码=%d, 错误=%v", se.Code, se.Errors)
+		// 转换为HTTP错误
+		resp := *errors.FromError(err)
+		se := &resp
+		se.RequestID = id
+		if se.Code >= 500 {
+			helper.WithContext(ctx).Errorw("msg", "request failed", "code", se.Code, "error", err)
+		}
 
-	// 序列化错误
-	body, err := codec.Marshal(se)
-	if err != nil {
-		log.Printf("错误序列化失败: %v", err)
-		w.WriteHeader(500)
-		return
-	}
+		// 获取编解码器
+		codec, _ := http.CodecForRequest(r, "Accept")
 
-	// 设置响应头
-	w.Header().Set("Content-Type", "application/"+codec.Name())
+		// 序列化错误
+		body, err := codec.Marshal(se)
+		if err != nil {
+			helper.WithContext(ctx).Errorw("msg", "marshal error response", "error", err)
+			w.WriteHeader(500)
+			return
+		}
 
-	// 设置状态码
-	if se.Code > 99 && se.Code < 600 {
-		w.WriteHeader(se.Code)
-	} else {
-		w.WriteHeader(500)
-	}
+		// 设置响应头
+		w.Header().Set("Content-Type", "application/"+codec.Name())
 
-	// 写入响应体
-	_, _ = w.Write(body)
+		// 设置状态码
+		if se.Code > 99 && se.Code < 600 {
+			w.WriteHeader(se.Code)
+		} else {
+			w.WriteHeader(500)
+		}
 
-	// 打印响应体
-	log.Printf("错误响应体: %s", string(body))
+		// 写入响应体
+		_, _ = w.Write(body)
+	}
 }
@@ -3,9 +3,16 @@ package server
 import (
 	"encoding/json"
 	"fmt"
-	"realworld_demo/internal/errors"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"realworld_demo/internal/errors"
+	"realworld_demo/internal/pkg/requestid"
+	"realworld_demo/internal/service"
+
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,3 +25,30 @@ func TestHTTPStruct(t *testing.T) {
 	assert.NoError(t, err)
 	fmt.Printf("%s", string(b))
 }
+
+func TestErrorRequestID(t *testing.T) {
+	a := assert.New(t)
+	srv := newTestServer(t, service.NewRealWorldService(nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger))
+
+	// 客户端提供的 id 原样返回
+	req := httptest.NewRequest(nethttp.MethodPost, "/api/users/login", strings.NewReader(`{"user":{}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestid.Header, "client-req-1")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	a.Equal(422, w.Code)
+	a.Equal("client-req-1", w.Header().Get(requestid.Header))
+	var body errors.HTTPError
+	a.NoError(json.Unmarshal(w.Body.Bytes(), &body))
+	a.Equal("client-req-1", body.RequestID)
+	a.Equal([]string{"can't be blank"}, body.Errors["email"])
+
+	// 没有 token 的请求在 JWT 中间件被拒绝，也会生成 id
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/api/user", nil))
+	a.Equal(401, w.Code)
+	id := w.Header().Get(requestid.Header)
+	a.Len(id, 32)
+	a.NoError(json.Unmarshal(w.Body.Bytes(), &body))
+	a.Equal(id, body.RequestID)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"realworld_demo/internal/biz"
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/markdown"
+	"realworld_demo/internal/service"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type feedArticleRepo struct {
+	biz.ArticleRepo
+	opts biz.ListOptions
+}
+
+func (r *feedArticleRepo) List(ctx context.Context, opts ...biz.ListOption) ([]*biz.Article, error) {
+	r.opts = biz.ListOptions{}
+	for _, opt := range opts {
+		opt(&r.opts)
+	}
+	return []*biz.Article{{
+		Slug: "hello", Title: "Hello", Body: "hi", TagList: []string{"node.js"},
+		CreatedAt: "2024-05-01T08:00:00Z", UpdatedAt: "2024-05-01T09:00:00Z",
+		Author: &biz.Profile{Username: "alice"},
+	}}, nil
+}
+
+type feedProfileRepo struct {
+	biz.ProfileRepo
+}
+
+func (feedProfileRepo) GetProfile(ctx context.Context, username string) (*biz.Profile, error) {
+	if username != "alice" {
+		return nil, errors.NotFound("user", "not found by username")
+	}
+	return &biz.Profile{ID: 1, Username: username}, nil
+}
+
+func TestFeeds(t *testing.T) {
+	a := assert.New(t)
+	ar := &feedArticleRepo{}
+	fc := biz.NewFeedUsecase(ar, feedProfileRepo{}, markdown.NewRenderer(), &conf.Data{Feeds: &conf.Feeds{ApiBaseUrl: "https://api.example.com/"}}, log.DefaultLogger)
+	srv := newTestServer(t, service.NewRealWorldService(nil, nil, nil, nil, nil, nil, fc, log.DefaultLogger))
+
+	// 不需要登录
+	w := get(srv, "/feeds/articles.atom")
+	a.Equal(200, w.Code)
+	a.Equal("application/atom+xml; charset=utf-8", w.Header().Get("Content-Type"))
+	a.Equal("public, max-age=300", w.Header().Get("Cache-Control"))
+	a.Empty(w.Header().Get("Last-Modified"))
+	etag := w.Header().Get("ETag")
+	a.NotEmpty(etag)
+
+	a.Equal(304, get(srv, "/feeds/articles.atom", "If-None-Match", etag).Code)
+	// 列表的最新更新时间在删除文章后不变，只按内容判断
+	a.Equal(200, get(srv, "/feeds/articles.atom", "If-Modified-Since", "Wed, 01 May 2024 09:00:00 GMT").Code)
+	// 格式不同，内容和 ETag 都不同
+	w = get(srv, "/feeds/articles.json", "If-None-Match", etag)
+	a.Equal(200, w.Code)
+	a.Equal("application/feed+json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	// 自身链接来自配置，不受请求头影响，避免污染共享缓存
+	w = get(srv, "/feeds/articles.json", "X-Forwarded-Proto", "http", "X-Forwarded-Host", "evil.example")
+	a.Contains(w.Body.String(), `"feed_url": "https://api.example.com/feeds/articles.json"`)
+	req := httptest.NewRequest(nethttp.MethodGet, "/feeds/articles.atom", nil)
+	req.Host = "evil.example"
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	a.NotContains(w.Body.String(), "evil.example")
+	a.Contains(w.Body.String(), `href="https://api.example.com/feeds/articles.atom"`)
+
+	a.Equal(200, get(srv, "/feeds/profiles/alice.rss").Code)
+	a.Equal("alice", ar.opts.Filters["author"])
+	a.Equal(404, get(srv, "/feeds/profiles/nobody.rss").Code)
+
+	a.Equal(200, get(srv, "/feeds/tags/node.js.atom").Code)
+	a.Equal("node.js", ar.opts.Tag)
+	a.Equal(404, get(srv, "/feeds/tags/go.xml").Code)
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	"realworld_demo/internal/pkg/requestid"
+
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/gorilla/handlers"
+)
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "HEAD", "OPTIONS", "DELETE"}
+	defaultCORSHeaders = []string{"X-Requested-With", "Content-Type", "Authorization", requestid.Header, "If-None-Match", "If-Modified-Since", "If-Match"}
+	// 前端需要读取的响应头
+	corsExposedHeaders = []string{requestid.Header, "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "ETag", "Last-Modified"}
+)
+
+const (
+	defaultHSTSMaxAge     = 180 * 24 * time.Hour
+	defaultReferrerPolicy = "strict-origin-when-cross-origin"
+	// 接口本身不返回 HTML，出现的 HTML（如误上传的文件）不允许执行任何内容
+	defaultCSP = "default-src 'none'; img-src 'self'; style-src 'self'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'"
+)
+
+// corsFilter 按 conf.Server.HTTP.CORS 处理跨域请求，未配置时允许任意来源但不带凭据
+func corsFilter(c *conf.Server_HTTP_CORS) (http.FilterFunc, error) {
+	origins := c.GetAllowedOrigins()
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+	for _, o := range origins {
+		if o == "*" && c.GetAllowCredentials() {
+			return nil, fmt.Errorf("cors: allow_credentials requires explicit allowed_origins, not \"*\"")
+		}
+	}
+	methods := c.GetAllowedMethods()
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := c.GetAllowedHeaders()
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	opts := []handlers.CORSOption{
+		handlers.AllowedOrigins(origins),
+		handlers.AllowedMethods(methods),
+		handlers.AllowedHeaders(headers),
+		handlers.ExposedHeaders(corsExposedHeaders),
+	}
+	if c.GetAllowCredentials() {
+		opts = append(opts, handlers.AllowCredentials())
+	}
+	if age := c.GetMaxAge().AsDuration(); age > 0 {
+		opts = append(opts, handlers.MaxAge(int(age/time.Second)))
+	}
+	return handlers.CORS(opts...), nil
+}
+
+// reloadableCORS 让 CORS 配置可以热更新，新的配置在下一个请求时生效
+type reloadableCORS struct {
+	filter atomic.Pointer[http.FilterFunc]
+}
+
+func newReloadableCORS(c *conf.Server_HTTP_CORS) (*reloadableCORS, error) {
+	r := &reloadableCORS{}
+	if err := r.Update(c); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *reloadableCORS) Update(c *conf.Server_HTTP_CORS) error {
+	f, err := corsFilter(c)
+	if err != nil {
+		return err
+	}
+	r.filter.Store(&f)
+	return nil
+}
+
+func (r *reloadableCORS) Filter(next nethttp.Handler) nethttp.Handler {
+	type built struct {
+		filter *http.FilterFunc
+		h      nethttp.Handler
+	}
+	var cur atomic.Pointer[built]
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, req *nethttp.Request) {
+		f := r.filter.Load()
+		b := cur.Load()
+		if b == nil || b.filter != f {
+			b = &built{filter: f, h: (*f)(next)}
+			cur.Store(b)
+		}
+		b.h.ServeHTTP(w, req)
+	})
+}
+
+// drainFilter 在停机排空阶段给响应加上 Connection: close，
+// 让客户端和负载均衡改用新连接，而不是复用即将关闭的长连接
+func drainFilter(hc *health.Checker) http.FilterFunc {
+	return func(next nethttp.Handler) nethttp.Handler {
+		return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			if hc.ShuttingDown() {
+				w.Header().Set("Connection", "close")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// securityHeaders 为所有响应加上安全相关的响应头，CSP 只加在 HTML 响应上
+func securityHeaders(c *conf.Server_HTTP_SecurityHeaders) http.FilterFunc {
+	if c.GetDisabled() {
+		return func(next nethttp.Handler) nethttp.Handler { return next }
+	}
+	var hsts string
+	maxAge := defaultHSTSMaxAge
+	if c.GetHstsMaxAge() != nil {
+		maxAge = c.GetHstsMaxAge().AsDuration()
+	}
+	if maxAge >= 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+		if c.GetHstsIncludeSubdomains() {
+			hsts += "; includeSubDomains"
+		}
+	}
+	referrer := c.GetReferrerPolicy()
+	if referrer == "" {
+		referrer = defaultReferrerPolicy
+	}
+	csp := c.GetContentSecurityPolicy()
+	if csp == "" {
+		csp = defaultCSP
+	}
+	return func(next nethttp.Handler) nethttp.Handler {
+		return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", referrer)
+			if hsts != "" {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(&cspWriter{ResponseWriter: w, csp: csp}, r)
+		})
+	}
+}
+
+// cspWriter 在写出响应头时根据 Content-Type 决定是否加 CSP
+type cspWriter struct {
+	nethttp.ResponseWriter
+	csp         string
+	wroteHeader bool
+}
+
+func (w *cspWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+			w.Header().Set("Content-Security-Policy", w.csp)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cspWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		// 与 net/http 相同，未设置 Content-Type 时按内容推断
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", nethttp.DetectContentType(b))
+		}
+		w.WriteHeader(nethttp.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cspWriter) Flush() {
+	if f, ok := w.ResponseWriter.(nethttp.Flusher); ok {
+		if !w.wroteHeader {
+			w.WriteHeader(nethttp.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Hijack is needed by WebSocket handlers, which type-assert the writer.
+func (w *cspWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nethttp.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *cspWriter) Unwrap() nethttp.ResponseWriter {
+	return w.ResponseWriter
+}
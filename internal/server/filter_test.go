@@ -0,0 +1,84 @@
+package server
+
+import (
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestCORSFilter(t *testing.T) {
+	a := assert.New(t)
+	_, err := corsFilter(&conf.Server_HTTP_CORS{AllowCredentials: true})
+	a.Error(err)
+
+	f, err := corsFilter(&conf.Server_HTTP_CORS{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+	})
+	a.NoError(err)
+	h := f(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {}))
+
+	req := httptest.NewRequest(nethttp.MethodGet, "/api/articles", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	a.Equal("https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	a.Equal("true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+	req.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	a.Empty(w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	a := assert.New(t)
+	body := "{}"
+	h := securityHeaders(&conf.Server_HTTP_SecurityHeaders{
+		HstsMaxAge:            durationpb.New(3600e9),
+		HstsIncludeSubdomains: true,
+	})(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/", nil))
+	a.Equal("nosniff", w.Header().Get("X-Content-Type-Options"))
+	a.Equal("max-age=3600; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+	a.Equal(defaultReferrerPolicy, w.Header().Get("Referrer-Policy"))
+	a.Empty(w.Header().Get("Content-Security-Policy"))
+
+	body = "<html><body>hi</body></html>"
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/", nil))
+	a.Equal(defaultCSP, w.Header().Get("Content-Security-Policy"))
+
+	h = nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {})
+	w = httptest.NewRecorder()
+	securityHeaders(&conf.Server_HTTP_SecurityHeaders{Disabled: true})(h).
+		ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/", nil))
+	a.Empty(w.Header().Get("X-Content-Type-Options"))
+}
+
+func TestDrainFilter(t *testing.T) {
+	a := assert.New(t)
+	hc := health.NewChecker()
+	h := drainFilter(hc)(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/api/articles", nil))
+	a.Empty(w.Header().Get("Connection"))
+
+	// 停机排空期间请求照常处理，但不再保持长连接
+	hc.Shutdown()
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/api/articles", nil))
+	a.Equal(200, w.Code)
+	a.Equal("close", w.Header().Get("Connection"))
+}
@@ -1,22 +1,30 @@
 package server
 
 import (
+	"context"
+
 	v1 "realworld_demo/api/realworld/v1"
 	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	"realworld_demo/internal/pkg/ratelimit"
 	"realworld_demo/internal/service"
 
 	"github.com/go-kratos/kratos/v2/log"
-	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport/grpc"
+	grpcgo "google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 // NewGRPCServer new a gRPC server.
-func NewGRPCServer(c *conf.Server, s *service.RealWorldService, logger log.Logger) *grpc.Server {
+func NewGRPCServer(c *conf.Server, jwtc *conf.JWT, rl *ratelimit.Limiter, hc *health.Checker, s *service.RealWorldService, logger log.Logger) *grpc.Server {
+	// 中间件，与 HTTP 共用；token 从 authorization metadata 读取
+	mw := append([]middleware.Middleware{grpcErrorMapping()}, newMiddleware(jwtc, rl, logger)...)
 	var opts = []grpc.ServerOption{
-		// 中间件
-		grpc.Middleware(
-			recovery.Recovery(),
-		),
+		// 用 readiness 检查代替 kratos 自带的健康服务
+		grpc.CustomHealth(),
+		grpc.Middleware(mw...),
+		grpc.StreamInterceptor(streamServer(hc, mw...)),
 	}
 	if c.Grpc.Network != "" {
 		opts = append(opts, grpc.Network(c.Grpc.Network))
@@ -29,5 +37,38 @@ func NewGRPCServer(c *conf.Server, s *service.RealWorldService, logger log.Logge
 	}
 	srv := grpc.NewServer(opts...)
 	v1.RegisterRealWorldServer(srv, s)
+	healthpb.RegisterHealthServer(srv, health.NewGRPCServer(hc, v1.RealWorld_ServiceDesc.ServiceName))
 	return srv
 }
+
+// streamServer runs the middleware once when a streaming RPC starts; the
+// request message is read by the handler, so the middleware sees nil. The
+// stream ends when the server starts shutting down, since a graceful stop
+// would otherwise wait for it.
+func streamServer(hc *health.Checker, m ...middleware.Middleware) grpcgo.StreamServerInterceptor {
+	return func(srv interface{}, ss grpcgo.ServerStream, info *grpcgo.StreamServerInfo, handler grpcgo.StreamHandler) error {
+		ctx, cancel := context.WithCancel(ss.Context())
+		defer cancel()
+		go func() {
+			select {
+			case <-hc.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		h := middleware.Chain(m...)(func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return nil, handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		})
+		_, err := h(ctx, nil)
+		return err
+	}
+}
+
+// serverStream carries the context built by the middleware, e.g. the
+// current user.
+type serverStream struct {
+	grpcgo.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
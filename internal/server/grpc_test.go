@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	v1 "realworld_demo/api/realworld/v1"
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	"realworld_demo/internal/service"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/stretchr/testify/assert"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCMiddleware(t *testing.T) {
+	a := assert.New(t)
+	c := &conf.Server{Grpc: &conf.Server_GRPC{Addr: "127.0.0.1:0"}}
+	hc := health.NewChecker()
+	rl, cleanup, err := NewRateLimiter(c, &conf.Data{}, hc, nil, log.DefaultLogger)
+	a.NoError(err)
+	defer cleanup()
+	// 请求在到达 service 之前就会被中间件拒绝，所以不需要 usecase
+	s := service.NewRealWorldService(nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger)
+	srv := NewGRPCServer(c, conf.NewJWT(), rl, hc, s, log.DefaultLogger)
+	endpoint, err := srv.Endpoint()
+	a.NoError(err)
+	go srv.Start(context.Background())
+	defer srv.Stop(context.Background())
+
+	conn, err := grpc.DialInsecure(context.Background(), grpc.WithEndpoint(endpoint.Host))
+	a.NoError(err)
+	defer conn.Close()
+	client := v1.NewRealWorldClient(conn)
+
+	_, err = client.GetCurrentUser(context.Background(), &v1.GetCurrentRequest{})
+	a.Equal(codes.Unauthenticated, status.Code(err))
+	a.Equal("token", errors.Reason(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Token bad")
+	_, err = client.GetCurrentUser(ctx, &v1.GetCurrentRequest{})
+	a.Equal(codes.Unauthenticated, status.Code(err))
+
+	var md metadata.MD
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "grpc-req-1")
+	_, err = client.Login(ctx, &v1.LoginRequest{User: &v1.LoginRequest_User{Password: "x"}}, ggrpc.Header(&md))
+	a.Equal(codes.InvalidArgument, status.Code(err))
+	a.Equal("email", errors.Reason(err))
+	a.Equal([]string{"grpc-req-1"}, md.Get("x-request-id"))
+
+	hs, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "realworld.v1.RealWorld"})
+	a.NoError(err)
+	a.Equal(healthpb.HealthCheckResponse_SERVING, hs.GetStatus())
+}
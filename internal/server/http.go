@@ -2,73 +2,71 @@ package server
 
 import (
 	"context"
+	nethttp "net/http"
+	"strings"
+
 	v1 "realworld_demo/api/realworld/v1"
 	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
 	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/ratelimit"
+	"realworld_demo/internal/pkg/stream"
 	"realworld_demo/internal/service"
 
 	"github.com/go-kratos/kratos/v2/log"
-	"github.com/go-kratos/kratos/v2/middleware"
-	"github.com/go-kratos/kratos/v2/middleware/logging"
-	"github.com/go-kratos/kratos/v2/middleware/recovery"
 	"github.com/go-kratos/kratos/v2/middleware/selector"
-	"github.com/go-kratos/kratos/v2/transport"
 	"github.com/go-kratos/kratos/v2/transport/http"
-	"github.com/gorilla/handlers"
 )
 
 func NewSkipRoutersMatcher() selector.MatchFunc {
 	// 跳过权限验证的接口
 	skipRouters := map[string]struct{}{
-		"/realworld.v1.RealWorld/Login":        {},
-		"/realworld.v1.RealWorld/Register":     {},
-		"/realworld.v1.RealWorld/GetArticle":   {},
-		"/realworld.v1.RealWorld/ListArticles": {},
-		"/realworld.v1.RealWorld/GetComments":  {},
-		"/realworld.v1.RealWorld/GetTags":      {},
-		"/realworld.v1.RealWorld/GetProfile":   {},
+		v1.OperationRealWorldLogin:        {},
+		v1.OperationRealWorldRegister:     {},
+		v1.OperationRealWorldGetArticle:   {},
+		v1.OperationRealWorldListArticles: {},
+		v1.OperationRealWorldGetComment:   {}, // 文章的评论列表
+		v1.OperationRealWorldGetTags:      {},
+		v1.OperationRealWorldGetProfile:   {},
+
+		service.OperationRealWorldArticlesFeed: {},
+
+		v1.OperationRealWorldVerifyEmail:          {},
+		v1.OperationRealWorldRequestPasswordReset: {},
+		v1.OperationRealWorldConfirmPasswordReset: {},
+
+		"/grpc.health.v1.Health/Check": {},
+		"/grpc.health.v1.Health/Watch": {},
 	}
 
 	return func(ctx context.Context, operation string) bool {
 		if _, ok := skipRouters[operation]; ok {
 			return false
 		}
+		// 流式接口已用 ticket 认证
+		if _, ok := auth.UserIDFromContext(ctx); ok {
+			return false
+		}
 		return true
 	}
 }
 
 // NewHTTPServer new a HTTP server.
-func NewHTTPServer(c *conf.Server, jwtc *conf.JWT, s *service.RealWorldService, logger log.Logger) *http.Server {
-	// 添加HTTP请求日志中间件
-	httpLogger := log.NewHelper(logger)
-
-	// 日志中间件
-	logMiddleware := func(handler middleware.Handler) middleware.Handler {
-		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
-			if tr, ok := transport.FromServerContext(ctx); ok {
-				httpLogger.Infof("收到HTTP请求: %s %s", tr.Kind(), tr.Operation())
-			}
-			return handler(ctx, req)
-		}
+func NewHTTPServer(c *conf.Server, dc *conf.Data, jwtc *conf.JWT, rl *ratelimit.Limiter, hc *health.Checker, rld *conf.Reloader, s *service.RealWorldService, logger log.Logger) (*http.Server, error) {
+	cors, err := newReloadableCORS(c.Http.GetCors())
+	if err != nil {
+		return nil, err
 	}
-
+	rld.Subscribe(func(bc *conf.Bootstrap) {
+		if err := cors.Update(bc.GetServer().GetHttp().GetCors()); err != nil {
+			log.NewHelper(logger).Errorf("reload cors: %v", err)
+		}
+	})
 	var opts = []http.ServerOption{
-		http.ErrorEncoder(errorEncoder),
-
-		// 添加自定义日志中间件
-		http.Middleware(
-			logMiddleware,
-			recovery.Recovery(),
-			selector.Server(auth.JWTAuth(jwtc.Secret)).Match(NewSkipRoutersMatcher()).Build(),
-			logging.Server(logger),
-		),
-		http.Filter(
-			handlers.CORS(
-				handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization"}),
-				handlers.AllowedMethods([]string{"GET", "POST", "PUT", "HEAD", "OPTIONS", "DELETE"}),
-				handlers.AllowedOrigins([]string{"*"}),
-			),
-		),
+		http.ErrorEncoder(newErrorEncoder(logger)),
+		http.ResponseEncoder(newResponseEncoder()),
+		http.Middleware(newMiddleware(jwtc, rl, logger)...),
+		http.Filter(drainFilter(hc), stream.Filter(hc.Done()), securityHeaders(c.Http.GetSecurityHeaders()), cors.Filter),
 	}
 	if c.Http.Network != "" {
 		opts = append(opts, http.Network(c.Http.Network))
@@ -81,14 +79,35 @@ func NewHTTPServer(c *conf.Server, jwtc *conf.JWT, s *service.RealWorldService,
 	}
 	srv := http.NewServer(opts...)
 
-	// 添加一个简单的调试路由
-	srv.Route("/").GET("/debug/health", func(ctx http.Context) error {
-		return ctx.JSON(200, map[string]interface{}{
-			"status":  "ok",
-			"message": "服务器正常运行",
-		})
-	})
+	// 存活探针；就绪探针在管理端口上
+	srv.Handle("/healthz", hc.LivenessHandler())
+
+	r := srv.Route("/")
+	r.POST("/api/uploads/avatar", s.UploadAvatar)
+	r.POST("/api/uploads/images", s.UploadImage)
+	r.POST("/api/stream/tickets", s.CreateStreamTicket)
+	r.GET("/api/stream", s.StreamEventsSSE)
+	r.GET("/api/stream/ws", s.StreamEventsWebSocket)
+	r.GET("/feeds/articles.{format}", s.ArticlesFeed)
+	r.GET("/feeds/profiles/{username}.{format}", s.ProfileFeed)
+	r.GET("/feeds/tags/{tag}.{format}", s.TagFeed)
+	if sc := dc.GetStorage(); sc.GetDriver() == "" || sc.GetDriver() == "local" {
+		srv.HandlePrefix("/uploads/", uploadsHandler(sc.LocalDir()))
+	}
 
 	v1.RegisterRealWorldHTTPServer(srv, s)
-	return srv
+	return srv, nil
+}
+
+// uploadsHandler serves locally stored uploads without directory listings.
+func uploadsHandler(dir string) nethttp.Handler {
+	fs := nethttp.StripPrefix("/uploads/", nethttp.FileServer(nethttp.Dir(dir)))
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			nethttp.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		fs.ServeHTTP(w, r)
+	})
 }
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	"realworld_demo/internal/service"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	a := assert.New(t)
+	exporter, err := prometheus.New(prometheus.WithoutScopeInfo())
+	a.NoError(err)
+	otel.SetMeterProvider(metricsdk.NewMeterProvider(metricsdk.WithReader(exporter)))
+
+	srv := newTestServer(t, service.NewRealWorldService(nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger))
+
+	// 校验失败的请求不会进入 service
+	req := httptest.NewRequest(nethttp.MethodPost, "/api/users/login", strings.NewReader(`{"user":{}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	a.Equal(422, w.Code)
+
+	// 认证失败的请求也计入指标
+	a.Equal(401, get(srv, "/api/user").Code)
+
+	// 指标只在管理端口上提供
+	a.Equal(404, get(srv, "/metrics").Code)
+
+	w = get(NewAdminServer(&conf.Server{}, health.NewChecker(), nil), "/metrics")
+	a.Equal(200, w.Code)
+	body, _ := io.ReadAll(w.Body)
+	a.Contains(string(body), `server_requests_code_total{code="422",kind="http",operation="/realworld.v1.RealWorld/Login",reason="email"} 1`)
+	a.Contains(string(body), `server_requests_code_total{code="401",kind="http",operation="/realworld.v1.RealWorld/GetCurrentUser",reason="token"} 1`)
+	a.Contains(string(body), `server_requests_seconds_bucket{kind="http",operation="/realworld.v1.RealWorld/Login"`)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/errors"
+	"realworld_demo/internal/pkg/consistency"
+	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/ratelimit"
+	"realworld_demo/internal/pkg/requestid"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/logging"
+	"github.com/go-kratos/kratos/v2/middleware/metrics"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/middleware/selector"
+	"github.com/go-kratos/kratos/v2/middleware/tracing"
+	"go.opentelemetry.io/otel"
+)
+
+// 请求指标，按 kind、operation 以及错误码和 reason 区分
+var (
+	meter = otel.Meter("realworld_demo/internal/server")
+
+	metricRequests, _ = metrics.DefaultRequestsCounter(meter, metrics.DefaultServerRequestsCounterName)
+	metricSeconds, _  = metrics.DefaultSecondsHistogram(meter, "server_requests_seconds")
+)
+
+// newMiddleware 返回 HTTP 和 gRPC 共用的中间件链
+func newMiddleware(jwtc *conf.JWT, rl *ratelimit.Limiter, logger log.Logger) []middleware.Middleware {
+	return []middleware.Middleware{
+		requestid.Server(),
+		// 同一请求内写入后的读取走主库
+		consistency.Server(),
+		// 从 traceparent 继续上游的 trace，日志中的 trace.id/span.id 也由此而来
+		tracing.Server(),
+		recovery.Recovery(),
+		// 在限流和认证之前，429 和 401 也计入指标
+		metrics.Server(
+			metrics.WithSeconds(metricSeconds),
+			metrics.WithRequests(metricRequests),
+		),
+		rl.Server(),
+		selector.Server(auth.JWTAuth(jwtc.Secret)).Match(NewSkipRoutersMatcher()).Build(),
+		logging.Server(logger),
+		validate(),
+	}
+}
+
+type validator interface {
+	Validate() error
+}
+
+// validate 调用请求消息上的 Validate 方法
+func validate() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if v, ok := req.(validator); ok {
+				if err := v.Validate(); err != nil {
+					return nil, err
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// grpcErrorMapping 把领域错误转换为 gRPC status，作用与 HTTP 的 errorEncoder 相同
+func grpcErrorMapping() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			reply, err := handler(ctx, req)
+			if err != nil {
+				return nil, errors.GRPCStatus(err)
+			}
+			return reply, nil
+		}
+	}
+}
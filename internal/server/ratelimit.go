@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	v1 "realworld_demo/api/realworld/v1"
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	"realworld_demo/internal/pkg/ratelimit"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRateLimiter builds the limiter shared by the HTTP and gRPC servers.
+func NewRateLimiter(c *conf.Server, dc *conf.Data, hc *health.Checker, rld *conf.Reloader, logger log.Logger) (*ratelimit.Limiter, func(), error) {
+	rc := c.GetRateLimit()
+	var (
+		store   ratelimit.Store
+		cleanup = func() {}
+	)
+	switch rc.GetStore() {
+	case "", "memory":
+		store = ratelimit.NewMemoryStore()
+	case "redis":
+		rdb := redis.NewClient(&redis.Options{
+			Network:      dc.GetRedis().GetNetwork(),
+			Addr:         dc.GetRedis().GetAddr(),
+			ReadTimeout:  dc.GetRedis().GetReadTimeout().AsDuration(),
+			WriteTimeout: dc.GetRedis().GetWriteTimeout().AsDuration(),
+		})
+		store = ratelimit.NewRedisStore(rdb)
+		hc.Register("redis", func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		})
+		cleanup = func() {
+			if err := rdb.Close(); err != nil {
+				log.NewHelper(logger).Errorf("close redis: %v", err)
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown rate limit store %q", rc.GetStore())
+	}
+
+	rules, lockout, err := limiterPolicy(rc)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	l := ratelimit.New(store,
+		ratelimit.WithRules(rules),
+		ratelimit.WithLockout(lockout),
+		ratelimit.WithAccount(requestAccount),
+		ratelimit.WithTrustProxyHeaders(rc.GetTrustProxyHeaders()),
+		ratelimit.WithLogger(logger),
+	)
+	// 规则和锁定策略随配置热更新，store 的变更需要重启
+	rld.Subscribe(func(bc *conf.Bootstrap) {
+		rules, lockout, err := limiterPolicy(bc.GetServer().GetRateLimit())
+		if err != nil {
+			log.NewHelper(logger).Errorf("reload rate limits: %v", err)
+			return
+		}
+		l.Update(rules, lockout)
+	})
+	return l, cleanup, nil
+}
+
+func limiterPolicy(rc *conf.Server_RateLimit) ([]ratelimit.Rule, ratelimit.Lockout, error) {
+	rules := make([]ratelimit.Rule, 0, len(rc.GetRules()))
+	for _, r := range rc.GetRules() {
+		if r.GetKey() != ratelimit.KeyIP && r.GetKey() != ratelimit.KeyAccount {
+			return nil, ratelimit.Lockout{}, fmt.Errorf("rate limit rule %s: unknown key %q", r.GetOperation(), r.GetKey())
+		}
+		if r.GetRate() <= 0 || r.GetBurst() <= 0 {
+			return nil, ratelimit.Lockout{}, fmt.Errorf("rate limit rule %s: rate and burst must be positive", r.GetOperation())
+		}
+		rules = append(rules, ratelimit.Rule{
+			Operation: r.GetOperation(),
+			Key:       r.GetKey(),
+			Limit:     ratelimit.Limit{Rate: r.GetRate(), Burst: int(r.GetBurst())},
+		})
+	}
+	lc := rc.GetLockout()
+	return rules, ratelimit.Lockout{
+		Operations:  lc.GetOperations(),
+		MaxFailures: int(lc.GetMaxFailures()),
+		Window:      lc.GetWindow().AsDuration(),
+		Duration:    lc.GetDuration().AsDuration(),
+	}, nil
+}
+
+// requestAccount 返回请求针对的账号，用于按账号限流和锁定
+func requestAccount(req interface{}) string {
+	switch r := req.(type) {
+	case *v1.LoginRequest:
+		return r.GetUser().GetEmail()
+	case *v1.RegisterRequest:
+		return r.GetUser().GetEmail()
+	case *v1.PasswordResetRequest:
+		return r.GetUser().GetEmail()
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	"realworld_demo/internal/service"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConfigReload(t *testing.T) {
+	a := assert.New(t)
+	bc := &conf.Bootstrap{
+		Server: &conf.Server{
+			Http: &conf.Server_HTTP{Addr: "0.0.0.0:8000", Cors: &conf.Server_HTTP_CORS{AllowedOrigins: []string{"https://a.example.com"}}},
+			Grpc: &conf.Server_GRPC{Addr: "0.0.0.0:9000"},
+		},
+		Data: &conf.Data{Database: &conf.Data_Database{Dsn: "root:123456@tcp(127.0.0.1:3306)/realworld"}},
+		Jwt:  &conf.JWT{Secret: "0123456789abcdef0123456789abcdef"},
+	}
+	rld := conf.NewReloader(bc)
+	hc := health.NewChecker()
+	rl, cleanup, err := NewRateLimiter(bc.Server, bc.Data, hc, rld, log.DefaultLogger)
+	a.NoError(err)
+	defer cleanup()
+	s := service.NewRealWorldService(nil, nil, nil, nil, nil, nil, nil, log.DefaultLogger)
+	srv, err := NewHTTPServer(bc.Server, bc.Data, bc.Jwt, rl, hc, rld, s, log.DefaultLogger)
+	a.NoError(err)
+
+	origin := func(o string) string {
+		req := httptest.NewRequest(nethttp.MethodGet, "/healthz", nil)
+		req.Header.Set("Origin", o)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+	a.Equal("https://a.example.com", origin("https://a.example.com"))
+	a.Empty(origin("https://b.example.com"))
+
+	next := proto.Clone(bc).(*conf.Bootstrap)
+	next.Server.Http.Cors.AllowedOrigins = []string{"https://b.example.com"}
+	_, err = rld.Apply(next)
+	a.NoError(err)
+	a.Empty(origin("https://a.example.com"))
+	a.Equal("https://b.example.com", origin("https://b.example.com"))
+
+	// 当前配置只在管理端口上提供，其中的密钥被隐藏
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/debug/config", nil))
+	a.Equal(404, w.Code)
+	w = httptest.NewRecorder()
+	NewAdminServer(bc.Server, health.NewChecker(), rld).ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/debug/config", nil))
+	a.Equal(200, w.Code)
+	var got map[string]json.RawMessage
+	a.NoError(json.Unmarshal(w.Body.Bytes(), &got))
+	a.JSONEq(`{"secret":"***"}`, string(got["jwt"]))
+	a.Contains(string(got["server"]), "https://b.example.com")
+	a.NotContains(w.Body.String(), "123456")
+}
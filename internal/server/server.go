@@ -1,8 +1,10 @@
 package server
 
 import (
+	"realworld_demo/internal/pkg/health"
+
 	"github.com/google/wire"
 )
 
 // ProviderSet is server providers. 依赖注入的集合
-var ProviderSet = wire.NewSet(NewGRPCServer, NewHTTPServer)
+var ProviderSet = wire.NewSet(NewGRPCServer, NewHTTPServer, NewAdminServer, NewRateLimiter, health.NewChecker)
@@ -0,0 +1,40 @@
+package server
+
+import (
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	"realworld_demo/internal/service"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer returns the HTTP server for s with the default
+// configuration, signing tokens with conf.NewJWT().
+func newTestServer(t *testing.T, s *service.RealWorldService) *http.Server {
+	t.Helper()
+	c := &conf.Server{Http: &conf.Server_HTTP{}}
+	hc := health.NewChecker()
+	rl, cleanup, err := NewRateLimiter(c, &conf.Data{}, hc, nil, log.DefaultLogger)
+	assert.NoError(t, err)
+	t.Cleanup(cleanup)
+	srv, err := NewHTTPServer(c, &conf.Data{}, conf.NewJWT(), rl, hc, conf.NewReloader(nil), s, log.DefaultLogger)
+	assert.NoError(t, err)
+	return srv
+}
+
+// get serves a GET of path on h; header lists name, value pairs.
+func get(h nethttp.Handler, path string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(nethttp.MethodGet, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "realworld_demo/api/realworld/v1"
+	"realworld_demo/internal/biz"
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/pkg/pubsub"
+	"realworld_demo/internal/service"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc/metadata"
+)
+
+const testEvent = `{"type":"notification","notification":{"ID":7,"Type":"follow","Actor":{"Username":"bob"}}}`
+
+// memTokenRepo 只保存 ticket，够流式接口的测试使用
+type memTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[string]*biz.UserToken
+}
+
+func (r *memTokenRepo) CreateToken(ctx context.Context, t *biz.UserToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[t.Purpose+t.TokenHash] = t
+	return nil
+}
+
+func (r *memTokenRepo) ConsumeToken(ctx context.Context, purpose, hash string) (uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[purpose+hash]
+	if !ok || t.ExpiresAt.Before(time.Now()) {
+		return 0, biz.ErrInvalidToken
+	}
+	delete(r.tokens, purpose+hash)
+	return t.UserID, nil
+}
+
+func (r *memTokenRepo) RevokeTokens(ctx context.Context, userID uint, purpose string) error {
+	return nil
+}
+
+// streamTicket 用 token 换取一次性的 ticket
+func streamTicket(t *testing.T, url, token string) string {
+	req, _ := nethttp.NewRequest("POST", url+"/api/stream/tickets", nil)
+	req.Header.Set("Authorization", "Token "+token)
+	resp, err := nethttp.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	var reply service.StreamTicketReply
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&reply))
+	assert.NotEmpty(t, reply.Ticket)
+	return reply.Ticket
+}
+
+// publishUntil 反复发布，直到 done 关闭；订阅在连接建立之后才生效
+func publishUntil(ps pubsub.Broker, done <-chan struct{}) {
+	for {
+		_ = ps.Publish(context.Background(), "user.1.notifications", []byte(testEvent))
+		select {
+		case <-done:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	a := assert.New(t)
+	c := &conf.Server{
+		Http: &conf.Server_HTTP{Addr: "127.0.0.1:0"},
+		Grpc: &conf.Server_GRPC{Addr: "127.0.0.1:0"},
+	}
+	jwtc := conf.NewJWT()
+	hc := health.NewChecker()
+	rl, cleanup, err := NewRateLimiter(c, &conf.Data{}, hc, conf.NewReloader(nil), log.DefaultLogger)
+	a.NoError(err)
+	defer cleanup()
+	ps := pubsub.NewMemoryBroker(0)
+	s := service.NewRealWorldService(nil, nil, nil, nil, biz.NewStreamUsecase(nil, &memTokenRepo{tokens: map[string]*biz.UserToken{}}, ps, log.DefaultLogger), nil, nil, log.DefaultLogger)
+	hs, err := NewHTTPServer(c, &conf.Data{}, jwtc, rl, hc, conf.NewReloader(nil), s, log.DefaultLogger)
+	a.NoError(err)
+	ts := httptest.NewServer(hs)
+	defer ts.Close()
+	token := auth.GenerateToken(jwtc.Secret, 1)
+
+	resp, err := nethttp.Get(ts.URL + "/api/stream?notifications=true")
+	a.NoError(err)
+	resp.Body.Close()
+	a.Equal(401, resp.StatusCode)
+
+	// 不接受 URL 中的 token
+	resp, err = nethttp.Get(ts.URL + "/api/stream?notifications=true&token=" + token)
+	a.NoError(err)
+	resp.Body.Close()
+	a.Equal(401, resp.StatusCode)
+
+	resp, err = nethttp.Post(ts.URL+"/api/stream/tickets", "", nil)
+	a.NoError(err)
+	resp.Body.Close()
+	a.Equal(401, resp.StatusCode)
+
+	// Server-Sent Events，ticket 放在查询参数里
+	ticket := streamTicket(t, ts.URL, token)
+	resp, err = nethttp.Get(ts.URL + "/api/stream?notifications=true&ticket=" + ticket)
+	a.NoError(err)
+	defer resp.Body.Close()
+	a.Equal(200, resp.StatusCode)
+	a.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+	done := make(chan struct{})
+	go publishUntil(ps, done)
+	r := bufio.NewReader(resp.Body)
+	var data string
+	for data == "" {
+		line, err := r.ReadString('\n')
+		if !a.NoError(err) {
+			break
+		}
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+			data = v
+		}
+	}
+	close(done)
+	a.Contains(data, `"username":"bob"`)
+
+	// ticket 只能使用一次
+	again, err := nethttp.Get(ts.URL + "/api/stream?notifications=true&ticket=" + ticket)
+	a.NoError(err)
+	again.Body.Close()
+	a.Equal(401, again.StatusCode)
+
+	// WebSocket
+	ws, err := websocket.Dial(strings.Replace(ts.URL, "http", "ws", 1)+"/api/stream/ws?notifications=true&ticket="+streamTicket(t, ts.URL, token), "", ts.URL)
+	if a.NoError(err) {
+		done = make(chan struct{})
+		go publishUntil(ps, done)
+		var msg string
+		a.NoError(websocket.Message.Receive(ws, &msg))
+		close(done)
+		a.Contains(msg, `"type":"notification"`)
+		ws.Close()
+	}
+
+	// gRPC server streaming
+	gs := NewGRPCServer(c, jwtc, rl, hc, s, log.DefaultLogger)
+	endpoint, err := gs.Endpoint()
+	a.NoError(err)
+	go gs.Start(context.Background())
+	defer gs.Stop(context.Background())
+	conn, err := grpc.DialInsecure(context.Background(), grpc.WithEndpoint(endpoint.Host))
+	a.NoError(err)
+	defer conn.Close()
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Token "+token)
+	stream, err := v1.NewRealWorldClient(conn).StreamEvents(ctx, &v1.StreamEventsRequest{Notifications: true})
+	a.NoError(err)
+	done = make(chan struct{})
+	go publishUntil(ps, done)
+	e, err := stream.Recv()
+	close(done)
+	if a.NoError(err) {
+		a.Equal("bob", e.GetNotification().GetActor().GetUsername())
+	}
+
+	// 停机时结束所有流
+	hc.Shutdown()
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	for err = nil; err == nil; {
+		_, err = r.ReadString('\n')
+	}
+}
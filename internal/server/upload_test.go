@@ -0,0 +1,76 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"realworld_demo/internal/biz"
+	"realworld_demo/internal/conf"
+	"realworld_demo/internal/pkg/health"
+	auth "realworld_demo/internal/pkg/middleware"
+	"realworld_demo/internal/service"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingReader 记录请求体被读取了多少字节
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestUploadAuthBeforeParsing(t *testing.T) {
+	a := assert.New(t)
+	c := &conf.Server{Http: &conf.Server_HTTP{}}
+	dc := &conf.Data{Storage: &conf.Data_Storage{MaxSize: 16}}
+	jwtc := conf.NewJWT()
+	hc := health.NewChecker()
+	rl, cleanup, err := NewRateLimiter(c, dc, hc, nil, log.DefaultLogger)
+	a.NoError(err)
+	defer cleanup()
+	mc := biz.NewMediaUsecase(nil, dc, log.DefaultLogger)
+	s := service.NewRealWorldService(nil, nil, mc, nil, nil, nil, nil, log.DefaultLogger)
+	srv, err := NewHTTPServer(c, dc, jwtc, rl, hc, conf.NewReloader(nil), s, log.DefaultLogger)
+	a.NoError(err)
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	fw, err := mw.CreateFormFile("file", "big.png")
+	a.NoError(err)
+	_, err = fw.Write(bytes.Repeat([]byte("x"), 2<<20))
+	a.NoError(err)
+	a.NoError(mw.Close())
+
+	post := func(header ...string) (*httptest.ResponseRecorder, *countingReader) {
+		body := &countingReader{r: bytes.NewReader(form.Bytes())}
+		req := httptest.NewRequest(nethttp.MethodPost, "/api/uploads/images", body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w, body
+	}
+
+	// 匿名请求在读取请求体之前就被拒绝
+	w, body := post()
+	a.Equal(401, w.Code)
+	a.Zero(body.n)
+
+	// 登录后按大小限制读取，超出即停止
+	w, body = post("Authorization", "Token "+auth.GenerateToken(jwtc.Secret, 1))
+	a.Equal(413, w.Code)
+	a.Less(body.n, form.Len())
+}
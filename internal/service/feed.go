@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	nethttp "net/http"
+	"time"
+
+	"realworld_demo/internal/biz"
+	"realworld_demo/internal/pkg/feed"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// 订阅源不由 proto 生成，operation 用于限流、指标和跳过认证
+const OperationRealWorldArticlesFeed = "/realworld.v1.RealWorld/ArticlesFeed"
+
+// ArticlesFeed handles GET /feeds/articles.{atom,rss,json}.
+func (s *RealWorldService) ArticlesFeed(ctx http.Context) error {
+	return s.serveFeed(ctx, biz.FeedQuery{})
+}
+
+// ProfileFeed handles GET /feeds/profiles/{username}.{atom,rss,json}.
+func (s *RealWorldService) ProfileFeed(ctx http.Context) error {
+	return s.serveFeed(ctx, biz.FeedQuery{Author: ctx.Vars().Get("username")})
+}
+
+// TagFeed handles GET /feeds/tags/{tag}.{atom,rss,json}.
+func (s *RealWorldService) TagFeed(ctx http.Context) error {
+	return s.serveFeed(ctx, biz.FeedQuery{Tag: ctx.Vars().Get("tag")})
+}
+
+func (s *RealWorldService) serveFeed(ctx http.Context, q biz.FeedQuery) error {
+	format, ok := feed.ParseFormat(ctx.Vars().Get("format"))
+	if !ok {
+		return errors.NotFound("feed", "unknown feed format")
+	}
+	http.SetOperation(ctx, OperationRealWorldArticlesFeed)
+	h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.fc.Feed(ctx, req.(biz.FeedQuery))
+	})
+	out, err := h(ctx, q)
+	if err != nil {
+		return err
+	}
+	f := out.(*feed.Feed)
+	req := ctx.Request()
+	f.FeedURL = s.fc.FeedURL(req.URL.EscapedPath())
+	b, err := f.Encode(format)
+	if err != nil {
+		return err
+	}
+
+	// 内容相同则 ETag 相同，ServeContent 据此处理 If-None-Match。不发送 Last-Modified：
+	// 文章删除或被挤出列表后，最新的更新时间不变，If-Modified-Since 会一直返回 304
+	sum := sha256.Sum256(b)
+	w := ctx.Response()
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.fc.MaxAge().Seconds())))
+	nethttp.ServeContent(w, req, "", time.Time{}, bytes.NewReader(b))
+	return nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	v1 "realworld_demo/api/realworld/v1"
+	"realworld_demo/internal/biz"
+)
+
+func convertNotification(do *biz.Notification) *v1.Notification {
+	n := &v1.Notification{
+		Id:        uint32(do.ID),
+		Type:      do.Type,
+		Read:      do.Read,
+		CreatedAt: timestamppb.New(do.CreatedAt),
+		Actor: &v1.Profile{
+			Username:  do.Actor.Username,
+			Bio:       do.Actor.Bio,
+			Image:     do.Actor.Image,
+			Following: do.Actor.Following,
+		},
+		ArticleSlug:  optionalString(do.ArticleSlug),
+		ArticleTitle: optionalString(do.ArticleTitle),
+	}
+	if do.CommentID != 0 {
+		id := uint32(do.CommentID)
+		n.CommentId = &id
+	}
+	return n
+}
+
+func convertNotificationPreferences(do *biz.NotificationPreferences) *v1.NotificationPreferencesReply {
+	return &v1.NotificationPreferencesReply{
+		Preferences: &v1.NotificationPreferences{
+			Follow:   do.Follow,
+			Favorite: do.Favorite,
+			Comment:  do.Comment,
+		},
+	}
+}
+
+func (s *RealWorldService) ListNotifications(ctx context.Context, req *v1.ListNotificationsRequest) (reply *v1.MultipleNotificationsReply, err error) {
+	rv, unread, err := s.nc.List(ctx, req.Unread, req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+	notifications := make([]*v1.Notification, 0, len(rv))
+	for _, x := range rv {
+		notifications = append(notifications, convertNotification(x))
+	}
+	return &v1.MultipleNotificationsReply{Notifications: notifications, UnreadCount: uint32(unread)}, nil
+}
+
+func (s *RealWorldService) GetUnreadNotificationCount(ctx context.Context, req *v1.GetUnreadNotificationCountRequest) (reply *v1.UnreadNotificationCountReply, err error) {
+	n, err := s.nc.UnreadCount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.UnreadNotificationCountReply{UnreadCount: uint32(n)}, nil
+}
+
+func (s *RealWorldService) MarkNotificationsRead(ctx context.Context, req *v1.MarkNotificationsReadRequest) (reply *v1.UnreadNotificationCountReply, err error) {
+	ids := make([]uint, len(req.Ids))
+	for i, id := range req.Ids {
+		ids[i] = uint(id)
+	}
+	n, err := s.nc.MarkRead(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.UnreadNotificationCountReply{UnreadCount: uint32(n)}, nil
+}
+
+func (s *RealWorldService) MarkAllNotificationsRead(ctx context.Context, req *v1.MarkAllNotificationsReadRequest) (reply *v1.UnreadNotificationCountReply, err error) {
+	if err := s.nc.MarkAllRead(ctx); err != nil {
+		return nil, err
+	}
+	return &v1.UnreadNotificationCountReply{}, nil
+}
+
+func (s *RealWorldService) GetNotificationPreferences(ctx context.Context, req *v1.GetNotificationPreferencesRequest) (reply *v1.NotificationPreferencesReply, err error) {
+	rv, err := s.nc.GetPreferences(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return convertNotificationPreferences(rv), nil
+}
+
+func (s *RealWorldService) UpdateNotificationPreferences(ctx context.Context, req *v1.UpdateNotificationPreferencesRequest) (reply *v1.NotificationPreferencesReply, err error) {
+	p := req.Preferences
+	rv, err := s.nc.UpdatePreferences(ctx, &biz.NotificationPreferencesUpdate{
+		Follow:   p.Follow,
+		Favorite: p.Favorite,
+		Comment:  p.Comment,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertNotificationPreferences(rv), nil
+}
@@ -17,9 +17,14 @@ type RealWorldService struct {
 
 	uc  *biz.UserUsecase
 	sc  *biz.SocialUsecase
+	mc  *biz.MediaUsecase
+	nc  *biz.NotificationUsecase
+	stc *biz.StreamUsecase
+	wc  *biz.WebhookUsecase
+	fc  *biz.FeedUsecase
 	log *log.Helper
 }
 
-func NewRealWorldService(uc *biz.UserUsecase, sc *biz.SocialUsecase, logger log.Logger) *RealWorldService {
-	return &RealWorldService{uc: uc, sc: sc, log: log.NewHelper(logger)}
+func NewRealWorldService(uc *biz.UserUsecase, sc *biz.SocialUsecase, mc *biz.MediaUsecase, nc *biz.NotificationUsecase, stc *biz.StreamUsecase, wc *biz.WebhookUsecase, fc *biz.FeedUsecase, logger log.Logger) *RealWorldService {
+	return &RealWorldService{uc: uc, sc: sc, mc: mc, nc: nc, stc: stc, wc: wc, fc: fc, log: log.NewHelper(logger)}
 }
@@ -2,19 +2,50 @@ package service
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	v1 "realworld_demo/api/realworld/v1"
 	"realworld_demo/internal/biz"
+	"realworld_demo/internal/pkg/httpcache"
+	"realworld_demo/internal/pkg/markdown"
 )
 
+func convertTOC(toc []markdown.Heading) []*v1.TocEntry {
+	rv := make([]*v1.TocEntry, len(toc))
+	for i, x := range toc {
+		rv[i] = &v1.TocEntry{
+			Level: int32(x.Level),
+			Text:  x.Text,
+			Id:    x.ID,
+		}
+	}
+	return rv
+}
+
+// optionalString 空字符串表示字段缺省
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 func convertArticleToSingleArticleReply(do *biz.Article) *v1.SingleArticleReply_Article {
 	return &v1.SingleArticleReply_Article{
 		Title:       do.Title,
 		Description: do.Description,
 		Body:        do.Body,
 		TagList:     do.TagList,
+		BodyHtml:    optionalString(do.BodyHTML),
+		Toc:         convertTOC(do.TOC),
+		ReadingTime: do.ReadingTime,
+		Version:     do.Version,
 	}
 }
 
@@ -35,7 +66,40 @@ func convertArticle(do *biz.Article) *v1.Article {
 			Image:     do.Author.Image,
 			Following: do.Author.Following,
 		},
+		BodyHtml:    optionalString(do.BodyHTML),
+		Toc:         convertTOC(do.TOC),
+		ReadingTime: do.ReadingTime,
+		Version:     do.Version,
+	}
+}
+
+// articleETag is the ETag of GetArticle and the If-Match value of
+// UpdateArticle.
+func articleETag(version uint32) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// ifMatchVersion reads the article version from the If-Match header. It
+// returns 0 without the header or for "*", which any existing article
+// matches.
+func ifMatchVersion(ctx context.Context) (version uint32, ok bool, err error) {
+	tr, found := transport.FromServerContext(ctx)
+	if !found || tr.Kind() != transport.KindHTTP {
+		return 0, false, nil
+	}
+	h := strings.TrimSpace(tr.RequestHeader().Get("If-Match"))
+	if h == "" {
+		return 0, false, nil
 	}
+	if h == "*" {
+		return 0, true, nil
+	}
+	// If-Match 用强比较，弱 ETag 永远不匹配
+	v, perr := strconv.ParseUint(strings.Trim(h, `"`), 10, 32)
+	if perr != nil || v == 0 || h != articleETag(uint32(v)) {
+		return 0, true, errors.New(412, "version", "If-Match must be the ETag of the article")
+	}
+	return uint32(v), true, nil
 }
 
 func convertComment(do *biz.Comment) *v1.Comment {
@@ -105,6 +169,11 @@ func (s *RealWorldService) GetArticle(ctx context.Context, req *v1.GetArticleReq
 	if err != nil {
 		return nil, err
 	}
+	// 回复中只有文章内容，不含收藏数，内容的修改都会更新 updated_at 和版本
+	if t, err := time.Parse(time.RFC3339, rv.UpdatedAt); err == nil {
+		httpcache.SetLastModified(ctx, t)
+	}
+	httpcache.SetETag(ctx, articleETag(rv.Version))
 	return &v1.SingleArticleReply{
 		Article: convertArticleToSingleArticleReply(rv),
 	}, nil
@@ -126,15 +195,30 @@ func (s *RealWorldService) CreateArticle(ctx context.Context, req *v1.CreateArti
 }
 
 func (s *RealWorldService) UpdateArticle(ctx context.Context, req *v1.UpdateArticleRequest) (reply *v1.SingleArticleReply, err error) {
+	// If-Match 优先于请求体中的 version，不匹配时返回 412 而不是 409
+	version, ifMatch, err := ifMatchVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ifMatch {
+		version = req.GetArticle().GetVersion()
+	}
 	rv, err := s.sc.UpdateArticle(ctx, &biz.Article{
-		Title:       req.Article.Title,
-		Description: req.Article.Description,
-		Body:        req.Article.Body,
-		TagList:     req.Article.TagList,
+		Slug:        req.Slug,
+		Title:       req.GetArticle().GetTitle(),
+		Description: req.GetArticle().GetDescription(),
+		Body:        req.GetArticle().GetBody(),
+		TagList:     req.GetArticle().GetTagList(),
+		Version:     version,
 	})
+	if ifMatch && errors.IsConflict(err) {
+		se := errors.FromError(err)
+		return nil, errors.New(412, se.Reason, se.Message).WithMetadata(se.Metadata)
+	}
 	if err != nil {
 		return nil, err
 	}
+	httpcache.SetETag(ctx, articleETag(rv.Version))
 	return &v1.SingleArticleReply{
 		Article: convertArticleToSingleArticleReply(rv),
 	}, nil
@@ -164,7 +248,7 @@ func (s *RealWorldService) AddComment(ctx context.Context, req *v1.AddCommentReq
 	}, nil
 }
 
-func (s *RealWorldService) GetComments(ctx context.Context, req *v1.AddCommentRequest) (reply *v1.MultipleCommentsReply, err error) {
+func (s *RealWorldService) GetComment(ctx context.Context, req *v1.GetCommentRequest) (reply *v1.MultipleCommentsReply, err error) {
 	rv, err := s.sc.ListComments(ctx, req.Slug)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"io"
+	nethttp "net/http"
+	"time"
+
+	v1 "realworld_demo/api/realworld/v1"
+	"realworld_demo/internal/biz"
+	"realworld_demo/internal/pkg/stream"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/encoding/json"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"golang.org/x/net/websocket"
+)
+
+// HTTP 的流式接口不由 proto 生成，operation 与 gRPC 方法相同，共用限流等配置
+const (
+	OperationRealWorldStreamEvents       = "/realworld.v1.RealWorld/StreamEvents"
+	OperationRealWorldCreateStreamTicket = "/realworld.v1.RealWorld/CreateStreamTicket"
+)
+
+const (
+	// 空闲时发送心跳，避免代理断开连接，也能及时发现已断开的客户端
+	streamHeartbeat = 25 * time.Second
+	// EventSource 断开后的重连间隔
+	sseRetry = 3 * time.Second
+	// 单次写入的超时，客户端不再读取时断开
+	wsWriteTimeout = 10 * time.Second
+)
+
+func convertEvent(do *biz.Event) *v1.StreamEvent {
+	e := &v1.StreamEvent{Type: do.Type}
+	switch {
+	case do.Comment != nil:
+		e.Payload = &v1.StreamEvent_Comment{Comment: convertComment(do.Comment)}
+	case do.Article != nil:
+		e.Payload = &v1.StreamEvent_Article{Article: convertArticle(do.Article)}
+	case do.Notification != nil:
+		e.Payload = &v1.StreamEvent_Notification{Notification: convertNotification(do.Notification)}
+	}
+	return e
+}
+
+func streamFilter(req *v1.StreamEventsRequest) biz.StreamFilter {
+	return biz.StreamFilter{
+		ArticleSlug:   req.GetArticle(),
+		Feed:          req.GetFeed(),
+		Notifications: req.GetNotifications(),
+	}
+}
+
+// StreamEvents sends events until the client cancels or the server shuts
+// down.
+func (s *RealWorldService) StreamEvents(req *v1.StreamEventsRequest, ss v1.RealWorld_StreamEventsServer) error {
+	ctx := ss.Context()
+	st, err := s.stc.Subscribe(ctx, streamFilter(req))
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-st.Events():
+			if !ok {
+				return nil
+			}
+			if err := ss.Send(convertEvent(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+type StreamTicketReply struct {
+	Ticket string `json:"ticket"`
+	// 有效期，单位秒
+	ExpiresIn int64 `json:"expiresIn"`
+}
+
+// CreateStreamTicket handles POST /api/stream/tickets. The ticket goes in
+// the ?ticket= query of one SSE or WebSocket request.
+func (s *RealWorldService) CreateStreamTicket(ctx http.Context) error {
+	http.SetOperation(ctx, OperationRealWorldCreateStreamTicket)
+	h := ctx.Middleware(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		ticket, ttl, err := s.stc.IssueTicket(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &StreamTicketReply{Ticket: ticket, ExpiresIn: int64(ttl / time.Second)}, nil
+	})
+	out, err := h(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return ctx.Result(200, out)
+}
+
+// StreamEventsSSE handles GET /api/stream with Server-Sent Events. The
+// query takes the fields of StreamEventsRequest.
+func (s *RealWorldService) StreamEventsSSE(ctx http.Context) error {
+	return s.streamHTTP(ctx, s.serveSSE)
+}
+
+// StreamEventsWebSocket handles GET /api/stream/ws, sending every event as
+// a text message.
+func (s *RealWorldService) StreamEventsWebSocket(ctx http.Context) error {
+	return s.streamHTTP(ctx, s.serveWebSocket)
+}
+
+type streamServeFunc func(ctx context.Context, w nethttp.ResponseWriter, r *nethttp.Request, st *biz.Stream)
+
+func (s *RealWorldService) streamHTTP(ctx http.Context, serve streamServeFunc) error {
+	var in v1.StreamEventsRequest
+	if err := ctx.BindQuery(&in); err != nil {
+		return err
+	}
+	req := ctx.Request()
+	http.SetOperation(ctx, OperationRealWorldStreamEvents)
+	// EventSource 和浏览器的 WebSocket 都不能设置请求头，用一次性的 ?ticket= 认证，
+	// 长期有效的 token 不出现在 URL 和访问日志中
+	var hctx context.Context = ctx
+	if ticket := req.URL.Query().Get("ticket"); ticket != "" && req.Header.Get("Authorization") == "" {
+		var err error
+		if hctx, err = s.stc.RedeemTicket(ctx, ticket); err != nil {
+			return err
+		}
+	}
+	h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.stc.Subscribe(ctx, streamFilter(req.(*v1.StreamEventsRequest)))
+	})
+	out, err := h(hctx, &in)
+	if err != nil {
+		return err
+	}
+	st := out.(*biz.Stream)
+	defer st.Close()
+
+	// 请求超时只约束建立订阅，推送持续到客户端断开或服务停止
+	sctx, cancel := stream.Detach(ctx)
+	defer cancel()
+	serve(sctx, ctx.Response(), req, st)
+	return nil
+}
+
+// 响应头已经写出，之后的错误只能结束连接
+func (s *RealWorldService) serveSSE(ctx context.Context, w nethttp.ResponseWriter, _ *nethttp.Request, st *biz.Stream) {
+	sse, err := stream.NewSSE(w, sseRetry)
+	if err != nil {
+		return
+	}
+	codec := encoding.GetCodec(json.Name)
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if err := sse.Ping(); err != nil {
+				return
+			}
+		case e, ok := <-st.Events():
+			if !ok {
+				return
+			}
+			b, err := codec.Marshal(convertEvent(e))
+			if err != nil {
+				s.log.WithContext(ctx).Errorf("marshal %s event: %v", e.Type, err)
+				continue
+			}
+			if err := sse.Event(e.Type, b); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *RealWorldService) serveWebSocket(ctx context.Context, w nethttp.ResponseWriter, r *nethttp.Request, st *biz.Stream) {
+	codec := encoding.GetCodec(json.Name)
+	websocket.Server{
+		// 连接用 ticket 认证而不是 cookie，不需要校验 Origin
+		Handshake: func(*websocket.Config, *nethttp.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			// 客户端不会发送消息，读取只是为了发现连接关闭
+			go func() {
+				defer cancel()
+				_, _ = io.Copy(io.Discard, ws)
+			}()
+			heartbeat := time.NewTicker(streamHeartbeat)
+			defer heartbeat.Stop()
+			for {
+				var err error
+				select {
+				case <-ctx.Done():
+					return
+				case <-heartbeat.C:
+					_ = ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+					ws.PayloadType = websocket.PingFrame
+					_, err = ws.Write(nil)
+					ws.PayloadType = websocket.TextFrame
+				case e, ok := <-st.Events():
+					if !ok {
+						return
+					}
+					b, merr := codec.Marshal(convertEvent(e))
+					if merr != nil {
+						s.log.WithContext(ctx).Errorf("marshal %s event: %v", e.Type, merr)
+						continue
+					}
+					_ = ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+					err = websocket.Message.Send(ws, string(b))
+				}
+				if err != nil {
+					return
+				}
+			}
+		},
+	}.ServeHTTP(w, r)
+}
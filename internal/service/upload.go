@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"io"
+	nethttp "net/http"
+	"strconv"
+
+	"realworld_demo/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// 上传接口不在 proto 中定义，operation 沿用同样的命名，便于中间件统一处理
+const (
+	OperationRealWorldUploadAvatar = "/realworld.v1.RealWorld/UploadAvatar"
+	OperationRealWorldUploadImage  = "/realworld.v1.RealWorld/UploadImage"
+)
+
+// multipart 表单的额外开销
+const multipartOverhead = 1 << 20
+
+type UploadReply struct {
+	Upload *UploadReply_Upload `json:"upload"`
+}
+
+type UploadReply_Upload struct {
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails,omitempty"`
+}
+
+func convertUpload(do *biz.Upload) *UploadReply {
+	rv := &UploadReply{Upload: &UploadReply_Upload{URL: do.URL}}
+	if len(do.Thumbnails) > 0 {
+		rv.Upload.Thumbnails = make(map[string]string, len(do.Thumbnails))
+		for size, url := range do.Thumbnails {
+			rv.Upload.Thumbnails[strconv.Itoa(size)] = url
+		}
+	}
+	return rv
+}
+
+// UploadAvatar handles POST /api/uploads/avatar with a multipart "file" field.
+func (s *RealWorldService) UploadAvatar(ctx http.Context) error {
+	return s.upload(ctx, OperationRealWorldUploadAvatar, s.mc.UploadAvatar)
+}
+
+// UploadImage handles POST /api/uploads/images with a multipart "file" field.
+func (s *RealWorldService) UploadImage(ctx http.Context) error {
+	return s.upload(ctx, OperationRealWorldUploadImage, s.mc.UploadImage)
+}
+
+func (s *RealWorldService) upload(ctx http.Context, operation string, fn func(context.Context, io.Reader) (*biz.Upload, error)) error {
+	req := ctx.Request()
+	req.Body = nethttp.MaxBytesReader(ctx.Response(), req.Body, s.mc.MaxSize()+multipartOverhead)
+
+	// 表单在中间件之后才解析，未认证或被限流的请求不会读取请求体
+	http.SetOperation(ctx, operation)
+	h := ctx.Middleware(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		f, _, err := req.FormFile("file")
+		if err != nil {
+			var mbe *nethttp.MaxBytesError
+			if errors.As(err, &mbe) {
+				return nil, errors.New(413, "file", "文件过大")
+			}
+			return nil, errors.New(422, "file", "cannot be empty")
+		}
+		defer f.Close()
+		rv, err := fn(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		return convertUpload(rv), nil
+	})
+	out, err := h(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return ctx.Result(200, out)
+}
@@ -2,9 +2,10 @@ package service
 
 import (
 	"context"
-	"fmt"
 	v1 "realworld_demo/api/realworld/v1"
 	"realworld_demo/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/errors"
 )
 
 // Login 方法
@@ -23,20 +24,16 @@ func (s *RealWorldService) Login(ctx context.Context, req *v1.LoginRequest) (rep
 
 // Register 方法
 func (s *RealWorldService) Register(ctx context.Context, req *v1.RegisterRequest) (reply *v1.UserReply, err error) {
-	fmt.Printf("注册用户: email=%s, username=%s\n", req.User.Email, req.User.Username)
 	// 调用业务层注册用户
 	u, err := s.uc.Register(ctx, req.User.Username, req.User.Email, req.User.Password)
 	if err != nil {
-		fmt.Printf("注册失败: %v\n", err)
 		return nil, err
 	}
 	if u == nil {
-		fmt.Println("注册失败: 业务层返回的用户对象为空")
-		return nil, fmt.Errorf("注册失败: 业务层返回的用户对象为空")
+		s.log.WithContext(ctx).Error("注册失败: 业务层返回的用户对象为空")
+		return nil, errors.InternalServer("user", "注册失败: 业务层返回的用户对象为空")
 	}
 
-	fmt.Printf("注册成功: email=%s, username=%s, token=%s\n", u.Email, u.Username, u.Token)
-
 	// 构建响应
 	reply = &v1.UserReply{
 		User: &v1.UserReply_User{
@@ -48,9 +45,6 @@ func (s *RealWorldService) Register(ctx context.Context, req *v1.RegisterRequest
 		},
 	}
 
-	// 打印响应内容
-	fmt.Printf("响应内容: %+v\n", reply)
-
 	return reply, nil
 }
 
@@ -71,12 +65,16 @@ func (s *RealWorldService) GetCurrentUser(ctx context.Context, req *v1.GetCurren
 
 // UpdateUser 方法
 func (s *RealWorldService) UpdateUser(ctx context.Context, req *v1.UpdateUserRequest) (reply *v1.UserReply, err error) {
+	if req.User == nil {
+		return nil, errors.New(422, "user", "cannot be empty")
+	}
 	u, err := s.uc.UpdateUser(ctx, &biz.UserUpdate{
-		Email:    req.User.GetEmail(),
-		Username: req.User.GetUsername(),
-		Password: req.User.GetPassword(),
-		Bio:      req.User.GetBio(),
-		Image:    req.User.GetImage(),
+		Email:           req.User.Email,
+		Username:        req.User.Username,
+		Password:        req.User.Password,
+		Bio:             req.User.Bio,
+		Image:           req.User.Image,
+		CurrentPassword: req.User.GetCurrentPassword(),
 	})
 	if err != nil {
 		return nil, err
@@ -90,3 +88,35 @@ func (s *RealWorldService) UpdateUser(ctx context.Context, req *v1.UpdateUserReq
 		},
 	}, nil
 }
+
+// VerifyEmail 方法
+func (s *RealWorldService) VerifyEmail(ctx context.Context, req *v1.VerifyEmailRequest) (reply *v1.VerifyEmailReply, err error) {
+	if err := s.uc.VerifyEmail(ctx, req.Token); err != nil {
+		return nil, err
+	}
+	return &v1.VerifyEmailReply{}, nil
+}
+
+// ResendVerification 方法
+func (s *RealWorldService) ResendVerification(ctx context.Context, req *v1.ResendVerificationRequest) (reply *v1.VerifyEmailReply, err error) {
+	if err := s.uc.ResendVerification(ctx); err != nil {
+		return nil, err
+	}
+	return &v1.VerifyEmailReply{}, nil
+}
+
+// RequestPasswordReset 方法
+func (s *RealWorldService) RequestPasswordReset(ctx context.Context, req *v1.PasswordResetRequest) (reply *v1.PasswordResetReply, err error) {
+	if err := s.uc.RequestPasswordReset(ctx, req.User.GetEmail()); err != nil {
+		return nil, err
+	}
+	return &v1.PasswordResetReply{}, nil
+}
+
+// ConfirmPasswordReset 方法
+func (s *RealWorldService) ConfirmPasswordReset(ctx context.Context, req *v1.ConfirmPasswordResetRequest) (reply *v1.PasswordResetReply, err error) {
+	if err := s.uc.ResetPassword(ctx, req.Token, req.User.GetPassword()); err != nil {
+		return nil, err
+	}
+	return &v1.PasswordResetReply{}, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	v1 "realworld_demo/api/realworld/v1"
+	"realworld_demo/internal/biz"
+)
+
+func convertWebhook(do *biz.Webhook) *v1.Webhook {
+	return &v1.Webhook{
+		Id:        uint32(do.ID),
+		Url:       do.URL,
+		Events:    do.Events,
+		Global:    do.Global,
+		CreatedAt: timestamppb.New(do.CreatedAt),
+	}
+}
+
+func convertWebhookDelivery(do *biz.WebhookDelivery) *v1.WebhookDelivery {
+	d := &v1.WebhookDelivery{
+		Id:             uint32(do.ID),
+		EventId:        uint32(do.EventID),
+		EventType:      do.EventType,
+		Status:         do.Status,
+		Attempts:       uint32(do.Attempts),
+		ResponseStatus: uint32(do.ResponseStatus),
+		ResponseBody:   do.ResponseBody,
+		Error:          do.Error,
+		DurationMs:     uint32(do.Duration.Milliseconds()),
+		CreatedAt:      timestamppb.New(do.CreatedAt),
+		UpdatedAt:      timestamppb.New(do.UpdatedAt),
+	}
+	if do.Status == biz.WebhookDeliveryPending {
+		d.NextAttemptAt = timestamppb.New(do.NextAttemptAt)
+	}
+	return d
+}
+
+func (s *RealWorldService) CreateWebhook(ctx context.Context, req *v1.CreateWebhookRequest) (reply *v1.WebhookReply, err error) {
+	w, err := s.wc.Create(ctx, &biz.Webhook{
+		URL:    req.GetWebhook().GetUrl(),
+		Events: req.GetWebhook().GetEvents(),
+		Global: req.GetWebhook().GetGlobal(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	rv := convertWebhook(w)
+	rv.Secret = &w.Secret
+	return &v1.WebhookReply{Webhook: rv}, nil
+}
+
+func (s *RealWorldService) ListWebhooks(ctx context.Context, req *v1.ListWebhooksRequest) (reply *v1.MultipleWebhooksReply, err error) {
+	rv, err := s.wc.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]*v1.Webhook, 0, len(rv))
+	for _, x := range rv {
+		webhooks = append(webhooks, convertWebhook(x))
+	}
+	return &v1.MultipleWebhooksReply{Webhooks: webhooks}, nil
+}
+
+func (s *RealWorldService) DeleteWebhook(ctx context.Context, req *v1.DeleteWebhookRequest) (reply *v1.DeleteWebhookReply, err error) {
+	if err := s.wc.Delete(ctx, uint(req.Id)); err != nil {
+		return nil, err
+	}
+	return &v1.DeleteWebhookReply{}, nil
+}
+
+func (s *RealWorldService) ListWebhookDeliveries(ctx context.Context, req *v1.ListWebhookDeliveriesRequest) (reply *v1.MultipleWebhookDeliveriesReply, err error) {
+	rv, err := s.wc.ListDeliveries(ctx, uint(req.Id), req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]*v1.WebhookDelivery, 0, len(rv))
+	for _, x := range rv {
+		deliveries = append(deliveries, convertWebhookDelivery(x))
+	}
+	return &v1.MultipleWebhookDeliveriesReply{Deliveries: deliveries}, nil
+}
+
+func (s *RealWorldService) TestWebhook(ctx context.Context, req *v1.TestWebhookRequest) (reply *v1.WebhookDeliveryReply, err error) {
+	d, err := s.wc.Test(ctx, uint(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	return &v1.WebhookDeliveryReply{Delivery: convertWebhookDelivery(d)}, nil
+}